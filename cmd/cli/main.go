@@ -7,6 +7,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -26,9 +27,11 @@ const (
 
 // Config represents CLI configuration
 type Config struct {
-	ServerURL string `json:"server_url"`
-	APIKey    string `json:"api_key,omitempty"`
-	Username  string `json:"username,omitempty"`
+	ServerURL    string `json:"server_url"`
+	APIKey       string `json:"api_key,omitempty"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Username     string `json:"username,omitempty"`
 }
 
 // API Models matching your server
@@ -69,8 +72,49 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	APIKey string `json:"api_key"`
-	User   User   `json:"user"`
+	User User `json:"user"`
+	TokenResponse
+
+	// Set instead of the tokens when the account requires a two-factor code
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+type LoginTwoFactorRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// DeviceAuthorizationResponse starts a browser/device code login (RFC 8628)
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// OAuthTokenResponse is returned by the token endpoint once a device login is approved
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 type CreateUserRequest struct {
@@ -119,8 +163,31 @@ const (
 	taskDetailView
 	userProfileView
 	organizationView
+	twoFactorView
+	deviceLoginView
 )
 
+// deviceClientID identifies the CLI to the device authorization endpoint and in the session list
+const deviceClientID = "go-task-cli"
+
+// Messages for the device code login, which polls the server in the background
+type deviceCodeMsg struct {
+	device *DeviceAuthorizationResponse
+	err    error
+}
+
+type devicePollMsg struct {
+	deviceCode string
+}
+
+type deviceTokenMsg struct {
+	deviceCode string
+	tokens     *OAuthTokenResponse
+	user       *User
+	errCode    string
+	err        error
+}
+
 // Main model
 type Model struct {
 	state  state
@@ -136,6 +203,11 @@ type Model struct {
 	// Data
 	tasks        []Task
 	selectedTask *Task
+	mfaToken     string
+
+	// Pending device code login
+	device         *DeviceAuthorizationResponse
+	deviceInterval time.Duration
 
 	// Messages
 	message  string
@@ -144,9 +216,14 @@ type Model struct {
 
 // API Client
 type APIClient struct {
-	baseURL string
-	apiKey  string
-	client  *http.Client
+	baseURL      string
+	apiKey       string
+	accessToken  string
+	refreshToken string
+	client       *http.Client
+
+	// onTokensRefreshed is called after the access token was renewed so it can be persisted
+	onTokensRefreshed func(accessToken, refreshToken string)
 }
 
 func NewAPIClient(baseURL, apiKey string) *APIClient {
@@ -157,27 +234,99 @@ func NewAPIClient(baseURL, apiKey string) *APIClient {
 	}
 }
 
+// NewTokenClient creates a client that authenticates with an access token and renews it when it expires
+func NewTokenClient(baseURL, accessToken, refreshToken string, onRefreshed func(accessToken, refreshToken string)) *APIClient {
+	c := NewAPIClient(baseURL, "")
+	c.accessToken = accessToken
+	c.refreshToken = refreshToken
+	c.onTokensRefreshed = onRefreshed
+	return c
+}
+
 func (c *APIClient) makeRequest(method, endpoint string, body interface{}) (*http.Response, error) {
-	var buf bytes.Buffer
+	var payload []byte
 	if body != nil {
+		var buf bytes.Buffer
 		if err := json.NewEncoder(&buf).Encode(body); err != nil {
 			return nil, err
 		}
+		payload = buf.Bytes()
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+endpoint, &buf)
+	resp, err := c.doRequest(method, endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// Renew an expired access token once and retry the request
+	if resp.StatusCode == http.StatusUnauthorized && c.refreshToken != "" && endpoint != "/token/refresh" {
+		resp.Body.Close()
+		if err := c.refresh(); err != nil {
+			return nil, err
+		}
+		return c.doRequest(method, endpoint, payload)
+	}
+
+	return resp, nil
+}
+
+func (c *APIClient) doRequest(method, endpoint string, payload []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.baseURL+endpoint, bytes.NewReader(payload))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if c.apiKey != "" {
+	req.Header.Set("X-Client-Name", deviceClientID)
+	if c.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	} else if c.apiKey != "" {
 		req.Header.Set("Authorization", "ApiKey "+c.apiKey)
 	}
 
 	return c.client.Do(req)
 }
 
+func (c *APIClient) refresh() error {
+	resp, err := c.doRequest("POST", "/token/refresh", mustJSON(RefreshTokenRequest{RefreshToken: c.refreshToken}))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("session expired, please log in again")
+	}
+
+	var tokens TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return err
+	}
+
+	c.accessToken = tokens.Token
+	c.refreshToken = tokens.RefreshToken
+	if c.onTokensRefreshed != nil {
+		c.onTokensRefreshed(tokens.Token, tokens.RefreshToken)
+	}
+
+	return nil
+}
+
+func (c *APIClient) Logout() error {
+	resp, err := c.makeRequest("POST", "/logout", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("logout failed: %s", string(body))
+	}
+
+	return nil
+}
+
 func (c *APIClient) Login(username, password string) (*LoginResponse, error) {
 	loginReq := LoginRequest{Username: username, Password: password}
 
@@ -200,6 +349,94 @@ func (c *APIClient) Login(username, password string) (*LoginResponse, error) {
 	return &loginResp, nil
 }
 
+// postForm sends a form-encoded request, as the OAuth endpoints expect
+func (c *APIClient) postForm(endpoint string, form url.Values) (*http.Response, error) {
+	req, err := http.NewRequest("POST", c.baseURL+endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.client.Do(req)
+}
+
+// StartDeviceLogin requests a device code and the user code to show to the user
+func (c *APIClient) StartDeviceLogin() (*DeviceAuthorizationResponse, error) {
+	resp, err := c.postForm("/oauth/device/code", url.Values{"client_id": {deviceClientID}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("device login failed: %s", string(body))
+	}
+
+	var device DeviceAuthorizationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&device); err != nil {
+		return nil, err
+	}
+
+	return &device, nil
+}
+
+// PollDeviceToken asks whether a device login was approved. While it is not, the OAuth
+// error code (such as authorization_pending or slow_down) is returned instead of tokens.
+func (c *APIClient) PollDeviceToken(deviceCode string) (*OAuthTokenResponse, string, error) {
+	resp, err := c.postForm("/oauth/token", url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {deviceCode},
+		"client_id":   {deviceClientID},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr OAuthErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&oauthErr); err != nil || oauthErr.Error == "" {
+			return nil, "", fmt.Errorf("token request failed with status %d", resp.StatusCode)
+		}
+		return nil, oauthErr.Error, nil
+	}
+
+	var tokens OAuthTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, "", err
+	}
+
+	return &tokens, "", nil
+}
+
+// LoginTwoFactor completes a login that requires a second factor, using a TOTP or recovery code
+func (c *APIClient) LoginTwoFactor(mfaToken, code string) (*LoginResponse, error) {
+	req := LoginTwoFactorRequest{MFAToken: mfaToken}
+	if strings.Contains(code, "-") || len(code) > 6 {
+		req.RecoveryCode = code
+	} else {
+		req.Code = code
+	}
+
+	resp, err := c.makeRequest("POST", "/login/2fa", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("verification failed: %s", string(body))
+	}
+
+	var loginResp LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&loginResp); err != nil {
+		return nil, err
+	}
+
+	return &loginResp, nil
+}
+
 func (c *APIClient) Register(username, password string, age *int, gender *string) (*User, error) {
 	createReq := CreateUserRequest{
 		Username: username,
@@ -352,7 +589,7 @@ func initialModel() Model {
 	config := loadConfig()
 
 	// Create text inputs
-	inputs := make([]textinput.Model, 4)
+	inputs := make([]textinput.Model, 5)
 
 	// Username input
 	inputs[0] = textinput.New()
@@ -379,6 +616,13 @@ func initialModel() Model {
 	inputs[3].CharLimit = 500
 	inputs[3].Width = 50
 
+	// Two-factor code input
+	inputs[4] = textinput.New()
+	inputs[4].Placeholder = "123456 or recovery code"
+	inputs[4].CharLimit = 20
+	inputs[4].PromptStyle = focusedStyle
+	inputs[4].TextStyle = focusedStyle
+
 	// Create list
 	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
 	l.Title = "📋 Server Tasks"
@@ -392,7 +636,10 @@ func initialModel() Model {
 	}
 
 	// Determine initial state
-	if config.APIKey != "" {
+	if config.AccessToken != "" {
+		model.state = menuView
+		model.client = newSessionClient(config)
+	} else if config.APIKey != "" {
 		model.state = menuView
 		model.client = NewAPIClient(config.ServerURL, config.APIKey)
 	} else {
@@ -408,6 +655,9 @@ func (m Model) Init() tea.Cmd {
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case deviceCodeMsg, devicePollMsg, deviceTokenMsg:
+		return m.updateDeviceLogin(msg)
+
 	case tea.KeyMsg:
 		switch m.state {
 		case loginView:
@@ -426,6 +676,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateTaskDetailView(msg)
 		case userProfileView:
 			return m.updateUserProfileView(msg)
+		case twoFactorView:
+			return m.updateTwoFactorView(msg)
+		case deviceLoginView:
+			return m.updateDeviceLoginView(msg)
 		}
 
 	case tea.WindowSizeMsg:
@@ -461,18 +715,17 @@ func (m Model) updateLoginView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
-			// Save config and update state
-			m.config.APIKey = loginResp.APIKey
-			m.config.Username = username
-			saveConfig(m.config)
-
-			m.client = NewAPIClient(m.config.ServerURL, loginResp.APIKey)
-			m.user = &loginResp.User
-			m.state = menuView
-			m.message = "Login successful!"
-			m.errorMsg = ""
+			// Ask for the second factor before any tokens are issued
+			if loginResp.MFARequired {
+				m.mfaToken = loginResp.MFAToken
+				m.state = twoFactorView
+				m.textInputs[4].SetValue("")
+				m.textInputs[4].Focus()
+				m.errorMsg = ""
+				return m, nil
+			}
 
-			return m, nil
+			return m.completeLogin(username, loginResp), nil
 		}
 
 		if s == "up" || s == "shift+tab" {
@@ -505,6 +758,11 @@ func (m Model) updateLoginView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.focusIndex = 0
 		m.errorMsg = ""
 		return m, nil
+
+	case "ctrl+d":
+		m.errorMsg = ""
+		m.message = "Requesting a device code..."
+		return m, startDeviceLogin(m.config.ServerURL)
 	}
 
 	var cmd tea.Cmd
@@ -512,6 +770,159 @@ func (m Model) updateLoginView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// startDeviceLogin requests a device code in the background
+func startDeviceLogin(serverURL string) tea.Cmd {
+	return func() tea.Msg {
+		device, err := NewAPIClient(serverURL, "").StartDeviceLogin()
+		return deviceCodeMsg{device: device, err: err}
+	}
+}
+
+// scheduleDevicePoll waits for the polling interval before asking the server again
+func scheduleDevicePoll(deviceCode string, interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return devicePollMsg{deviceCode: deviceCode}
+	})
+}
+
+// pollDeviceToken checks once whether the device login was approved, and loads the user if it was
+func pollDeviceToken(serverURL, deviceCode string) tea.Cmd {
+	return func() tea.Msg {
+		tokens, errCode, err := NewAPIClient(serverURL, "").PollDeviceToken(deviceCode)
+		if err != nil || tokens == nil {
+			return deviceTokenMsg{deviceCode: deviceCode, errCode: errCode, err: err}
+		}
+
+		user, err := NewTokenClient(serverURL, tokens.AccessToken, "", nil).GetUser()
+		return deviceTokenMsg{deviceCode: deviceCode, tokens: tokens, user: user, err: err}
+	}
+}
+
+// updateDeviceLogin drives the device code login: show the code, then poll until the login is
+// approved, denied or expires. Messages from a cancelled login are ignored.
+func (m Model) updateDeviceLogin(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case deviceCodeMsg:
+		m.message = ""
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("Device login failed: %v", msg.err)
+			return m, nil
+		}
+
+		m.device = msg.device
+		m.deviceInterval = time.Duration(msg.device.Interval) * time.Second
+		if m.deviceInterval <= 0 {
+			m.deviceInterval = 5 * time.Second
+		}
+		m.state = deviceLoginView
+		return m, scheduleDevicePoll(m.device.DeviceCode, m.deviceInterval)
+
+	case devicePollMsg:
+		if m.state != deviceLoginView || m.device == nil || msg.deviceCode != m.device.DeviceCode {
+			return m, nil
+		}
+		return m, pollDeviceToken(m.config.ServerURL, msg.deviceCode)
+
+	case deviceTokenMsg:
+		if m.state != deviceLoginView || m.device == nil || msg.deviceCode != m.device.DeviceCode {
+			return m, nil
+		}
+
+		switch {
+		case msg.err != nil:
+			m.errorMsg = fmt.Sprintf("Device login failed: %v", msg.err)
+		case msg.errCode == "authorization_pending":
+			return m, scheduleDevicePoll(msg.deviceCode, m.deviceInterval)
+		case msg.errCode == "slow_down":
+			m.deviceInterval += 5 * time.Second
+			return m, scheduleDevicePoll(msg.deviceCode, m.deviceInterval)
+		case msg.errCode == "access_denied":
+			m.errorMsg = "The login was denied"
+		case msg.errCode == "expired_token":
+			m.errorMsg = "The code expired, please try again"
+		case msg.tokens != nil && msg.user != nil:
+			m.device = nil
+			return m.completeLogin(msg.user.Username, &LoginResponse{
+				User: *msg.user,
+				TokenResponse: TokenResponse{
+					Token:        msg.tokens.AccessToken,
+					RefreshToken: msg.tokens.RefreshToken,
+				},
+			}), nil
+		default:
+			m.errorMsg = fmt.Sprintf("Device login failed: %s", msg.errCode)
+		}
+
+		m.device = nil
+		m.state = loginView
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func (m Model) updateDeviceLoginView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.device = nil
+		m.state = loginView
+		m.errorMsg = ""
+		return m, nil
+	}
+	return m, nil
+}
+
+// completeLogin saves the session tokens and switches to the main menu
+func (m Model) completeLogin(username string, loginResp *LoginResponse) Model {
+	m.config.AccessToken = loginResp.Token
+	m.config.RefreshToken = loginResp.RefreshToken
+	m.config.Username = username
+	saveConfig(m.config)
+
+	m.client = newSessionClient(m.config)
+	m.user = &loginResp.User
+	m.mfaToken = ""
+	m.state = menuView
+	m.message = "Login successful!"
+	m.errorMsg = ""
+
+	return m
+}
+
+func (m Model) updateTwoFactorView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch keypress := msg.String(); keypress {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.state = loginView
+		m.mfaToken = ""
+		m.errorMsg = ""
+		return m, nil
+	case "enter":
+		code := strings.TrimSpace(m.textInputs[4].Value())
+		if code == "" {
+			m.errorMsg = "Please enter your authentication code"
+			return m, nil
+		}
+
+		client := NewAPIClient(m.config.ServerURL, "")
+		loginResp, err := client.LoginTwoFactor(m.mfaToken, code)
+		if err != nil {
+			m.errorMsg = fmt.Sprintf("Login failed: %v", err)
+			m.textInputs[4].SetValue("")
+			return m, nil
+		}
+
+		return m.completeLogin(m.textInputs[0].Value(), loginResp), nil
+	}
+
+	var cmd tea.Cmd
+	m.textInputs[4], cmd = m.textInputs[4].Update(msg)
+	return m, cmd
+}
+
 func (m Model) updateRegisterView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch keypress := msg.String(); keypress {
 	case "ctrl+c":
@@ -547,8 +958,13 @@ func (m Model) updateMenuView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.state = organizationView
 		return m, nil
 	case "l":
-		// Logout
+		// Logout, revoking the server-side session when logged in with a token
+		if m.client != nil && m.config.AccessToken != "" {
+			m.client.Logout()
+		}
 		m.config.APIKey = ""
+		m.config.AccessToken = ""
+		m.config.RefreshToken = ""
 		m.config.Username = ""
 		saveConfig(m.config)
 		m.state = loginView
@@ -793,6 +1209,10 @@ func (m Model) View() string {
 		return m.taskDetailView()
 	case userProfileView:
 		return m.userProfileView()
+	case twoFactorView:
+		return m.twoFactorView()
+	case deviceLoginView:
+		return m.deviceLoginView()
 	}
 	return ""
 }
@@ -822,7 +1242,45 @@ func (m Model) loginView() string {
 		content.WriteString("\n\n")
 	}
 
-	content.WriteString(helpStyle("(enter) login • (r) register • (ctrl+c) quit"))
+	content.WriteString(helpStyle("(enter) login • (ctrl+d) login with browser/device code • (r) register • (ctrl+c) quit"))
+
+	return appStyle.Render(content.String())
+}
+
+func (m Model) twoFactorView() string {
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("🔑 Two-Factor Authentication"))
+	content.WriteString("\n\n")
+	content.WriteString("Enter the code from your authenticator app, or a recovery code:\n")
+	content.WriteString(m.textInputs[4].View())
+	content.WriteString("\n\n")
+
+	if m.errorMsg != "" {
+		content.WriteString(errorMessageStyle(m.errorMsg))
+		content.WriteString("\n\n")
+	}
+
+	content.WriteString(helpStyle("(enter) verify • (esc) back • (ctrl+c) quit"))
+
+	return appStyle.Render(content.String())
+}
+
+func (m Model) deviceLoginView() string {
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("🌐 Login with Browser"))
+	content.WriteString("\n\n")
+	content.WriteString("Open this page in a browser:\n")
+	content.WriteString(m.device.VerificationURIComplete)
+	content.WriteString("\n\n")
+	content.WriteString("and confirm this code:\n")
+	content.WriteString(focusedStyle.Render(m.device.UserCode))
+	content.WriteString("\n\n")
+	content.WriteString(statusMessageStyle("Waiting for approval..."))
+	content.WriteString("\n\n")
+
+	content.WriteString(helpStyle("(esc) cancel • (ctrl+c) quit"))
 
 	return appStyle.Render(content.String())
 }
@@ -1071,6 +1529,20 @@ func (m *Model) updateTaskList() {
 	m.list.SetItems(items)
 }
 
+// newSessionClient creates a token client that persists renewed tokens to the config file
+func newSessionClient(config Config) *APIClient {
+	return NewTokenClient(config.ServerURL, config.AccessToken, config.RefreshToken, func(accessToken, refreshToken string) {
+		config.AccessToken = accessToken
+		config.RefreshToken = refreshToken
+		saveConfig(config)
+	})
+}
+
+func mustJSON(v interface{}) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
+
 // Config functions
 func loadConfig() Config {
 	config := Config{
@@ -0,0 +1,85 @@
+// Command passwordreport counts the accounts whose password hashes still use weaker
+// argon2 parameters or an old pepper than the current configuration. Those accounts are
+// upgraded automatically on their next successful login.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/omed0/go-hello-world/handlers"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/config"
+	"github.com/omed0/go-hello-world/internal/database"
+
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	verbose := flag.Bool("v", false, "list the usernames still on old parameters")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+
+	passwords, err := handlers.NewPasswordConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid password configuration: %v", err)
+	}
+
+	db, err := handlers.InstanceDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %v", err)
+	}
+	defer handlers.CloseDB()
+
+	users, err := database.New(db).ListUserPasswordHashes(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to list users: %v", err)
+	}
+
+	// Group outdated hashes by their parameter string
+	outdated := map[string][]string{}
+	total := 0
+	for _, user := range users {
+		if auth.NeedsRehash(user.PasswordHash, passwords) {
+			params := auth.HashParameters(user.PasswordHash)
+			outdated[params] = append(outdated[params], user.Username)
+			total++
+		}
+	}
+
+	current := fmt.Sprintf("m=%d,t=%d,p=%d", passwords.Memory, passwords.Time, passwords.Threads)
+	if passwords.PepperID != "" {
+		current += ",kid=" + passwords.PepperID
+	}
+
+	fmt.Printf("Current parameters: %s\n", current)
+	fmt.Printf("Accounts with passwords: %d, on old parameters: %d\n", len(users), total)
+	if total == 0 {
+		return
+	}
+
+	params := make([]string, 0, len(outdated))
+	for p := range outdated {
+		params = append(params, p)
+	}
+	sort.Strings(params)
+
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PARAMETERS\tACCOUNTS")
+	for _, p := range params {
+		fmt.Fprintf(tw, "%s\t%d\n", p, len(outdated[p]))
+		if *verbose {
+			for _, username := range outdated[p] {
+				fmt.Fprintf(tw, "  %s\t\n", username)
+			}
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/internal/notify"
+	"github.com/omed0/go-hello-world/internal/tracing"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// Auth events logged for account deletion
+const (
+	authEventAccountDeletionScheduled = "account_deletion_scheduled"
+	authEventAccountDeletionCancelled = "account_deletion_cancelled"
+	authEventAccountDeleted           = "account_deleted"
+)
+
+// accountDeletionBatchSize limits how many due deletions are carried out per check
+const accountDeletionBatchSize = 100
+
+// soleOwnerError is returned when deleting an account would leave organizations without an owner
+type soleOwnerError struct {
+	organizations []string
+}
+
+func (e *soleOwnerError) Error() string {
+	return "you are the only owner of " + strings.Join(e.organizations, ", ") + "; transfer ownership first"
+}
+
+// HandlerDeleteAccount schedules the current user's account for deletion after the configured grace period,
+// or deletes it right away without one. The password confirms the request.
+func (api *ApiConfig) HandlerDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	var params models.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	user, err := api.Queries.GetUserByID(r.Context(), principal.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get user details")
+		return
+	}
+
+	valid, err := api.verifyPassword(r.Context(), params.Password, user.PasswordHash)
+	if err != nil || !valid {
+		RespondWithError(w, http.StatusForbidden, "Password is incorrect")
+		return
+	}
+
+	// Validate how tasks are handled
+	if params.TaskAction == "" {
+		params.TaskAction = models.TaskActionAnonymize
+	}
+	transferTo := uuid.NullUUID{}
+	switch params.TaskAction {
+	case models.TaskActionDelete, models.TaskActionAnonymize:
+		if params.TransferTo != nil {
+			RespondWithError(w, http.StatusBadRequest, "transfer_to is only used with the transfer task action")
+			return
+		}
+	case models.TaskActionTransfer:
+		if params.TransferTo == nil {
+			RespondWithError(w, http.StatusBadRequest, "transfer_to is required to transfer tasks")
+			return
+		}
+		if errMsg := api.checkTaskRecipient(r.Context(), principal.UserID, *params.TransferTo); errMsg != "" {
+			RespondWithError(w, http.StatusBadRequest, errMsg)
+			return
+		}
+		transferTo = uuid.NullUUID{UUID: *params.TransferTo, Valid: true}
+	default:
+		RespondWithError(w, http.StatusBadRequest, "task_action must be delete, anonymize or transfer")
+		return
+	}
+
+	soleOwned, err := api.soleOwnedOrganizations(r.Context(), api.Queries, principal.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to check organization ownership")
+		return
+	}
+	if soleOwned != nil {
+		RespondWithError(w, http.StatusConflict, soleOwned.Error())
+		return
+	}
+
+	deletion := database.AccountDeletion{
+		UserID:       principal.UserID,
+		TaskAction:   params.TaskAction,
+		TransferTo:   transferTo,
+		ScheduledFor: time.Now().UTC().Add(api.Config.AccountDeletionGracePeriod),
+	}
+
+	// Without a grace period there is nothing to cancel, so the account goes right away
+	if api.Config.AccountDeletionGracePeriod <= 0 {
+		if err := api.deleteAccount(r.Context(), api.requestActor(r), deletion, user); err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Failed to delete account")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	deletion, err = api.Queries.ScheduleAccountDeletion(r.Context(), database.ScheduleAccountDeletionParams{
+		UserID:       deletion.UserID,
+		TaskAction:   deletion.TaskAction,
+		TransferTo:   deletion.TransferTo,
+		ScheduledFor: deletion.ScheduledFor,
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to schedule account deletion")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventAccountDeletionScheduled, loginAttempt{
+		username: user.Username,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: user.ID, Valid: true},
+	}, "tasks "+deletion.TaskAction+", scheduled for "+deletion.ScheduledFor.Format(time.RFC3339))
+
+	if err := api.Notifier.Send(r.Context(), notify.Message{
+		UserID:    user.ID,
+		Recipient: user.Username,
+		Subject:   "Account deletion scheduled",
+		Body: fmt.Sprintf("Your account will be deleted on %s. Cancel with DELETE /v1/user/deletion before then to keep it.",
+			deletion.ScheduledFor.Format(time.RFC3339)),
+	}); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to send account deletion notice", "username", user.Username, "error", err)
+	}
+
+	RespondWithJSON(w, http.StatusAccepted, models.DatabaseAccountDeletionToAccountDeletion(deletion))
+}
+
+// HandlerGetAccountDeletion shows the current user's scheduled account deletion
+func (api *ApiConfig) HandlerGetAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	deletion, err := api.Queries.GetAccountDeletion(r.Context(), principal.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "No account deletion is scheduled")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get account deletion")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.DatabaseAccountDeletionToAccountDeletion(deletion))
+}
+
+// HandlerCancelAccountDeletion keeps the current user's account by cancelling its scheduled deletion
+func (api *ApiConfig) HandlerCancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	cancelled, err := api.Queries.CancelAccountDeletion(r.Context(), principal.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to cancel account deletion")
+		return
+	}
+	if cancelled == 0 {
+		RespondWithError(w, http.StatusNotFound, "No account deletion is scheduled")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventAccountDeletionCancelled, loginAttempt{
+		username: principal.Username,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: principal.UserID, Valid: true},
+	}, "")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerExportAccount returns everything stored about the current user as a downloadable JSON archive:
+// the profile, every task including deleted ones, and organization memberships
+func (api *ApiConfig) HandlerExportAccount(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	user, err := api.Queries.GetUserByID(r.Context(), principal.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get user details")
+		return
+	}
+
+	tasks, err := api.Queries.GetAllTasksByUserId(r.Context(), uuid.NullUUID{UUID: principal.UserID, Valid: true})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get tasks")
+		return
+	}
+
+	rows, err := api.Queries.ListUserMemberships(r.Context(), principal.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get organizations")
+		return
+	}
+	memberships := make([]models.Membership, len(rows))
+	for i, row := range rows {
+		memberships[i] = models.DatabaseMembershipRowToMembership(row, user.OrganizationID)
+	}
+
+	export := models.AccountExport{
+		ExportedAt:  time.Now().UTC(),
+		Profile:     models.DatabaseUserRowToUser(user),
+		Tasks:       models.DatabaseTasksToTasks(tasks),
+		Memberships: memberships,
+	}
+
+	deletion, err := api.Queries.GetAccountDeletion(r.Context(), principal.UserID)
+	if err == nil {
+		scheduled := models.DatabaseAccountDeletionToAccountDeletion(deletion)
+		export.Deletion = &scheduled
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get account deletion")
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "account-export-"+user.Username+".json"))
+	RespondWithJSON(w, http.StatusOK, export)
+}
+
+// RunAccountDeletions deletes accounts whose grace period has ended, checking at the configured interval
+// until the context is cancelled. A non-positive interval disables the check.
+func (api *ApiConfig) RunAccountDeletions(ctx context.Context) {
+	if api.Config.AccountDeletionCheckInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(api.Config.AccountDeletionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		api.deleteDueAccounts(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deleteDueAccounts carries out one batch of due account deletions. Accounts that are still the only owner
+// of an organization stay scheduled until ownership is transferred or the deletion is cancelled.
+func (api *ApiConfig) deleteDueAccounts(ctx context.Context) {
+	due, err := api.Queries.ListDueAccountDeletions(ctx, accountDeletionBatchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list due account deletions", "error", err)
+		return
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	ctx, span := api.Tracer.Start(ctx, "delete due accounts", tracing.SpanKindInternal, tracing.Int("accounts", len(due)))
+	defer span.End()
+
+	for _, deletion := range due {
+		user, err := api.Queries.GetUserByID(ctx, deletion.UserID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get account for deletion", "user_id", deletion.UserID, "error", err)
+			continue
+		}
+
+		err = api.deleteAccount(ctx, systemActor, deletion, user)
+		var soleOwner *soleOwnerError
+		if errors.As(err, &soleOwner) {
+			// Became the only owner after the batch was listed; it is skipped until that changes
+			slog.WarnContext(ctx, "Account deletion waits for an ownership transfer", "username", user.Username, "organizations", soleOwner.organizations)
+			continue
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to delete account", "username", user.Username, "error", err)
+		}
+	}
+}
+
+// deleteAccount removes an account and handles its tasks as requested. A task recipient who no longer
+// shares an organization with the account gets nothing and the tasks are anonymized instead.
+func (api *ApiConfig) deleteAccount(ctx context.Context, actor auditActor, deletion database.AccountDeletion, user database.GetUserByIDRow) error {
+	owner := uuid.NullUUID{UUID: deletion.UserID, Valid: true}
+	taskAction := deletion.TaskAction
+	var tasks int64
+
+	err := api.withTx(ctx, func(q *database.Queries) error {
+		soleOwned, err := api.soleOwnedOrganizations(ctx, q, deletion.UserID)
+		if err != nil {
+			return err
+		}
+		if soleOwned != nil {
+			return soleOwned
+		}
+
+		if taskAction == models.TaskActionTransfer {
+			shared := false
+			if deletion.TransferTo.Valid {
+				shared, err = q.SharesOrganization(ctx, database.SharesOrganizationParams{
+					UserID:      deletion.UserID,
+					OtherUserID: deletion.TransferTo.UUID,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if !shared {
+				taskAction = models.TaskActionAnonymize
+			}
+		}
+
+		switch taskAction {
+		case models.TaskActionTransfer:
+			tasks, err = q.TransferUserTasks(ctx, database.TransferUserTasksParams{FromUserID: owner, ToUserID: deletion.TransferTo})
+		case models.TaskActionAnonymize:
+			tasks, err = q.AnonymizeUserTasks(ctx, owner)
+		default:
+			tasks, err = q.DeleteUserTasks(ctx, owner)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.DeleteUser(ctx, deletion.UserID); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, actor, auditChange{
+			OrganizationID: user.OrganizationID,
+			Action:         auditUserDeleted,
+			TargetType:     auditTargetUser,
+			TargetID:       user.ID,
+			Before:         models.DatabaseUserRowToUser(user),
+			After:          map[string]any{"tasks": taskActionPastTense(taskAction), "task_count": tasks},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// The account is gone, so the event is recorded by username only
+	api.logAuthEvent(ctx, authEventAccountDeleted, loginAttempt{username: user.Username},
+		fmt.Sprintf("user %s, %d tasks %s", deletion.UserID, tasks, taskActionPastTense(taskAction)))
+	return nil
+}
+
+// soleOwnedOrganizations returns an error naming the organizations the user is the only owner of, or nil
+func (api *ApiConfig) soleOwnedOrganizations(ctx context.Context, q *database.Queries, userID uuid.UUID) (*soleOwnerError, error) {
+	orgs, err := q.ListSoleOwnedOrganizations(ctx, userID)
+	if err != nil || len(orgs) == 0 {
+		return nil, err
+	}
+
+	names := make([]string, len(orgs))
+	for i, org := range orgs {
+		names[i] = org.Name
+	}
+	return &soleOwnerError{organizations: names}, nil
+}
+
+// checkTaskRecipient checks that tasks can be handed to the recipient, returning an error message if not
+func (api *ApiConfig) checkTaskRecipient(ctx context.Context, userID, recipientID uuid.UUID) string {
+	if recipientID == userID {
+		return "transfer_to must be another user"
+	}
+
+	recipient, err := api.Queries.GetUserByID(ctx, recipientID)
+	if err != nil || recipient.AccountType != auth.AccountTypeHuman || recipient.DisabledAt.Valid {
+		return "transfer_to must be an active user"
+	}
+
+	shared, err := api.Queries.SharesOrganization(ctx, database.SharesOrganizationParams{
+		UserID:      userID,
+		OtherUserID: recipientID,
+	})
+	if err != nil || !shared {
+		return "transfer_to must be a member of one of your organizations"
+	}
+	return ""
+}
+
+// taskActionPastTense describes what happened to a deleted account's tasks
+func taskActionPastTense(action string) string {
+	switch action {
+	case models.TaskActionTransfer:
+		return "transferred"
+	case models.TaskActionAnonymize:
+		return "anonymized"
+	default:
+		return "deleted"
+	}
+}
@@ -0,0 +1,455 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/internal/notify"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// Auth events logged for system administration
+const (
+	authEventUserSuspended           = "user_suspended"
+	authEventUserReactivated         = "user_reactivated"
+	authEventKeyRotationRequired     = "api_key_rotation_required"
+	authEventOrganizationHardDeleted = "organization_hard_deleted"
+)
+
+// maxKeyRotationGracePeriod caps how long keys keep working after a forced rotation
+const maxKeyRotationGracePeriod = 30 * 24 * time.Hour
+
+// HandlerAdminGetUsers lists every account, newest first. It filters by search (part of the username),
+// account_type (human or service), status (active or suspended) and organization_id (membership),
+// and pages with limit and offset.
+func (api *ApiConfig) HandlerAdminGetUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, offset := parseLimit(query.Get("limit")), parseOffset(query.Get("offset"))
+
+	search := strings.TrimSpace(query.Get("search"))
+	filter := database.AdminCountUsersParams{
+		Search: sql.NullString{String: search, Valid: search != ""},
+	}
+
+	switch accountType := query.Get("account_type"); accountType {
+	case "":
+	case auth.AccountTypeHuman, auth.AccountTypeService:
+		filter.AccountType = sql.NullString{String: accountType, Valid: true}
+	default:
+		RespondWithError(w, http.StatusBadRequest, "account_type must be human or service")
+		return
+	}
+
+	switch status := query.Get("status"); status {
+	case "":
+	case "active", "suspended":
+		filter.Suspended = sql.NullBool{Bool: status == "suspended", Valid: true}
+	default:
+		RespondWithError(w, http.StatusBadRequest, "status must be active or suspended")
+		return
+	}
+
+	if orgID := query.Get("organization_id"); orgID != "" {
+		id, err := uuid.Parse(orgID)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid organization ID")
+			return
+		}
+		filter.OrganizationID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	rows, err := api.Queries.AdminListUsers(r.Context(), database.AdminListUsersParams{
+		Search:         filter.Search,
+		AccountType:    filter.AccountType,
+		Suspended:      filter.Suspended,
+		OrganizationID: filter.OrganizationID,
+		PageLimit:      int32(limit),
+		PageOffset:     int32(offset),
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get users")
+		return
+	}
+
+	total, err := api.Queries.AdminCountUsers(r.Context(), filter)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get users")
+		return
+	}
+
+	users := make([]models.AdminUser, len(rows))
+	for i, row := range rows {
+		users[i] = models.DatabaseAdminUserRowToAdminUser(row)
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.Page[models.AdminUser]{Items: users, Total: total, Limit: limit, Offset: offset})
+}
+
+// HandlerAdminGetOrganizations lists every organization, newest first. It filters by search (part of the name)
+// and include_deleted, and pages with limit and offset.
+func (api *ApiConfig) HandlerAdminGetOrganizations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, offset := parseLimit(query.Get("limit")), parseOffset(query.Get("offset"))
+
+	search := strings.TrimSpace(query.Get("search"))
+	filter := database.AdminCountOrganizationsParams{
+		Search: sql.NullString{String: search, Valid: search != ""},
+	}
+	if includeDeleted := query.Get("include_deleted"); includeDeleted != "" {
+		var err error
+		if filter.IncludeDeleted, err = strconv.ParseBool(includeDeleted); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "include_deleted must be true or false")
+			return
+		}
+	}
+
+	rows, err := api.Queries.AdminListOrganizations(r.Context(), database.AdminListOrganizationsParams{
+		Search:         filter.Search,
+		IncludeDeleted: filter.IncludeDeleted,
+		PageLimit:      int32(limit),
+		PageOffset:     int32(offset),
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get organizations")
+		return
+	}
+
+	total, err := api.Queries.AdminCountOrganizations(r.Context(), filter)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get organizations")
+		return
+	}
+
+	orgs := make([]models.AdminOrganization, len(rows))
+	for i, row := range rows {
+		orgs[i] = models.DatabaseAdminOrganizationRowToAdminOrganization(row)
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.Page[models.AdminOrganization]{Items: orgs, Total: total, Limit: limit, Offset: offset})
+}
+
+// HandlerAdminSuspendUser stops an account from signing in or using any credential and ends its sessions
+func (api *ApiConfig) HandlerAdminSuspendUser(w http.ResponseWriter, r *http.Request) {
+	api.setUserSuspended(w, r, true)
+}
+
+// HandlerAdminReactivateUser lets a suspended account sign in again; ended sessions stay ended
+func (api *ApiConfig) HandlerAdminReactivateUser(w http.ResponseWriter, r *http.Request) {
+	api.setUserSuspended(w, r, false)
+}
+
+func (api *ApiConfig) setUserSuspended(w http.ResponseWriter, r *http.Request, suspended bool) {
+	admin, user, ok := api.userForSystemAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	if user.ID == admin.UserID {
+		RespondWithError(w, http.StatusBadRequest, "You cannot suspend or reactivate yourself")
+		return
+	}
+
+	// Nothing to do if the account is already in the requested state, which also keeps the original timestamp
+	if user.DisabledAt.Valid == suspended {
+		RespondWithJSON(w, http.StatusOK, models.DatabaseUserToAdminUser(user))
+		return
+	}
+
+	disabledAt := sql.NullTime{}
+	eventType, action := authEventUserReactivated, auditUserReactivated
+	if suspended {
+		disabledAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		eventType, action = authEventUserSuspended, auditUserSuspended
+	}
+
+	before := user
+	err := api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		user, err = q.SetUserDisabledAt(r.Context(), database.SetUserDisabledAtParams{
+			ID:         user.ID,
+			DisabledAt: disabledAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		if suspended {
+			if err := q.RevokeUserSessions(r.Context(), user.ID); err != nil {
+				return err
+			}
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: user.OrganizationID,
+			Action:         action,
+			TargetType:     auditTargetUser,
+			TargetID:       user.ID,
+			Before:         models.DatabaseUserToAdminUser(before),
+			After:          models.DatabaseUserToAdminUser(user),
+		})
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), eventType, loginAttempt{
+		username: user.Username,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: user.ID, Valid: true},
+	}, "by system admin "+admin.Username)
+
+	RespondWithJSON(w, http.StatusOK, models.DatabaseUserToAdminUser(user))
+}
+
+// HandlerAdminRequireKeyRotation gives every active API key of an account a rotation deadline.
+// Keys not rotated through the key rotation endpoint by then stop authenticating.
+func (api *ApiConfig) HandlerAdminRequireKeyRotation(w http.ResponseWriter, r *http.Request) {
+	admin, user, ok := api.userForSystemAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional; without a grace period keys stop working right away
+	var params models.RequireKeyRotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	var grace time.Duration
+	if params.GracePeriod != "" {
+		var err error
+		grace, err = time.ParseDuration(params.GracePeriod)
+		if err != nil || grace < 0 || grace > maxKeyRotationGracePeriod {
+			RespondWithError(w, http.StatusBadRequest, "grace_period must be a duration such as 24h, at most 720h")
+			return
+		}
+	}
+
+	rotateBy := time.Now().UTC().Add(grace)
+	var keys int64
+	err := api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		keys, err = q.RequireUserAPIKeyRotation(r.Context(), database.RequireUserAPIKeyRotationParams{
+			UserID:   user.ID,
+			RotateBy: sql.NullTime{Time: rotateBy, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: user.OrganizationID,
+			Action:         auditAPIKeyRotationRequired,
+			TargetType:     auditTargetUser,
+			TargetID:       user.ID,
+			After:          models.KeyRotationResponse{UserID: user.ID, Keys: keys, RotateBy: rotateBy},
+		})
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to require key rotation")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventKeyRotationRequired, loginAttempt{
+		username: user.Username,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: user.ID, Valid: true},
+	}, fmt.Sprintf("%d keys by %s, by system admin %s", keys, rotateBy.Format(time.RFC3339), admin.Username))
+
+	if keys > 0 && user.AccountType == auth.AccountTypeHuman {
+		if err := api.Notifier.Send(r.Context(), notify.Message{
+			UserID:    user.ID,
+			Recipient: user.Username,
+			Subject:   "API key rotation required",
+			Body: fmt.Sprintf("Rotate your API keys with POST /v1/user/keys/{keyId}/rotate before %s; keys that are not rotated stop working then.",
+				rotateBy.Format(time.RFC3339)),
+		}); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to send key rotation notice", "username", user.Username, "error", err)
+		}
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.KeyRotationResponse{UserID: user.ID, Keys: keys, RotateBy: rotateBy})
+}
+
+// HandlerAdminDeleteOrganization permanently deletes an organization, soft-deleted or not, along with its
+// service accounts. With dry_run=true it only reports what would be removed.
+func (api *ApiConfig) HandlerAdminDeleteOrganization(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organization ID")
+		return
+	}
+
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+	}
+
+	org, err := api.Queries.AdminGetOrganization(r.Context(), orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "Organization not found")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get organization")
+		return
+	}
+
+	if dryRun {
+		counts, err := api.Queries.CountOrganizationDependents(r.Context(), orgID)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Failed to count organization data")
+			return
+		}
+		RespondWithJSON(w, http.StatusOK, models.DatabaseOrganizationDependentsToReport(org, counts, true))
+		return
+	}
+
+	// Service accounts that only belong to this organization go with it. Their keys and certificate mappings
+	// are revoked first so the audit log shows each credential ending, and their tasks are removed because
+	// tasks cannot outlive their owner.
+	var counts database.CountOrganizationDependentsRow
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		if counts, err = q.CountOrganizationDependents(r.Context(), orgID); err != nil {
+			return err
+		}
+		if err := api.removeOrganizationServiceAccounts(r, q, orgID); err != nil {
+			return err
+		}
+		if _, err := q.HardDeleteOrganization(r.Context(), orgID); err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			Action:         auditOrganizationDeleted,
+			TargetType:     auditTargetOrganization,
+			TargetID:       orgID,
+			Before:         models.DatabaseOrganizationDependentsToReport(org, counts, false),
+		})
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to delete organization")
+		return
+	}
+
+	report := models.DatabaseOrganizationDependentsToReport(org, counts, false)
+	api.logAuthEvent(r.Context(), authEventOrganizationHardDeleted, loginAttempt{ip: api.clientIP(r)},
+		fmt.Sprintf("organization %s (%s) with %d members and %d service accounts by system admin %s",
+			org.Name, org.ID, report.Members, report.ServiceAccounts, principal.Username))
+
+	RespondWithJSON(w, http.StatusOK, report)
+}
+
+// removeOrganizationServiceAccounts revokes the credentials of an organization's own service accounts and
+// deletes the accounts with their tasks, auditing each step in the organization's log
+func (api *ApiConfig) removeOrganizationServiceAccounts(r *http.Request, q *database.Queries, orgID uuid.UUID) error {
+	org := uuid.NullUUID{UUID: orgID, Valid: true}
+
+	keys, err := q.RevokeOrganizationServiceAccountKeys(r.Context(), orgID)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := api.audit(r, q, auditChange{
+			OrganizationID: org,
+			Action:         auditAPIKeyRevoked,
+			TargetType:     auditTargetAPIKey,
+			TargetID:       key.ID,
+			Before:         auditAPIKey{APIKey: models.DatabaseAPIKeyToAPIKey(key), UserID: key.UserID},
+		}); err != nil {
+			return err
+		}
+	}
+
+	mappings, err := q.DeleteOrganizationServiceAccountCertificates(r.Context(), orgID)
+	if err != nil {
+		return err
+	}
+	for _, mapping := range mappings {
+		if err := api.audit(r, q, auditChange{
+			OrganizationID: org,
+			Action:         auditCertificateUnmapped,
+			TargetType:     auditTargetClientCertificate,
+			TargetID:       mapping.ID,
+			Before:         models.DatabaseClientCertificateMappingToClientCertificateMapping(mapping, ""),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if _, err := q.DeleteOrganizationServiceAccountTasks(r.Context(), orgID); err != nil {
+		return err
+	}
+
+	accounts, err := q.DeleteOrganizationServiceAccounts(r.Context(), orgID)
+	if err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		if err := api.audit(r, q, auditChange{
+			OrganizationID: org,
+			Action:         auditServiceAccountDeleted,
+			TargetType:     auditTargetServiceAccount,
+			TargetID:       account.ID,
+			Before:         models.DatabaseUserToServiceAccount(account),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// userForSystemAdmin loads the account named in the URL for a system administrator
+func (api *ApiConfig) userForSystemAdmin(w http.ResponseWriter, r *http.Request) (*auth.Principal, database.User, bool) {
+	var user database.User
+
+	admin, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return nil, user, false
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return nil, user, false
+	}
+
+	user, err = api.Queries.AdminGetUser(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "User not found")
+		return nil, user, false
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get user")
+		return nil, user, false
+	}
+
+	return admin, user, true
+}
@@ -1,15 +1,23 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"log"
+	"log/slog"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/authz"
+	"github.com/omed0/go-hello-world/internal/config"
 	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/internal/metrics"
+	"github.com/omed0/go-hello-world/internal/notify"
+	"github.com/omed0/go-hello-world/internal/oidc"
+	"github.com/omed0/go-hello-world/internal/tracing"
 )
 
 var (
@@ -21,20 +29,155 @@ var (
 // ErrMissingDBURL is returned when DB_URL is not set.
 var ErrMissingDBURL = errors.New("DB_URL environment variable is missing")
 
+// Password hashing configuration errors
+var (
+	ErrInvalidPasswordConfig = errors.New("invalid password hashing parameters")
+	ErrUnknownPepperID       = errors.New("PASSWORD_PEPPER_ID is not listed in PASSWORD_PEPPERS")
+)
+
+// ErrUnknownTracingExporter is returned when TRACING_EXPORTER names an exporter that does not exist
+var ErrUnknownTracingExporter = errors.New("TRACING_EXPORTER must be none, stdout, file or otlp")
+
 type ApiConfig struct {
-	Queries *database.Queries
+	Queries   *database.Queries
+	DB        *sql.DB
+	Config    *config.Config
+	Notifier  notify.Sink
+	Passwords *auth.PasswordConfig
+	OIDC      *oidc.Client
+	Metrics   *metrics.Metrics
+
+	// OrgOIDC talks to identity providers configured by organizations and only reaches public addresses
+	OrgOIDC *oidc.Client
+
+	// Tracer records spans for requests and queries; it is nil when tracing is off
+	Tracer *tracing.Tracer
+
+	// Authenticator identifies callers on protected routes
+	Authenticator auth.Authenticator
+
+	// Permissions resolves the caller's role to what they may do
+	Permissions *authz.Engine
+
+	// dummyPasswordHash is verified against when a login names no usable account
+	dummyPasswordHash string
 }
 
 // NewApiConfig creates a new ApiConfig instance with a database connection
-func NewApiConfig() (*ApiConfig, error) {
+func NewApiConfig(cfg *config.Config) (*ApiConfig, error) {
+	passwords, err := NewPasswordConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dummyPasswordHash, err := newDummyPasswordHash(passwords)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := auth.PasswordPolicyByName(cfg.PasswordPolicy); !ok {
+		return nil, ErrUnknownPasswordPolicy
+	}
+
+	if provider, ok := globalOIDCProvider(cfg); ok {
+		if err := provider.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	tracer, err := NewTracer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	db, err := InstanceDB()
 	if err != nil {
 		return nil, err
 	}
 
-	return &ApiConfig{
-		Queries: database.New(db),
-	}, nil
+	api := &ApiConfig{
+		Queries:   database.New(tracing.WrapDB(tracer, db)),
+		DB:        db,
+		Config:    cfg,
+		Notifier:  notify.NewFileSink(cfg.NotificationLogFile),
+		Passwords: passwords,
+		OIDC:      oidc.NewClient(nil),
+		OrgOIDC:   oidc.NewPublicClient(),
+		Metrics:   metrics.New(),
+		Tracer:    tracer,
+	}
+	api.dummyPasswordHash = dummyPasswordHash
+	api.Metrics.ObserveDB(db)
+	api.Authenticator = api.newAuthenticator()
+	api.Permissions = &authz.Engine{Roles: api.Queries, Members: api.Queries}
+
+	return api, nil
+}
+
+// NewPasswordConfig builds the password hashing parameters and pepper keys from the configuration
+func NewPasswordConfig(cfg *config.Config) (*auth.PasswordConfig, error) {
+	passwords := &auth.PasswordConfig{
+		Time:     uint32(cfg.PasswordHashTime),
+		Memory:   uint32(cfg.PasswordHashMemory),
+		Threads:  uint8(cfg.PasswordHashThreads),
+		KeyLen:   uint32(cfg.PasswordHashKeyLen),
+		PepperID: cfg.PasswordPepperID,
+		Peppers:  make(map[string][]byte, len(cfg.PasswordPeppers)),
+	}
+
+	if passwords.Time < 1 || passwords.Memory < 8*uint32(passwords.Threads) || passwords.Threads < 1 || passwords.KeyLen < 16 {
+		return nil, ErrInvalidPasswordConfig
+	}
+
+	for id, secret := range cfg.PasswordPeppers {
+		passwords.Peppers[id] = []byte(secret)
+	}
+
+	if passwords.PepperID != "" {
+		if _, ok := passwords.Peppers[passwords.PepperID]; !ok {
+			return nil, ErrUnknownPepperID
+		}
+	}
+
+	return passwords, nil
+}
+
+// NewTracer creates the tracer for the configured exporter, or returns nil when tracing is off
+func NewTracer(cfg *config.Config) (*tracing.Tracer, error) {
+	var exporter tracing.Exporter
+	switch cfg.TracingExporter {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		exporter = tracing.NewWriterExporter(os.Stdout)
+	case "file":
+		fileExporter, err := tracing.NewFileExporter(cfg.TracingFile)
+		if err != nil {
+			return nil, err
+		}
+		exporter = fileExporter
+	case "otlp":
+		exporter = tracing.NewOTLPExporter(cfg.TracingOTLPEndpoint, cfg.TracingServiceName)
+	default:
+		return nil, ErrUnknownTracingExporter
+	}
+
+	return tracing.NewTracer(exporter, cfg.TracingSampleRatio), nil
+}
+
+// withTx runs fn inside a database transaction, committing only if fn succeeds
+func (api *ApiConfig) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := api.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(database.New(tracing.WrapDB(api.Tracer, tx))); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // InstanceDB initializes the database connection and returns a DB instance.
@@ -50,7 +193,7 @@ func InstanceDB() (*sql.DB, error) {
 func initializeDB() error {
 	// Load environment variables only once
 	if err := godotenv.Load(".env"); err != nil {
-		log.Printf("Warning: failed to load .env file: %v", err)
+		slog.Warn("Failed to load .env file", "error", err)
 	}
 
 	dbURL := os.Getenv("DB_URL")
@@ -76,7 +219,7 @@ func initializeDB() error {
 		return err
 	}
 
-	log.Println("Database connected successfully")
+	slog.Info("Database connected successfully")
 	return nil
 }
 
@@ -84,9 +227,9 @@ func initializeDB() error {
 func CloseDB() {
 	if dbConn != nil {
 		if err := dbConn.Close(); err != nil {
-			log.Printf("Error closing database connection: %v", err)
+			slog.Error("Failed to close database connection", "error", err)
 		} else {
-			log.Println("Database connection closed")
+			slog.Info("Database connection closed")
 		}
 		dbConn = nil
 		// Reset the once to allow reconnection if needed
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/models"
+)
+
+const maxAPIKeyNameLength = 100
+
+// auditAPIKey is how an API key appears in the audit log, together with the account it belongs to
+type auditAPIKey struct {
+	models.APIKey
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// HandlerCreateAPIKey creates a new named, scoped API key for the current user
+func (api *ApiConfig) HandlerCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	var params models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if status, errMsg := validateAPIKeyRequest(r.Context(), &params); errMsg != "" {
+		RespondWithError(w, status, errMsg)
+		return
+	}
+
+	var key models.APIKeyWithSecret
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		key, err = createAPIKey(r.Context(), q, principal.UserID, params)
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: principal.OrganizationID,
+			Action:         auditAPIKeyCreated,
+			TargetType:     auditTargetAPIKey,
+			TargetID:       key.ID,
+			After:          auditAPIKey{APIKey: key.APIKey, UserID: principal.UserID},
+		})
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, key)
+}
+
+// validateAPIKeyRequest trims and checks a new key's name, scopes and expiry, returning a status and message on failure
+func validateAPIKeyRequest(ctx context.Context, params *models.CreateAPIKeyRequest) (int, string) {
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" {
+		return http.StatusBadRequest, "API key name is required"
+	}
+
+	if len(params.Name) > maxAPIKeyNameLength {
+		return http.StatusBadRequest, "API key name must be less than 100 characters"
+	}
+
+	if status, errMsg := checkGrantableScopes(ctx, params.Scopes); errMsg != "" {
+		return status, errMsg
+	}
+
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		return http.StatusBadRequest, "Expiry must be in the future"
+	}
+
+	return 0, ""
+}
+
+// checkGrantableScopes validates scopes to be granted to a credential, returning a status and message on failure
+func checkGrantableScopes(ctx context.Context, scopes []string) (int, string) {
+	if err := auth.ValidateScopes(scopes); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+
+	// A scoped key can only grant scopes it holds itself
+	if principal, err := auth.PrincipalFromContext(ctx); err == nil {
+		for _, scope := range scopes {
+			if !principal.HasScope(scope) {
+				return http.StatusForbidden, "Cannot grant scope not held by the current key: " + scope
+			}
+		}
+	}
+
+	return 0, ""
+}
+
+// createAPIKey generates a new secret and stores its hash as a key of userID
+func createAPIKey(ctx context.Context, q *database.Queries, userID uuid.UUID, params models.CreateAPIKeyRequest) (models.APIKeyWithSecret, error) {
+	secret, err := auth.GenerateAPIKey()
+	if err != nil {
+		return models.APIKeyWithSecret{}, err
+	}
+
+	createParams := database.CreateAPIKeyParams{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      params.Name,
+		KeyPrefix: auth.APIKeyPrefix(secret),
+		KeyHash:   auth.HashToken(secret),
+		Scopes:    params.Scopes,
+	}
+
+	if params.ExpiresAt != nil {
+		createParams.ExpiresAt = sql.NullTime{Time: params.ExpiresAt.UTC(), Valid: true}
+	}
+
+	key, err := q.CreateAPIKey(ctx, createParams)
+	if err != nil {
+		return models.APIKeyWithSecret{}, err
+	}
+
+	return models.APIKeyWithSecret{
+		APIKey: models.DatabaseAPIKeyToAPIKey(key),
+		Key:    secret,
+	}, nil
+}
+
+// HandlerGetAPIKeys lists the active API keys of the current user
+func (api *ApiConfig) HandlerGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	keys, err := api.Queries.GetAPIKeysByUser(r.Context(), principal.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get API keys")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.DatabaseAPIKeysToAPIKeys(keys))
+}
+
+// HandlerRotateAPIKey replaces the secret of an API key, keeping its name, scopes and expiry
+func (api *ApiConfig) HandlerRotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID, err := uuid.Parse(chi.URLParam(r, "keyId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	if !api.checkKeyScopesHeld(w, r, principal.UserID, keyID) {
+		return
+	}
+
+	secret, err := auth.GenerateAPIKey()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to generate API key")
+		return
+	}
+
+	var key database.ApiKey
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		key, err = q.RotateAPIKey(r.Context(), database.RotateAPIKeyParams{
+			ID:        keyID,
+			UserID:    principal.UserID,
+			KeyPrefix: auth.APIKeyPrefix(secret),
+			KeyHash:   auth.HashToken(secret),
+		})
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: principal.OrganizationID,
+			Action:         auditAPIKeyRotated,
+			TargetType:     auditTargetAPIKey,
+			TargetID:       key.ID,
+			After:          map[string]string{"prefix": key.KeyPrefix},
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to rotate API key")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.APIKeyWithSecret{
+		APIKey: models.DatabaseAPIKeyToAPIKey(key),
+		Key:    secret,
+	})
+}
+
+// HandlerRevokeAPIKey permanently revokes an API key
+func (api *ApiConfig) HandlerRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID, err := uuid.Parse(chi.URLParam(r, "keyId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	if !api.checkKeyScopesHeld(w, r, principal.UserID, keyID) {
+		return
+	}
+
+	err = api.revokeAPIKey(r, principal.OrganizationID, principal.UserID, keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkKeyScopesHeld loads an active key of the user and responds with an error unless the caller holds
+// every scope of that key, so a narrow key cannot take over or cut off a broader one
+func (api *ApiConfig) checkKeyScopesHeld(w http.ResponseWriter, r *http.Request, userID, keyID uuid.UUID) bool {
+	key, err := api.Queries.GetAPIKeyByID(r.Context(), database.GetAPIKeyByIDParams{
+		ID:     keyID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "API key not found")
+		return false
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get API key")
+		return false
+	}
+
+	if status, errMsg := checkGrantableScopes(r.Context(), key.Scopes); errMsg != "" {
+		RespondWithError(w, status, errMsg)
+		return false
+	}
+	return true
+}
+
+// revokeAPIKey revokes an active API key of an account and records it in the organization's audit log
+func (api *ApiConfig) revokeAPIKey(r *http.Request, orgID uuid.NullUUID, userID, keyID uuid.UUID) error {
+	return api.withTx(r.Context(), func(q *database.Queries) error {
+		key, err := q.RevokeAPIKey(r.Context(), database.RevokeAPIKeyParams{
+			ID:     keyID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: orgID,
+			Action:         auditAPIKeyRevoked,
+			TargetType:     auditTargetAPIKey,
+			TargetID:       key.ID,
+			Before:         auditAPIKey{APIKey: models.DatabaseAPIKeyToAPIKey(key), UserID: userID},
+		})
+	})
+}
@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/internal/logging"
+	"github.com/omed0/go-hello-world/models"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// Audited actions, named target.verb
+const (
+	auditTaskCreated = "task.created"
+	auditTaskUpdated = "task.updated"
+	auditTaskDeleted = "task.deleted"
+
+	auditOrganizationCreated    = "organization.created"
+	auditOrganizationUpdated    = "organization.updated"
+	auditOrganizationDeleted    = "organization.deleted"
+	auditOwnershipTransferred   = "organization.ownership_transferred"
+	auditMemberAdded            = "member.added"
+	auditMemberRoleChanged      = "member.role_changed"
+	auditMemberRemoved          = "member.removed"
+	auditInvitationCreated      = "invitation.created"
+	auditInvitationRevoked      = "invitation.revoked"
+	auditRoleCreated            = "role.created"
+	auditRoleUpdated            = "role.updated"
+	auditRoleDeleted            = "role.deleted"
+	auditServiceAccountCreated  = "service_account.created"
+	auditServiceAccountDisabled = "service_account.disabled"
+	auditServiceAccountEnabled  = "service_account.enabled"
+	auditServiceAccountDeleted  = "service_account.deleted"
+	auditCertificateMapped      = "client_certificate.created"
+	auditCertificateUnmapped    = "client_certificate.deleted"
+
+	auditAPIKeyCreated          = "api_key.created"
+	auditAPIKeyRotated          = "api_key.rotated"
+	auditAPIKeyRevoked          = "api_key.revoked"
+	auditAPIKeyRotationRequired = "api_key.rotation_required"
+
+	auditUserUpdated     = "user.updated"
+	auditUserSuspended   = "user.suspended"
+	auditUserReactivated = "user.reactivated"
+	auditUserDeleted     = "user.deleted"
+)
+
+// Audit target types
+const (
+	auditTargetTask              = "task"
+	auditTargetOrganization      = "organization"
+	auditTargetMember            = "member"
+	auditTargetInvitation        = "invitation"
+	auditTargetRole              = "role"
+	auditTargetServiceAccount    = "service_account"
+	auditTargetClientCertificate = "client_certificate"
+	auditTargetAPIKey            = "api_key"
+	auditTargetUser              = "user"
+)
+
+// auditExportBatchSize is how many events an export reads from the database at a time
+const auditExportBatchSize = 500
+
+// auditRedactedFields are never written to the audit log; a change to them is recorded without the values
+var auditRedactedFields = []string{"password", "password_hash", "secret", "client_secret", "token", "key"}
+
+// auditChange describes a change to record in the audit log. Before and After are the target as returned
+// by the API; either is nil when the target is created or deleted.
+type auditChange struct {
+	OrganizationID uuid.NullUUID // the organization whose audit log shows the event
+	Action         string
+	TargetType     string
+	TargetID       uuid.UUID
+	Before, After  any
+}
+
+// auditActor is who made a change and from where
+type auditActor struct {
+	userID    uuid.NullUUID
+	username  string
+	method    string
+	ip        string
+	requestID string
+}
+
+// systemActor makes changes the server carries out on its own, such as scheduled account deletions
+var systemActor = auditActor{username: "system", method: "system"}
+
+// audit records a change made by the caller through q, so the event commits or rolls back with the change itself
+func (api *ApiConfig) audit(r *http.Request, q *database.Queries, change auditChange) error {
+	return recordAudit(r.Context(), q, api.requestActor(r), change)
+}
+
+// requestActor returns the caller of a request as an audit actor
+func (api *ApiConfig) requestActor(r *http.Request) auditActor {
+	actor := auditActor{ip: api.clientIP(r), requestID: logging.RequestID(r.Context())}
+	if principal, err := auth.PrincipalFromContext(r.Context()); err == nil {
+		actor.userID = uuid.NullUUID{UUID: principal.UserID, Valid: true}
+		actor.username = principal.Username
+		actor.method = principal.Method
+	}
+	return actor
+}
+
+// recordAudit writes an audit event for a change made by actor
+func recordAudit(ctx context.Context, q *database.Queries, actor auditActor, change auditChange) error {
+	changes, err := auditDiff(change.Before, change.After)
+	if err != nil {
+		return err
+	}
+
+	return q.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+		ID:             uuid.New(),
+		OrganizationID: change.OrganizationID,
+		ActorID:        actor.userID,
+		ActorUsername:  sql.NullString{String: actor.username, Valid: actor.username != ""},
+		AuthMethod:     sql.NullString{String: actor.method, Valid: actor.method != ""},
+		Action:         change.Action,
+		TargetType:     change.TargetType,
+		TargetID:       uuid.NullUUID{UUID: change.TargetID, Valid: change.TargetID != uuid.Nil},
+		Changes:        changes,
+		IpAddress:      sql.NullString{String: actor.ip, Valid: actor.ip != ""},
+		RequestID:      sql.NullString{String: actor.requestID, Valid: actor.requestID != ""},
+	})
+}
+
+// auditFieldChange is the before and after value of one changed field
+type auditFieldChange struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+// auditDiff compares the JSON form of two versions of a target and returns the changed fields.
+// Timestamps maintained by the database are left out and sensitive fields are redacted.
+func auditDiff(before, after any) (pqtype.NullRawMessage, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return pqtype.NullRawMessage{}, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return pqtype.NullRawMessage{}, err
+	}
+
+	diff := make(map[string]auditFieldChange)
+	for field, value := range beforeFields {
+		if next, ok := afterFields[field]; !ok || !reflect.DeepEqual(value, next) {
+			diff[field] = auditFieldChange{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			diff[field] = auditFieldChange{After: value}
+		}
+	}
+	if len(diff) == 0 {
+		return pqtype.NullRawMessage{}, nil
+	}
+
+	for field := range diff {
+		if slices.Contains(auditRedactedFields, field) {
+			diff[field] = auditFieldChange{Before: "[redacted]", After: "[redacted]"}
+		}
+	}
+
+	raw, err := json.Marshal(diff)
+	if err != nil {
+		return pqtype.NullRawMessage{}, err
+	}
+	return pqtype.NullRawMessage{RawMessage: raw, Valid: true}, nil
+}
+
+// auditFields returns the JSON fields of a value, without the ones the audit log ignores
+func auditFields(value any) (map[string]any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("audited values must be JSON objects: %w", err)
+	}
+	delete(fields, "created_at")
+	delete(fields, "updated_at")
+	return fields, nil
+}
+
+// parseAuditFilter reads the audit log filters from the query string, returning an error message for invalid ones
+func parseAuditFilter(query url.Values) (database.CountAuditEventsParams, string) {
+	var filter database.CountAuditEventsParams
+
+	for name, dst := range map[string]*uuid.NullUUID{"actor_id": &filter.ActorID, "target_id": &filter.TargetID} {
+		if value := query.Get(name); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return filter, "Invalid " + name
+			}
+			*dst = uuid.NullUUID{UUID: id, Valid: true}
+		}
+	}
+
+	for name, dst := range map[string]*sql.NullString{"action": &filter.Action, "target_type": &filter.TargetType} {
+		if value := strings.TrimSpace(query.Get(name)); value != "" {
+			*dst = sql.NullString{String: value, Valid: true}
+		}
+	}
+
+	for name, dst := range map[string]*sql.NullTime{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, name + " must be an RFC 3339 time"
+			}
+			*dst = sql.NullTime{Time: t.UTC(), Valid: true}
+		}
+	}
+
+	return filter, ""
+}
+
+// HandlerGetAuditEvents lists the organization's audit events, newest first.
+// Filters: actor_id, action (an action or a target type such as "task"), target_type, target_id, since and until.
+func (api *ApiConfig) HandlerGetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	_, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, offset := parseLimit(query.Get("limit")), parseOffset(query.Get("offset"))
+
+	filter, errMsg := parseAuditFilter(query)
+	if errMsg != "" {
+		RespondWithError(w, http.StatusBadRequest, errMsg)
+		return
+	}
+	filter.OrganizationID = uuid.NullUUID{UUID: orgID, Valid: true}
+
+	events, err := api.Queries.ListAuditEvents(r.Context(), database.ListAuditEventsParams{
+		OrganizationID: filter.OrganizationID,
+		ActorID:        filter.ActorID,
+		Action:         filter.Action,
+		TargetType:     filter.TargetType,
+		TargetID:       filter.TargetID,
+		Since:          filter.Since,
+		Until:          filter.Until,
+		PageLimit:      int32(limit),
+		PageOffset:     int32(offset),
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get audit events")
+		return
+	}
+
+	total, err := api.Queries.CountAuditEvents(r.Context(), filter)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get audit events")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.Page[models.AuditEvent]{
+		Items:  models.DatabaseAuditEventsToAuditEvents(events),
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// HandlerExportAuditEvents downloads the organization's audit events as JSON Lines, oldest first,
+// with the same filters as the listing
+func (api *ApiConfig) HandlerExportAuditEvents(w http.ResponseWriter, r *http.Request) {
+	_, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	filter, errMsg := parseAuditFilter(r.URL.Query())
+	if errMsg != "" {
+		RespondWithError(w, http.StatusBadRequest, errMsg)
+		return
+	}
+	filter.OrganizationID = uuid.NullUUID{UUID: orgID, Valid: true}
+
+	api.exportAuditEvents(w, r, filter, "audit-"+orgID.String()+".jsonl")
+}
+
+// HandlerAdminExportAuditEvents downloads the whole audit log as JSON Lines, optionally for one organization_id
+func (api *ApiConfig) HandlerAdminExportAuditEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, errMsg := parseAuditFilter(query)
+	if errMsg != "" {
+		RespondWithError(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	if orgID := query.Get("organization_id"); orgID != "" {
+		id, err := uuid.Parse(orgID)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid organization ID")
+			return
+		}
+		filter.OrganizationID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	api.exportAuditEvents(w, r, filter, "audit.jsonl")
+}
+
+// exportAuditEvents streams the matching audit events one JSON object per line, reading them in batches
+func (api *ApiConfig) exportAuditEvents(w http.ResponseWriter, r *http.Request, filter database.CountAuditEventsParams, filename string) {
+	params := database.ExportAuditEventsParams{
+		OrganizationID: filter.OrganizationID,
+		ActorID:        filter.ActorID,
+		Action:         filter.Action,
+		TargetType:     filter.TargetType,
+		TargetID:       filter.TargetID,
+		Since:          filter.Since,
+		Until:          filter.Until,
+		PageLimit:      auditExportBatchSize,
+	}
+
+	events, err := api.Queries.ExportAuditEvents(r.Context(), params)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to export audit events")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	for len(events) > 0 {
+		for _, event := range events {
+			if err := encoder.Encode(models.DatabaseAuditEventToAuditEvent(event)); err != nil {
+				slog.WarnContext(r.Context(), "Failed to write audit export", "error", err)
+				return
+			}
+		}
+		if len(events) < auditExportBatchSize {
+			return
+		}
+
+		last := events[len(events)-1]
+		params.AfterCreatedAt, params.AfterID = last.CreatedAt, last.ID
+		if events, err = api.Queries.ExportAuditEvents(r.Context(), params); err != nil {
+			// The status is already sent, so the truncated export can only be logged
+			slog.ErrorContext(r.Context(), "Failed to export audit events", "error", err)
+			return
+		}
+	}
+}
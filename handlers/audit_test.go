@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+// TestAuditDiffKeepsOnlyChangedFields checks that unchanged fields and database timestamps are left out
+func TestAuditDiffKeepsOnlyChangedFields(t *testing.T) {
+	before := map[string]any{"title": "Old", "is_completed": false, "updated_at": "2024-01-01T00:00:00Z"}
+	after := map[string]any{"title": "Old", "is_completed": true, "updated_at": "2024-01-02T00:00:00Z"}
+
+	changes, err := auditDiff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var diff map[string]auditFieldChange
+	if err := json.Unmarshal(changes.RawMessage, &diff); err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 1 || diff["is_completed"].Before != false || diff["is_completed"].After != true {
+		t.Errorf("expected only is_completed to change, got %s", changes.RawMessage)
+	}
+}
+
+// TestAuditDiffRedactsSecrets checks that sensitive values never reach the audit log
+func TestAuditDiffRedactsSecrets(t *testing.T) {
+	changes, err := auditDiff(nil, map[string]any{"name": "ci", "key": "hw_live_abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var diff map[string]auditFieldChange
+	if err := json.Unmarshal(changes.RawMessage, &diff); err != nil {
+		t.Fatal(err)
+	}
+	if diff["key"].After != "[redacted]" || diff["name"].After != "ci" {
+		t.Errorf("expected the key to be redacted, got %s", changes.RawMessage)
+	}
+}
+
+// TestAuditDiffWithoutChanges checks that identical versions record no changes
+func TestAuditDiffWithoutChanges(t *testing.T) {
+	changes, err := auditDiff(map[string]any{"role": "admin"}, map[string]any{"role": "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes.Valid {
+		t.Errorf("expected no changes, got %s", changes.RawMessage)
+	}
+}
+
+// TestParseAuditFilter checks that filters are parsed and invalid ones rejected
+func TestParseAuditFilter(t *testing.T) {
+	filter, errMsg := parseAuditFilter(url.Values{
+		"action":   {"task"},
+		"actor_id": {"2b1f5a3e-8c1d-4f7a-9b2e-6d4c3a2b1f00"},
+		"since":    {"2024-05-01T10:00:00+02:00"},
+	})
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	}
+	if !filter.Action.Valid || filter.Action.String != "task" || !filter.ActorID.Valid || filter.TargetID.Valid {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+	if !filter.Since.Valid || filter.Since.Time.Hour() != 8 {
+		t.Errorf("expected since in UTC, got %v", filter.Since.Time)
+	}
+
+	for _, query := range []url.Values{{"target_id": {"nope"}}, {"until": {"yesterday"}}} {
+		if _, errMsg := parseAuditFilter(query); errMsg == "" {
+			t.Errorf("expected %v to be rejected", query)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+)
+
+// newAuthenticator builds the chain of credentials accepted on protected routes, in order of precedence
+func (api *ApiConfig) newAuthenticator() auth.Authenticator {
+	chain := auth.Chain{
+		auth.APIKeyAuthenticator{Queries: api.Queries},
+		auth.BearerAuthenticator{Queries: api.Queries, Secret: []byte(api.Config.JWTSecret)},
+	}
+
+	if api.Config.BasicAuthEnabled {
+		chain = append(chain, auth.BasicAuthenticator{
+			Queries:   api.Queries,
+			Passwords: api.Passwords,
+			Guard:     basicAuthGuard{api: api},
+			DummyHash: api.dummyPasswordHash,
+		})
+	}
+
+	// Client certificates are only verified when the server terminates TLS with a client CA
+	if api.Config.TLSCertFile != "" && api.Config.TLSClientCAFile != "" {
+		chain = append(chain, auth.ClientCertAuthenticator{Resolver: certificateResolver{queries: api.Queries}})
+	}
+
+	return chain
+}
+
+// basicAuthGuard applies the login lockout to passwords sent with basic authentication
+type basicAuthGuard struct {
+	api *ApiConfig
+}
+
+func (g basicAuthGuard) Locked(r *http.Request, username string) bool {
+	return g.api.checkLoginLocked(r.Context(), g.api.newLoginAttempt(r, username)) > 0
+}
+
+func (g basicAuthGuard) RecordFailure(r *http.Request, username string, userID uuid.NullUUID) {
+	attempt := g.api.newLoginAttempt(r, username)
+	attempt.userID = userID
+	g.api.recordLoginFailure(r.Context(), attempt)
+}
+
+func (g basicAuthGuard) RecordSuccess(r *http.Request, username string) {
+	g.api.clearLoginFailures(r.Context(), g.api.newLoginAttempt(r, username))
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"crypto/x509"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/authz"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// Auth events logged for client certificate mappings
+const (
+	authEventClientCertificateMapped   = "client_certificate_mapped"
+	authEventClientCertificateUnmapped = "client_certificate_unmapped"
+)
+
+// maxCertMatchValueLength matches the match_value column
+const maxCertMatchValueLength = 255
+
+// clientCertificateTouchInterval limits how often last_used_at is written for a busy mapping
+const clientCertificateTouchInterval = time.Minute
+
+// errCertificateMapped is returned when a certificate name is already mapped to an account
+var errCertificateMapped = errors.New("certificate name already mapped")
+
+// certificateResolver maps verified client certificates to accounts through client_certificate_mappings
+type certificateResolver struct {
+	queries *database.Queries
+}
+
+// ResolveCertificate implements auth.CertificateResolver
+func (c certificateResolver) ResolveCertificate(r *http.Request, cert *x509.Certificate) (*auth.Principal, error) {
+	for _, identity := range auth.CertificateIdentities(cert) {
+		row, err := c.queries.GetActiveClientCertificateMapping(r.Context(), database.GetActiveClientCertificateMappingParams{
+			MatchType:  identity.Type,
+			MatchValue: identity.Value,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		mapping := row.ClientCertificateMapping
+
+		if !mapping.LastUsedAt.Valid || time.Since(mapping.LastUsedAt.Time) >= clientCertificateTouchInterval {
+			if err := c.queries.TouchClientCertificateMapping(r.Context(), mapping.ID); err != nil {
+				slog.WarnContext(r.Context(), "Failed to record client certificate usage", "error", err)
+			}
+		}
+
+		return &auth.Principal{
+			UserID:                mapping.UserID,
+			Username:              row.Username,
+			AccountType:           row.AccountType,
+			DefaultOrganizationID: row.OrganizationID,
+			Scopes:                mapping.Scopes,
+		}, nil
+	}
+
+	return nil, auth.ErrInvalidCertificate
+}
+
+// HandlerCreateClientCertificateMapping lets certificates carrying a name authenticate as the admin or one of the
+// organization's service accounts
+func (api *ApiConfig) HandlerCreateClientCertificateMapping(w http.ResponseWriter, r *http.Request) {
+	admin, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var params models.CreateClientCertificateMappingRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	// Validate input
+	if !auth.IsCertMatchType(params.MatchType) {
+		RespondWithError(w, http.StatusBadRequest, "Match type must be one of uri, dns, email or common_name")
+		return
+	}
+
+	params.MatchValue = auth.NormalizeCertMatchValue(params.MatchType, params.MatchValue)
+	if params.MatchValue == "" || len(params.MatchValue) > maxCertMatchValueLength {
+		RespondWithError(w, http.StatusBadRequest, "Match value is required and must be at most 255 characters")
+		return
+	}
+
+	if status, errMsg := checkGrantableScopes(r.Context(), params.Scopes); errMsg != "" {
+		RespondWithError(w, status, errMsg)
+		return
+	}
+
+	target, member, ok := api.organizationMember(w, r, orgID, params.UserID)
+	if !ok {
+		return
+	}
+
+	// A certificate signs in as its account, so admins may only map their own certificates
+	// and those of the organization's service accounts
+	org := uuid.NullUUID{UUID: orgID, Valid: true}
+	ownServiceAccount := target.AccountType == auth.AccountTypeService && target.OrganizationID == org
+	if target.ID != admin.UserID && !ownServiceAccount {
+		RespondWithError(w, http.StatusForbidden, "Certificates can only be mapped to yourself or the organization's service accounts")
+		return
+	}
+
+	current, err := api.Permissions.Permissions(r.Context(), org, member.Role)
+	if err != nil && !errors.Is(err, authz.ErrUnknownRole) {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to look up role")
+		return
+	}
+	if !authz.Grants(api.callerPermissions(r, admin), current...) {
+		RespondWithError(w, http.StatusForbidden, "You cannot map certificates to an account with permissions you do not have")
+		return
+	}
+
+	// Only an account's own holder can give a certificate unrestricted access; service accounts never get it
+	if target.ID != admin.UserID && slices.Contains(params.Scopes, auth.ScopeAll) {
+		RespondWithError(w, http.StatusBadRequest, "Certificates mapped to another account must list explicit scopes")
+		return
+	}
+
+	var mapping database.ClientCertificateMapping
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		mapping, err = q.CreateClientCertificateMapping(r.Context(), database.CreateClientCertificateMappingParams{
+			ID:             uuid.New(),
+			UserID:         target.ID,
+			OrganizationID: orgID,
+			MatchType:      params.MatchType,
+			MatchValue:     params.MatchValue,
+			Scopes:         params.Scopes,
+			CreatedBy:      uuid.NullUUID{UUID: admin.UserID, Valid: true},
+		})
+		if err != nil {
+			return errCertificateMapped
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			Action:         auditCertificateMapped,
+			TargetType:     auditTargetClientCertificate,
+			TargetID:       mapping.ID,
+			After:          models.DatabaseClientCertificateMappingToClientCertificateMapping(mapping, target.Username),
+		})
+	})
+	if errors.Is(err, errCertificateMapped) {
+		RespondWithError(w, http.StatusConflict, "This certificate name is already mapped")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to create client certificate mapping")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventClientCertificateMapped, loginAttempt{
+		username: target.Username,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: target.ID, Valid: true},
+	}, mapping.MatchType+"="+mapping.MatchValue+" by "+admin.Username)
+
+	RespondWithJSON(w, http.StatusCreated, models.DatabaseClientCertificateMappingToClientCertificateMapping(mapping, target.Username))
+}
+
+// HandlerGetClientCertificateMappings lists the certificate mappings of the admin's organization
+func (api *ApiConfig) HandlerGetClientCertificateMappings(w http.ResponseWriter, r *http.Request) {
+	_, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := api.Queries.ListOrganizationClientCertificateMappings(r.Context(), orgID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get client certificate mappings")
+		return
+	}
+
+	mappings := make([]models.ClientCertificateMapping, len(rows))
+	for i, row := range rows {
+		mappings[i] = models.DatabaseClientCertificateMappingToClientCertificateMapping(row.ClientCertificateMapping, row.Username)
+	}
+
+	RespondWithJSON(w, http.StatusOK, mappings)
+}
+
+// HandlerDeleteClientCertificateMapping stops a certificate name from authenticating
+func (api *ApiConfig) HandlerDeleteClientCertificateMapping(w http.ResponseWriter, r *http.Request) {
+	admin, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	mappingID, err := uuid.Parse(chi.URLParam(r, "mappingId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid mapping ID")
+		return
+	}
+
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		deleted, err := q.DeleteClientCertificateMapping(r.Context(), database.DeleteClientCertificateMappingParams{
+			ID:             mappingID,
+			OrganizationID: orgID,
+		})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return sql.ErrNoRows
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			Action:         auditCertificateUnmapped,
+			TargetType:     auditTargetClientCertificate,
+			TargetID:       mappingID,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "Client certificate mapping not found")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to delete client certificate mapping")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventClientCertificateUnmapped, loginAttempt{ip: api.clientIP(r)},
+		"mapping "+mappingID.String()+" by "+admin.Username)
+
+	w.WriteHeader(http.StatusNoContent)
+}
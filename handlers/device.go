@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// Device code statuses
+const (
+	deviceCodePending  = "pending"
+	deviceCodeApproved = "approved"
+	deviceCodeDenied   = "denied"
+)
+
+// Auth event types for the device authorization grant
+const (
+	authEventDeviceApproved = "device_approved"
+	authEventDeviceDenied   = "device_denied"
+)
+
+// OAuth error codes used by the device token endpoint (RFC 8628 section 3.5)
+const (
+	oauthErrInvalidRequest       = "invalid_request"
+	oauthErrInvalidGrant         = "invalid_grant"
+	oauthErrUnsupportedGrantType = "unsupported_grant_type"
+	oauthErrAuthorizationPending = "authorization_pending"
+	oauthErrSlowDown             = "slow_down"
+	oauthErrAccessDenied         = "access_denied"
+	oauthErrExpiredToken         = "expired_token"
+	oauthErrServerError          = "server_error"
+)
+
+// slowDownIncrement is added to a client's polling interval each time it polls too fast
+const slowDownIncrement = 5 * time.Second
+
+const defaultDeviceClientID = "cli"
+
+// deviceVerificationPath is where users enter the code shown by their device
+const deviceVerificationPath = "/v1/device"
+
+// devicePage renders the verification page and the result of a browser approval
+var devicePage = template.Must(template.New("device").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Device login</title></head>
+<body style="font-family: sans-serif; max-width: 32rem; margin: 4rem auto;">
+<h1>Device login</h1>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+{{if .Error}}<p style="color: #b00020;">{{.Error}}</p>{{end}}
+{{if .ShowForm}}
+<p>Enter the code shown on your device, then sign in to approve it.</p>
+<form method="get" action="auth/oidc/login">
+  <p><label>Code<br><input name="user_code" value="{{.UserCode}}" autocomplete="off" required></label></p>
+  <p><label>Organization (optional)<br><input name="organization"></label></p>
+  <p><button type="submit">Sign in with single sign-on</button></p>
+</form>
+<p>Already signed in on another client? Approve the code with <code>POST /v1/device/verify</code>.</p>
+{{end}}
+</body>
+</html>
+`))
+
+type devicePageData struct {
+	UserCode string
+	Message  string
+	Error    string
+	ShowForm bool
+}
+
+func renderDevicePage(w http.ResponseWriter, status int, data devicePageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := devicePage.Execute(w, data); err != nil {
+		slog.Error("Failed to render device page", "error", err)
+	}
+}
+
+// respondOAuthError sends an OAuth 2.0 error response
+func respondOAuthError(w http.ResponseWriter, code int, errCode, description string) {
+	w.Header().Set("Cache-Control", "no-store")
+	RespondWithJSON(w, code, models.OAuthErrorResponse{Error: errCode, ErrorDescription: description})
+}
+
+// HandlerDeviceAuthorization starts a device login and returns the device code to poll with
+// and the user code to enter on the verification page
+func (api *ApiConfig) HandlerDeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondOAuthError(w, http.StatusBadRequest, oauthErrInvalidRequest, "Invalid form body")
+		return
+	}
+
+	clientID := strings.TrimSpace(r.PostForm.Get("client_id"))
+	if clientID == "" {
+		clientID = defaultDeviceClientID
+	}
+	if len(clientID) > 100 {
+		respondOAuthError(w, http.StatusBadRequest, oauthErrInvalidRequest, "client_id is too long")
+		return
+	}
+
+	if err := api.Queries.DeleteExpiredDeviceCodes(r.Context()); err != nil {
+		slog.WarnContext(r.Context(), "Failed to delete expired device codes", "error", err)
+	}
+
+	deviceCode, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		respondOAuthError(w, http.StatusInternalServerError, oauthErrServerError, "")
+		return
+	}
+	userCode, err := auth.GenerateUserCode()
+	if err != nil {
+		respondOAuthError(w, http.StatusInternalServerError, oauthErrServerError, "")
+		return
+	}
+
+	interval := api.Config.DeviceCodeInterval
+	if _, err := api.Queries.CreateDeviceCode(r.Context(), database.CreateDeviceCodeParams{
+		ID:             uuid.New(),
+		DeviceCodeHash: auth.HashToken(deviceCode),
+		UserCode:       auth.NormalizeUserCode(userCode),
+		ClientID:       clientID,
+		PollInterval:   int32(interval.Seconds()),
+		ExpiresAt:      time.Now().UTC().Add(api.Config.DeviceCodeTTL),
+	}); err != nil {
+		respondOAuthError(w, http.StatusInternalServerError, oauthErrServerError, "")
+		return
+	}
+
+	verificationURI := strings.TrimRight(api.Config.PublicURL, "/") + deviceVerificationPath
+
+	w.Header().Set("Cache-Control", "no-store")
+	RespondWithJSON(w, http.StatusOK, models.DeviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(userCode),
+		ExpiresIn:               int(api.Config.DeviceCodeTTL.Seconds()),
+		Interval:                int(interval.Seconds()),
+	})
+}
+
+// HandlerOAuthToken is the OAuth token endpoint; it supports the device code grant,
+// which clients poll until the user approves or denies the login
+func (api *ApiConfig) HandlerOAuthToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondOAuthError(w, http.StatusBadRequest, oauthErrInvalidRequest, "Invalid form body")
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != auth.DeviceCodeGrantType {
+		respondOAuthError(w, http.StatusBadRequest, oauthErrUnsupportedGrantType, "")
+		return
+	}
+
+	deviceCode := r.PostForm.Get("device_code")
+	if deviceCode == "" {
+		respondOAuthError(w, http.StatusBadRequest, oauthErrInvalidRequest, "device_code is required")
+		return
+	}
+
+	row, err := api.Queries.GetDeviceCodeByHash(r.Context(), auth.HashToken(deviceCode))
+	if err != nil {
+		respondOAuthError(w, http.StatusBadRequest, oauthErrInvalidGrant, "Unknown device code")
+		return
+	}
+
+	now := time.Now().UTC()
+	if !now.Before(row.ExpiresAt) {
+		respondOAuthError(w, http.StatusBadRequest, oauthErrExpiredToken, "")
+		return
+	}
+
+	// Clients polling faster than the interval are told to slow down and get a longer interval
+	interval := time.Duration(row.PollInterval) * time.Second
+	slowDown := row.LastPolledAt.Valid && now.Sub(row.LastPolledAt.Time) < interval
+	if slowDown {
+		interval += slowDownIncrement
+	}
+	if err := api.Queries.RecordDeviceCodePoll(r.Context(), database.RecordDeviceCodePollParams{
+		ID:           row.ID,
+		LastPolledAt: sql.NullTime{Time: now, Valid: true},
+		PollInterval: int32(interval.Seconds()),
+	}); err != nil {
+		slog.WarnContext(r.Context(), "Failed to record device code poll", "error", err)
+	}
+	if slowDown {
+		respondOAuthError(w, http.StatusBadRequest, oauthErrSlowDown, "")
+		return
+	}
+
+	switch row.Status {
+	case deviceCodePending:
+		respondOAuthError(w, http.StatusBadRequest, oauthErrAuthorizationPending, "")
+		return
+	case deviceCodeDenied:
+		respondOAuthError(w, http.StatusBadRequest, oauthErrAccessDenied, "")
+		return
+	case deviceCodeApproved:
+	default:
+		respondOAuthError(w, http.StatusBadRequest, oauthErrInvalidGrant, "Device code has already been used")
+		return
+	}
+
+	// Consuming the code first makes sure only one poll receives tokens
+	row, err = api.Queries.ConsumeDeviceCode(r.Context(), row.ID)
+	if err != nil || !row.UserID.Valid {
+		respondOAuthError(w, http.StatusBadRequest, oauthErrInvalidGrant, "Device code has already been used")
+		return
+	}
+
+	tokens, err := api.issueSession(r, row.UserID.UUID, row.ClientID)
+	if err != nil {
+		respondOAuthError(w, http.StatusInternalServerError, oauthErrServerError, "")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	RespondWithJSON(w, http.StatusOK, models.OAuthTokenResponse{
+		AccessToken:  tokens.Token,
+		TokenType:    tokens.TokenType,
+		ExpiresIn:    tokens.ExpiresIn,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// HandlerDeviceVerificationPage shows the page where users enter the code from their device
+func (api *ApiConfig) HandlerDeviceVerificationPage(w http.ResponseWriter, r *http.Request) {
+	renderDevicePage(w, http.StatusOK, devicePageData{
+		UserCode: r.URL.Query().Get("user_code"),
+		ShowForm: true,
+	})
+}
+
+// HandlerVerifyDevice lets a signed-in user approve or deny a pending device login by its user code
+func (api *ApiConfig) HandlerVerifyDevice(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	var params models.DeviceVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	row, err := api.pendingDeviceCode(r.Context(), params.UserCode)
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, "Invalid or expired user code")
+		return
+	}
+
+	if params.Approve {
+		row, err = api.approveDeviceCode(r.Context(), r, row.ID, principal.UserID)
+	} else {
+		row, err = api.denyDeviceCode(r.Context(), r, row.ID, principal.UserID)
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, "Invalid or expired user code")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.DeviceVerifyResponse{
+		UserCode: auth.FormatUserCode(row.UserCode),
+		ClientID: row.ClientID,
+		Status:   row.Status,
+	})
+}
+
+// pendingDeviceCode looks up a device login that is still waiting for approval
+func (api *ApiConfig) pendingDeviceCode(ctx context.Context, userCode string) (database.DeviceCode, error) {
+	normalized := auth.NormalizeUserCode(userCode)
+	if normalized == "" {
+		return database.DeviceCode{}, sql.ErrNoRows
+	}
+	return api.Queries.GetPendingDeviceCodeByUserCode(ctx, normalized)
+}
+
+// approveDeviceCode binds a pending device login to the user; the device receives tokens on its next poll
+func (api *ApiConfig) approveDeviceCode(ctx context.Context, r *http.Request, id, userID uuid.UUID) (database.DeviceCode, error) {
+	row, err := api.Queries.ApproveDeviceCode(ctx, database.ApproveDeviceCodeParams{
+		ID:     id,
+		UserID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		return row, err
+	}
+
+	api.logDeviceEvent(ctx, r, authEventDeviceApproved, userID, row.ClientID)
+	return row, nil
+}
+
+// denyDeviceCode rejects a pending device login
+func (api *ApiConfig) denyDeviceCode(ctx context.Context, r *http.Request, id, userID uuid.UUID) (database.DeviceCode, error) {
+	row, err := api.Queries.DenyDeviceCode(ctx, id)
+	if err != nil {
+		return row, err
+	}
+
+	api.logDeviceEvent(ctx, r, authEventDeviceDenied, userID, row.ClientID)
+	return row, nil
+}
+
+func (api *ApiConfig) logDeviceEvent(ctx context.Context, r *http.Request, eventType string, userID uuid.UUID, clientID string) {
+	var username string
+	if user, err := api.Queries.GetUserByID(ctx, userID); err == nil {
+		username = user.Username
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		slog.ErrorContext(ctx, "Failed to load user for device event", "error", err)
+	}
+
+	attempt := api.newLoginAttempt(r, username)
+	attempt.userID = uuid.NullUUID{UUID: userID, Valid: true}
+	api.logAuthEvent(ctx, eventType, attempt, "client_id="+clientID)
+}
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/authz"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/internal/notify"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// Auth events logged for organization invitations
+const (
+	authEventInvitationCreated  = "invitation_created"
+	authEventInvitationRevoked  = "invitation_revoked"
+	authEventInvitationAccepted = "invitation_accepted"
+	authEventInvitationDeclined = "invitation_declined"
+)
+
+// maxInvitationTTL caps how far in the future an invitation can expire
+const maxInvitationTTL = 30 * 24 * time.Hour
+
+// HandlerCreateInvitation invites someone to the admin's organization with a role and returns the
+// single-use invite token once
+func (api *ApiConfig) HandlerCreateInvitation(w http.ResponseWriter, r *http.Request) {
+	admin, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var params models.CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	// Validate input
+	if params.Role == "" {
+		params.Role = authz.RoleUser
+	}
+	granted, err := api.Permissions.Permissions(r.Context(), uuid.NullUUID{UUID: orgID, Valid: true}, params.Role)
+	if errors.Is(err, authz.ErrUnknownRole) {
+		RespondWithError(w, http.StatusBadRequest, "Unknown role: "+params.Role)
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to look up role")
+		return
+	}
+	if !authz.Grants(api.callerPermissions(r, admin), granted...) {
+		RespondWithError(w, http.StatusForbidden, "You cannot invite someone with permissions you do not have")
+		return
+	}
+
+	createParams := database.CreateOrganizationInvitationParams{
+		ID:             uuid.New(),
+		OrganizationID: orgID,
+		Role:           params.Role,
+		InvitedBy:      uuid.NullUUID{UUID: admin.UserID, Valid: true},
+		ExpiresAt:      time.Now().UTC().Add(api.Config.InvitationTTL),
+	}
+
+	if params.Username != nil {
+		username := strings.TrimSpace(*params.Username)
+		if valid, errMsg := validateUsername(username); !valid {
+			RespondWithError(w, http.StatusBadRequest, errMsg)
+			return
+		}
+		createParams.Username = sql.NullString{String: username, Valid: true}
+	}
+
+	if params.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*params.Email))
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email || len(email) > 255 {
+			RespondWithError(w, http.StatusBadRequest, "Invalid email address")
+			return
+		}
+		createParams.Email = sql.NullString{String: email, Valid: true}
+	}
+
+	if params.ExpiresAt != nil {
+		expiresAt := params.ExpiresAt.UTC()
+		now := time.Now().UTC()
+		if !expiresAt.After(now) || expiresAt.Sub(now) > maxInvitationTTL {
+			RespondWithError(w, http.StatusBadRequest, "expires_at must be in the future and at most 30 days away")
+			return
+		}
+		createParams.ExpiresAt = expiresAt
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to generate invitation token")
+		return
+	}
+	createParams.TokenHash = auth.HashToken(token)
+
+	var invitation database.OrganizationInvitation
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		invitation, err = q.CreateOrganizationInvitation(r.Context(), createParams)
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			Action:         auditInvitationCreated,
+			TargetType:     auditTargetInvitation,
+			TargetID:       invitation.ID,
+			After:          models.DatabaseInvitationToInvitation(invitation),
+		})
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventInvitationCreated, loginAttempt{ip: api.clientIP(r)},
+		"role "+invitation.Role+" in organization "+orgID.String()+" by "+admin.Username)
+
+	if err := api.sendInvitation(r.Context(), invitation, token); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to send invitation", "invitation_id", invitation.ID, "error", err)
+	}
+
+	RespondWithJSON(w, http.StatusCreated, models.InvitationWithToken{
+		Invitation: models.DatabaseInvitationToInvitation(invitation),
+		Token:      token,
+	})
+}
+
+// HandlerGetInvitations lists every invitation of the admin's organization, newest first
+func (api *ApiConfig) HandlerGetInvitations(w http.ResponseWriter, r *http.Request) {
+	_, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := api.Queries.ListOrganizationInvitations(r.Context(), orgID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get invitations")
+		return
+	}
+
+	invitations := make([]models.Invitation, len(rows))
+	for i, row := range rows {
+		invitations[i] = models.DatabaseInvitationToInvitation(row)
+	}
+
+	RespondWithJSON(w, http.StatusOK, invitations)
+}
+
+// HandlerRevokeInvitation withdraws a pending invitation so its token can no longer be used
+func (api *ApiConfig) HandlerRevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	admin, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	invitationID, err := uuid.Parse(chi.URLParam(r, "invitationId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		revoked, err := q.RevokeInvitation(r.Context(), database.RevokeInvitationParams{
+			ID:             invitationID,
+			OrganizationID: orgID,
+		})
+		if err != nil {
+			return err
+		}
+		if revoked == 0 {
+			return sql.ErrNoRows
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			Action:         auditInvitationRevoked,
+			TargetType:     auditTargetInvitation,
+			TargetID:       invitationID,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "Pending invitation not found")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to revoke invitation")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventInvitationRevoked, loginAttempt{ip: api.clientIP(r)},
+		"invitation "+invitationID.String()+" in organization "+orgID.String()+" by "+admin.Username)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerAcceptInvitation makes the caller a member of the organization with the invited role
+func (api *ApiConfig) HandlerAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	principal, invitation, ok := api.invitationForCaller(w, r)
+	if !ok {
+		return
+	}
+
+	// The role may have been deleted since the invitation was sent
+	org := uuid.NullUUID{UUID: invitation.OrganizationID, Valid: true}
+	if _, err := api.Permissions.Permissions(r.Context(), org, invitation.Role); err != nil {
+		if errors.Is(err, authz.ErrUnknownRole) {
+			RespondWithError(w, http.StatusConflict, "The invited role no longer exists, ask for a new invitation")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Failed to look up role")
+		return
+	}
+
+	_, err := api.Queries.GetOrganizationMembership(r.Context(), database.GetOrganizationMembershipParams{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         principal.UserID,
+	})
+	if err == nil {
+		RespondWithError(w, http.StatusConflict, "You are already a member of this organization")
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get organization member")
+		return
+	}
+
+	// Consuming the token and joining happen together so a token cannot be used twice
+	var member database.OrganizationMember
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		if _, err := q.AcceptInvitation(r.Context(), database.AcceptInvitationParams{
+			ID:          invitation.ID,
+			RespondedBy: uuid.NullUUID{UUID: principal.UserID, Valid: true},
+		}); err != nil {
+			return err
+		}
+
+		var err error
+		member, err = q.AddOrganizationMember(r.Context(), database.AddOrganizationMemberParams{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         principal.UserID,
+			Role:           invitation.Role,
+		})
+		if err != nil {
+			return err
+		}
+
+		if !principal.DefaultOrganizationID.Valid {
+			if _, err := q.UpdateUserOrganization(r.Context(), database.UpdateUserOrganizationParams{
+				ID:             principal.UserID,
+				OrganizationID: org,
+			}); err != nil {
+				return err
+			}
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: org,
+			Action:         auditMemberAdded,
+			TargetType:     auditTargetMember,
+			TargetID:       principal.UserID,
+			After:          map[string]string{"username": principal.Username, "role": member.Role, "invitation_id": invitation.ID.String()},
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "Invitation not found or no longer valid")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to accept invitation")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventInvitationAccepted, loginAttempt{
+		username: principal.Username,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: principal.UserID, Valid: true},
+	}, "role "+member.Role+" in organization "+invitation.OrganizationID.String())
+
+	organization, err := api.Queries.GetOrganizationByID(r.Context(), invitation.OrganizationID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get organization")
+		return
+	}
+
+	defaultOrg := principal.DefaultOrganizationID
+	if !defaultOrg.Valid {
+		defaultOrg = org
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.DatabaseMembershipRowToMembership(database.ListUserMembershipsRow{
+		OrganizationMember: member,
+		OrganizationName:   organization.Name,
+	}, defaultOrg))
+}
+
+// HandlerDeclineInvitation turns down an invitation; its token cannot be used afterwards
+func (api *ApiConfig) HandlerDeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	principal, invitation, ok := api.invitationForCaller(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := api.Queries.DeclineInvitation(r.Context(), database.DeclineInvitationParams{
+		ID:          invitation.ID,
+		RespondedBy: uuid.NullUUID{UUID: principal.UserID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "Invitation not found or no longer valid")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to decline invitation")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventInvitationDeclined, loginAttempt{
+		username: principal.Username,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: principal.UserID, Valid: true},
+	}, "organization "+invitation.OrganizationID.String())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// invitationForCaller loads the pending invitation matching the token in the request body and checks
+// it is addressed to the caller. Unknown, used and expired tokens all respond with 404.
+func (api *ApiConfig) invitationForCaller(w http.ResponseWriter, r *http.Request) (*auth.Principal, database.OrganizationInvitation, bool) {
+	var invitation database.OrganizationInvitation
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organization ID")
+		return nil, invitation, false
+	}
+
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return nil, invitation, false
+	}
+
+	var params models.InvitationTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return nil, invitation, false
+	}
+	if params.Token == "" {
+		RespondWithError(w, http.StatusBadRequest, "Invitation token is required")
+		return nil, invitation, false
+	}
+
+	invitation, err = api.Queries.GetPendingInvitationByToken(r.Context(), database.GetPendingInvitationByTokenParams{
+		TokenHash:      auth.HashToken(params.Token),
+		OrganizationID: orgID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "Invitation not found or no longer valid")
+		return nil, invitation, false
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get invitation")
+		return nil, invitation, false
+	}
+
+	// Bound invitations can only be answered by the account they were sent to
+	if invitation.Username.Valid && !strings.EqualFold(invitation.Username.String, principal.Username) {
+		RespondWithError(w, http.StatusForbidden, "This invitation was sent to another user")
+		return nil, invitation, false
+	}
+	if invitation.Email.Valid {
+		matches, err := api.Queries.UserHasIdentityEmail(r.Context(), database.UserHasIdentityEmailParams{
+			UserID: principal.UserID,
+			Email:  invitation.Email.String,
+		})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Failed to check invitation")
+			return nil, invitation, false
+		}
+		if !matches {
+			RespondWithError(w, http.StatusForbidden, "This invitation was sent to another email address")
+			return nil, invitation, false
+		}
+	}
+
+	return principal, invitation, true
+}
+
+// sendInvitation delivers the invite token to the user or address the invitation is bound to;
+// unbound invitations are handed out by the admin
+func (api *ApiConfig) sendInvitation(ctx context.Context, invitation database.OrganizationInvitation, token string) error {
+	msg := notify.Message{
+		Subject: "Organization invitation",
+		Body: fmt.Sprintf("You have been invited to join organization %s as %s. Accept with POST /v1/organizations/%s/invitations/accept and this token: %s (valid until %s)",
+			invitation.OrganizationID, invitation.Role, invitation.OrganizationID, token, invitation.ExpiresAt.Format(time.RFC3339)),
+	}
+
+	switch {
+	case invitation.Username.Valid:
+		user, err := api.Queries.GetUserByUsername(ctx, invitation.Username.String)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		msg.UserID = user.ID
+		msg.Recipient = user.Username
+	case invitation.Email.Valid:
+		msg.Recipient = invitation.Email.String
+	default:
+		return nil
+	}
+
+	return api.Notifier.Send(ctx, msg)
+}
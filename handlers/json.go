@@ -2,20 +2,28 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+
+	"github.com/omed0/go-hello-world/internal/logging"
 )
 
-// ErrorResponse represents an error response
+// ErrorResponse represents an error response. RequestID lets clients quote the request when reporting problems.
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// errorRecorder is implemented by response writers that keep the error message for the request log
+type errorRecorder interface {
+	RecordError(msg string)
 }
 
 // RespondWithJSON sends a JSON response with the specified status code and payload
 func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	data, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Failed to marshal JSON: %v", err)
+		slog.Error("Failed to marshal JSON", "error", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
@@ -24,17 +32,16 @@ func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.WriteHeader(code)
 
 	if _, err := w.Write(data); err != nil {
-		log.Printf("Failed to write response: %v", err)
+		slog.Warn("Failed to write response", "error", err)
 	}
 }
 
 // RespondWithError sends an error response in JSON format
 func RespondWithError(w http.ResponseWriter, code int, msg string) {
-	if code >= 500 {
-		log.Printf("Server error: %s", msg)
-	} else if code >= 400 {
-		log.Printf("Client error: %s", msg)
+	if recorder, ok := w.(errorRecorder); ok {
+		recorder.RecordError(msg)
 	}
 
-	RespondWithJSON(w, code, ErrorResponse{Error: msg})
+	// The request ID middleware has already put the ID in the response headers
+	RespondWithJSON(w, code, ErrorResponse{Error: msg, RequestID: w.Header().Get(logging.RequestIDHeader)})
 }
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// Login attempt scopes
+const (
+	loginScopeUsername = "username"
+	loginScopeIP       = "ip"
+)
+
+// Auth event types
+const (
+	authEventLoginFailed     = "login_failed"
+	authEventAccountLocked   = "account_locked"
+	authEventIPLocked        = "ip_locked"
+	authEventAccountUnlocked = "account_unlocked"
+)
+
+// loginAttempt identifies the username and client a login attempt came from
+type loginAttempt struct {
+	username string
+	ip       string
+	userID   uuid.NullUUID
+}
+
+// newLoginAttempt builds the attempt key for a request; usernames are compared case-insensitively
+func (api *ApiConfig) newLoginAttempt(r *http.Request, username string) loginAttempt {
+	return loginAttempt{
+		username: strings.ToLower(username),
+		ip:       api.clientIP(r),
+	}
+}
+
+// checkLoginLocked reports how long the username or client IP of an attempt is still locked out
+func (api *ApiConfig) checkLoginLocked(ctx context.Context, attempt loginAttempt) time.Duration {
+	now := time.Now().UTC()
+	var remaining time.Duration
+
+	for scope, key := range attempt.keys() {
+		row, err := api.Queries.GetLoginAttempt(ctx, database.GetLoginAttemptParams{Scope: scope, Key: key})
+		if err != nil || !row.LockedUntil.Valid {
+			continue
+		}
+		if left := row.LockedUntil.Time.Sub(now); left > remaining {
+			remaining = left
+		}
+	}
+
+	return remaining
+}
+
+// recordLoginFailure counts a failed attempt against the username and client IP and locks them out
+// with exponential backoff once they pass the configured limits
+func (api *ApiConfig) recordLoginFailure(ctx context.Context, attempt loginAttempt) {
+	now := time.Now().UTC()
+	api.logAuthEvent(ctx, authEventLoginFailed, attempt, "")
+	api.Metrics.Logins.Inc("failure")
+
+	for scope, key := range attempt.keys() {
+		row, err := api.Queries.RecordFailedLogin(ctx, database.RecordFailedLoginParams{
+			Scope:       scope,
+			Key:         key,
+			FailedAt:    now,
+			WindowStart: now.Add(-api.Config.LoginAttemptWindow),
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to record login attempt", "error", err)
+			continue
+		}
+
+		lockout := api.lockoutPolicy(scope).LockoutFor(int(row.FailedCount))
+		if lockout == 0 {
+			continue
+		}
+
+		if err := api.Queries.LockLogin(ctx, database.LockLoginParams{
+			Scope:       scope,
+			Key:         key,
+			LockedUntil: sql.NullTime{Time: now.Add(lockout), Valid: true},
+		}); err != nil {
+			slog.ErrorContext(ctx, "Failed to lock login", "error", err)
+			continue
+		}
+
+		eventType := authEventAccountLocked
+		if scope == loginScopeIP {
+			eventType = authEventIPLocked
+		}
+		api.logAuthEvent(ctx, eventType, attempt, fmt.Sprintf("%d failed attempts, locked for %s", row.FailedCount, lockout))
+	}
+}
+
+// clearLoginFailures resets the username counter after a complete, successful login.
+// The IP counter is left alone so a valid account cannot be used to reset it.
+func (api *ApiConfig) clearLoginFailures(ctx context.Context, attempt loginAttempt) {
+	if err := api.Queries.ClearLoginAttempts(ctx, database.ClearLoginAttemptsParams{
+		Scope: loginScopeUsername,
+		Key:   attempt.username,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to clear login attempts", "error", err)
+	}
+}
+
+// lockoutPolicy returns the configured limits for a login attempt scope
+func (api *ApiConfig) lockoutPolicy(scope string) auth.LockoutPolicy {
+	maxAttempts := api.Config.LoginMaxAttempts
+	if scope == loginScopeIP {
+		maxAttempts = api.Config.LoginMaxAttemptsPerIP
+	}
+
+	return auth.LockoutPolicy{
+		MaxAttempts: maxAttempts,
+		Window:      api.Config.LoginAttemptWindow,
+		BaseLockout: api.Config.LoginLockoutBase,
+		MaxLockout:  api.Config.LoginLockoutMax,
+	}
+}
+
+// logAuthEvent stores an auth event and mirrors it to the server log
+func (api *ApiConfig) logAuthEvent(ctx context.Context, eventType string, attempt loginAttempt, detail string) {
+	slog.InfoContext(ctx, "Auth event", "event", eventType, "username", attempt.username, "ip", attempt.ip, "detail", detail)
+
+	if err := api.Queries.CreateAuthEvent(ctx, database.CreateAuthEventParams{
+		ID:        uuid.New(),
+		UserID:    attempt.userID,
+		Username:  sql.NullString{String: attempt.username, Valid: attempt.username != ""},
+		EventType: eventType,
+		IpAddress: sql.NullString{String: attempt.ip, Valid: attempt.ip != ""},
+		Detail:    sql.NullString{String: detail, Valid: detail != ""},
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to store auth event", "error", err)
+	}
+}
+
+// keys returns the login attempt rows an attempt counts against
+func (a loginAttempt) keys() map[string]string {
+	keys := map[string]string{loginScopeUsername: a.username}
+	if a.ip != "" {
+		keys[loginScopeIP] = a.ip
+	}
+	return keys
+}
+
+// respondLoginLocked rejects a locked-out login attempt
+func respondLoginLocked(w http.ResponseWriter, remaining time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+	RespondWithError(w, http.StatusTooManyRequests, "Too many failed login attempts, try again later")
+}
+
+// clientIP returns the address of the client, honoring proxy headers only when configured to
+func (api *ApiConfig) clientIP(r *http.Request) string {
+	if api.Config.TrustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return strings.TrimSpace(realIP)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ClientIP returns the address requests are attributed to, for use by middleware such as rate limiting
+func (api *ApiConfig) ClientIP(r *http.Request) string {
+	return api.clientIP(r)
+}
+
+// HandlerAdminUnlockUser clears the login lockout of a member of the admin's organization
+func (api *ApiConfig) HandlerAdminUnlockUser(w http.ResponseWriter, r *http.Request) {
+	admin, target, ok := api.orgMemberForAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	// The event describes the target's lockout, so the admin is named in the detail rather than as the attempt's client
+	attempt := loginAttempt{
+		username: strings.ToLower(target.Username),
+		userID:   uuid.NullUUID{UUID: target.ID, Valid: true},
+	}
+	if err := api.Queries.ClearLoginAttempts(r.Context(), database.ClearLoginAttemptsParams{
+		Scope: loginScopeUsername,
+		Key:   attempt.username,
+	}); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to unlock user")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventAccountUnlocked, attempt,
+		fmt.Sprintf("unlocked by %s (%s) from %s", admin.Username, admin.UserID, api.clientIP(r)))
+
+	RespondWithJSON(w, http.StatusOK, MessageResponse{Message: "Login lockout cleared for " + target.Username})
+}
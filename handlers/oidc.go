@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/authz"
+	"github.com/omed0/go-hello-world/internal/config"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/internal/oidc"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// oidcStateTTL is how long a user has to complete the login at the identity provider
+const oidcStateTTL = 10 * time.Minute
+
+// Auth event types for single sign-on
+const (
+	authEventSSOLogin       = "sso_login"
+	authEventSSOProvisioned = "sso_user_provisioned"
+)
+
+// errSSONotConfigured is returned when neither the organization nor the server has an OIDC provider
+var errSSONotConfigured = errors.New("single sign-on is not configured")
+
+var invalidUsernameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// OIDCLoginResponse is returned by the login endpoint to clients that ask for JSON instead of a redirect
+type OIDCLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// globalOIDCProvider returns the provider configured through the environment, if any
+func globalOIDCProvider(cfg *config.Config) (oidc.ProviderConfig, bool) {
+	if cfg.OIDCIssuer == "" {
+		return oidc.ProviderConfig{}, false
+	}
+	return oidc.ProviderConfig{
+		Issuer:       cfg.OIDCIssuer,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		Scopes:       cfg.OIDCScopes,
+		RoleClaim:    cfg.OIDCRoleClaim,
+		RoleMapping:  cfg.OIDCRoleMapping,
+		DefaultRole:  cfg.OIDCDefaultRole,
+		OrgClaim:     cfg.OIDCOrgClaim,
+	}, true
+}
+
+// oidcProviderFor returns the provider of an organization, or the global provider when orgID is not set.
+// Organization providers without a redirect URL use the global one.
+func (api *ApiConfig) oidcProviderFor(ctx context.Context, orgID uuid.NullUUID) (oidc.ProviderConfig, error) {
+	if !orgID.Valid {
+		if provider, ok := globalOIDCProvider(api.Config); ok {
+			return provider, nil
+		}
+		return oidc.ProviderConfig{}, errSSONotConfigured
+	}
+
+	org, err := api.Queries.GetOrganizationByID(ctx, orgID.UUID)
+	if err != nil {
+		return oidc.ProviderConfig{}, err
+	}
+
+	var settings models.OrganizationSettings
+	if !org.Settings.Valid || json.Unmarshal(org.Settings.RawMessage, &settings) != nil || settings.OIDC == nil {
+		return oidc.ProviderConfig{}, errSSONotConfigured
+	}
+
+	// Settings saved before issuers were restricted are refused rather than fetched
+	provider := *settings.OIDC
+	if err := oidc.ValidatePublicIssuer(provider.Issuer); err != nil {
+		return oidc.ProviderConfig{}, errSSONotConfigured
+	}
+	if provider.RedirectURL == "" {
+		provider.RedirectURL = api.Config.OIDCRedirectURL
+	}
+	return provider, nil
+}
+
+// oidcClientFor returns the client for an organization's provider, or for the global provider when orgID is not set
+func (api *ApiConfig) oidcClientFor(orgID uuid.NullUUID) *oidc.Client {
+	if orgID.Valid {
+		return api.OrgOIDC
+	}
+	return api.OIDC
+}
+
+// findOrganization looks an organization up by ID or, failing that, by name
+func findOrganization(ctx context.Context, q *database.Queries, ref string) (database.Organization, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return q.GetOrganizationByID(ctx, id)
+	}
+	return q.GetOrganizationByName(ctx, ref)
+}
+
+// HandlerOIDCLogin starts the authorization code flow with PKCE. The optional organization query
+// parameter (ID or name) selects the organization's own identity provider, and user_code approves
+// a pending device login instead of starting a session.
+// Browsers are redirected; clients sending Accept: application/json get the URL in the body.
+func (api *ApiConfig) HandlerOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	var deviceCodeID uuid.NullUUID
+	if userCode := r.URL.Query().Get("user_code"); userCode != "" {
+		deviceCode, err := api.pendingDeviceCode(r.Context(), userCode)
+		if err != nil {
+			renderDevicePage(w, http.StatusNotFound, devicePageData{
+				UserCode: userCode,
+				Error:    "That code is invalid or has expired.",
+				ShowForm: true,
+			})
+			return
+		}
+		deviceCodeID = uuid.NullUUID{UUID: deviceCode.ID, Valid: true}
+	}
+
+	var orgID uuid.NullUUID
+	if ref := strings.TrimSpace(r.URL.Query().Get("organization")); ref != "" {
+		org, err := findOrganization(r.Context(), api.Queries, ref)
+		if err != nil {
+			RespondWithError(w, http.StatusNotFound, "Organization not found")
+			return
+		}
+		orgID = uuid.NullUUID{UUID: org.ID, Valid: true}
+	}
+
+	provider, err := api.oidcProviderFor(r.Context(), orgID)
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, "Single sign-on is not configured")
+		return
+	}
+
+	state, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	nonce, err := oidc.NewNonce()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	verifier, err := oidc.NewPKCEVerifier()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+
+	authURL, err := api.oidcClientFor(orgID).AuthCodeURL(r.Context(), provider, state, nonce, oidc.PKCEChallenge(verifier))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "OIDC discovery failed", "issuer", provider.Issuer, "error", err)
+		RespondWithError(w, http.StatusBadGateway, "Identity provider is unavailable")
+		return
+	}
+
+	if err := api.Queries.DeleteExpiredOIDCLoginStates(r.Context()); err != nil {
+		slog.WarnContext(r.Context(), "Failed to delete expired OIDC login states", "error", err)
+	}
+
+	if err := api.Queries.CreateOIDCLoginState(r.Context(), database.CreateOIDCLoginStateParams{
+		StateHash:      auth.HashToken(state),
+		OrganizationID: orgID,
+		Nonce:          nonce,
+		CodeVerifier:   verifier,
+		ExpiresAt:      time.Now().UTC().Add(oidcStateTTL),
+		DeviceCodeID:   deviceCodeID,
+	}); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		RespondWithJSON(w, http.StatusOK, OIDCLoginResponse{AuthorizationURL: authURL})
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// HandlerOIDCCallback completes the authorization code flow, provisions the user on first login
+// and issues a session. Local two-factor authentication is left to the identity provider.
+func (api *ApiConfig) HandlerOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if idpError := query.Get("error"); idpError != "" {
+		message := "Identity provider returned an error: " + idpError
+		if description := query.Get("error_description"); description != "" {
+			message += " (" + description + ")"
+		}
+		RespondWithError(w, http.StatusUnauthorized, message)
+		return
+	}
+
+	code, state := query.Get("code"), query.Get("state")
+	if code == "" || state == "" {
+		RespondWithError(w, http.StatusBadRequest, "Code and state are required")
+		return
+	}
+
+	// The state is single use, so a replayed callback fails here
+	loginState, err := api.Queries.ConsumeOIDCLoginState(r.Context(), auth.HashToken(state))
+	if err != nil {
+		RespondWithError(w, http.StatusUnauthorized, "Invalid or expired login state")
+		return
+	}
+
+	provider, err := api.oidcProviderFor(r.Context(), loginState.OrganizationID)
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, "Single sign-on is not configured")
+		return
+	}
+
+	tokens, err := api.oidcClientFor(loginState.OrganizationID).Exchange(r.Context(), provider, code, loginState.CodeVerifier)
+	if err != nil {
+		slog.WarnContext(r.Context(), "OIDC code exchange failed", "issuer", provider.Issuer, "error", err)
+		RespondWithError(w, http.StatusUnauthorized, "Failed to complete login with the identity provider")
+		return
+	}
+
+	idToken, err := api.oidcClientFor(loginState.OrganizationID).VerifyIDToken(r.Context(), provider, tokens.IDToken, loginState.Nonce)
+	if err != nil {
+		slog.WarnContext(r.Context(), "OIDC ID token rejected", "issuer", provider.Issuer, "error", err)
+		RespondWithError(w, http.StatusUnauthorized, "Invalid ID token")
+		return
+	}
+
+	userID, created, err := api.provisionOIDCUser(r.Context(), provider, loginState.OrganizationID, idToken)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "OIDC provisioning failed", "subject", idToken.Subject, "issuer", idToken.Issuer, "error", err)
+		RespondWithError(w, http.StatusInternalServerError, "Failed to provision user")
+		return
+	}
+
+	user, err := api.Queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get user details")
+		return
+	}
+
+	attempt := api.newLoginAttempt(r, user.Username)
+	attempt.userID = uuid.NullUUID{UUID: user.ID, Valid: true}
+	if created {
+		api.logAuthEvent(r.Context(), authEventSSOProvisioned, attempt, idToken.Issuer)
+	}
+	api.logAuthEvent(r.Context(), authEventSSOLogin, attempt, idToken.Issuer)
+
+	// A login started from the device verification page approves the device rather than this browser
+	if loginState.DeviceCodeID.Valid {
+		if _, err := api.approveDeviceCode(r.Context(), r, loginState.DeviceCodeID.UUID, user.ID); err != nil {
+			renderDevicePage(w, http.StatusGone, devicePageData{Error: "The device login has expired. Start again on your device."})
+			return
+		}
+		renderDevicePage(w, http.StatusOK, devicePageData{Message: "Signed in as " + user.Username + ". Your device is now logged in; you can close this window."})
+		return
+	}
+
+	sessionTokens, err := api.issueSession(r, user.ID, "")
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Authentication failed")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.LoginResponse{
+		User:          models.DatabaseUserRowToUser(user),
+		TokenResponse: sessionTokens,
+	})
+}
+
+// syncOIDCMembership makes an SSO user a member of the provider's organization with the mapped role.
+// An existing member's role is only changed when the provider maps roles from a claim.
+func syncOIDCMembership(ctx context.Context, q *database.Queries, userID uuid.UUID, orgID uuid.NullUUID, role string, roleFromClaims bool) error {
+	if !orgID.Valid {
+		return nil
+	}
+
+	member, err := q.GetOrganizationMembership(ctx, database.GetOrganizationMembershipParams{
+		OrganizationID: orgID.UUID,
+		UserID:         userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = q.AddOrganizationMember(ctx, database.AddOrganizationMemberParams{
+			OrganizationID: orgID.UUID,
+			UserID:         userID,
+			Role:           role,
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if roleFromClaims && member.Role != role {
+		// The provider cannot demote the last owner; ownership is handed over with a transfer
+		if member.Role == authz.RoleOwner {
+			remains, err := otherOwnerRemains(ctx, q, orgID.UUID, userID)
+			if err != nil || !remains {
+				return err
+			}
+		}
+
+		_, err = q.UpdateOrganizationMemberRole(ctx, database.UpdateOrganizationMemberRoleParams{
+			OrganizationID: orgID.UUID,
+			UserID:         userID,
+			Role:           role,
+		})
+	}
+	return err
+}
+
+// provisionOIDCUser returns the user linked to the token's issuer and subject, creating one on first login.
+// Existing accounts are never linked by email, since the provider may not own that address.
+// Role and organization are kept in sync with the claims when the provider maps them.
+func (api *ApiConfig) provisionOIDCUser(ctx context.Context, provider oidc.ProviderConfig, boundOrg uuid.NullUUID, token *oidc.IDToken) (uuid.UUID, bool, error) {
+	var userID uuid.UUID
+	var created bool
+
+	err := api.withTx(ctx, func(q *database.Queries) error {
+		role := oidc.MapRole(provider, token)
+		orgID, orgFromClaims := oidcOrganization(ctx, q, provider, boundOrg, token)
+		email := sql.NullString{String: token.Email, Valid: token.Email != ""}
+
+		identity, err := q.GetUserIdentity(ctx, database.GetUserIdentityParams{Issuer: token.Issuer, Subject: token.Subject})
+		if err == nil {
+			userID = identity.UserID
+
+			user, err := q.GetUserByID(ctx, userID)
+			if err != nil {
+				return err
+			}
+			if orgFromClaims && user.OrganizationID != orgID {
+				if _, err := q.UpdateUserOrganization(ctx, database.UpdateUserOrganizationParams{ID: userID, OrganizationID: orgID}); err != nil {
+					return err
+				}
+			}
+			if err := syncOIDCMembership(ctx, q, userID, orgID, role, provider.RoleClaim != ""); err != nil {
+				return err
+			}
+
+			return q.TouchUserIdentity(ctx, database.TouchUserIdentityParams{ID: identity.ID, Email: email})
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		username, err := oidcUsername(ctx, q, token)
+		if err != nil {
+			return err
+		}
+
+		// SSO users have no usable local password until they reset it
+		secret, err := auth.GenerateOpaqueToken()
+		if err != nil {
+			return err
+		}
+		passwordHash, err := api.hashPassword(ctx, secret)
+		if err != nil {
+			return err
+		}
+
+		user, err := q.CreateSSOUser(ctx, database.CreateSSOUserParams{
+			ID:             uuid.New(),
+			Username:       username,
+			PasswordHash:   passwordHash,
+			OrganizationID: orgID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := syncOIDCMembership(ctx, q, user.ID, orgID, role, true); err != nil {
+			return err
+		}
+
+		if _, err := q.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+			ID:      uuid.New(),
+			UserID:  user.ID,
+			Issuer:  token.Issuer,
+			Subject: token.Subject,
+			Email:   email,
+		}); err != nil {
+			return err
+		}
+
+		userID, created = user.ID, true
+		return nil
+	})
+
+	return userID, created, err
+}
+
+// oidcOrganization picks the organization for an SSO user: the organization whose provider was used,
+// else the organization named by the global provider's org claim, else none.
+// It reports whether the login named an organization.
+func oidcOrganization(ctx context.Context, q *database.Queries, provider oidc.ProviderConfig, boundOrg uuid.NullUUID, token *oidc.IDToken) (uuid.NullUUID, bool) {
+	if boundOrg.Valid {
+		return boundOrg, true
+	}
+
+	if provider.OrgClaim != "" {
+		if refs := oidc.StringsClaim(token.Claims, provider.OrgClaim); len(refs) > 0 {
+			if org, err := findOrganization(ctx, q, refs[0]); err == nil {
+				return uuid.NullUUID{UUID: org.ID, Valid: true}, true
+			}
+		}
+	}
+
+	return uuid.NullUUID{}, false
+}
+
+// oidcUsername derives a free, valid username from the token's profile claims
+func oidcUsername(ctx context.Context, q *database.Queries, token *oidc.IDToken) (string, error) {
+	emailLocal, _, _ := strings.Cut(token.Email, "@")
+	base := "sso-" + token.Subject
+	for _, candidate := range []string{token.PreferredUsername, emailLocal, token.Name} {
+		if candidate = invalidUsernameChars.ReplaceAllString(candidate, "-"); len(strings.Trim(candidate, "-")) >= 3 {
+			base = strings.Trim(candidate, "-")
+			break
+		}
+	}
+	base = invalidUsernameChars.ReplaceAllString(base, "-")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	username := base
+	for i := 0; i < 5; i++ {
+		exists, err := q.UsernameExists(ctx, username)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return username, nil
+		}
+
+		suffix, err := auth.GenerateOpaqueToken()
+		if err != nil {
+			return "", err
+		}
+		username = base + "-" + suffix[:6]
+	}
+
+	return "", errors.New("could not find a free username")
+}
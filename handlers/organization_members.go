@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/authz"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// authEventMemberRemoved is logged when a member leaves or is removed from an organization
+const authEventMemberRemoved = "member_removed"
+
+// errLastOwner is returned when a change would leave an organization without an owner
+var errLastOwner = errors.New("an organization must keep at least one owner")
+
+// HandlerGetUserOrganizations lists the organizations the current user belongs to and their role in each
+func (api *ApiConfig) HandlerGetUserOrganizations(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	rows, err := api.Queries.ListUserMemberships(r.Context(), principal.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get organizations")
+		return
+	}
+
+	memberships := make([]models.Membership, len(rows))
+	for i, row := range rows {
+		memberships[i] = models.DatabaseMembershipRowToMembership(row, principal.DefaultOrganizationID)
+	}
+
+	RespondWithJSON(w, http.StatusOK, memberships)
+}
+
+// HandlerRemoveOrganizationMember removes a member from the admin's organization; admins can remove themselves to leave
+func (api *ApiConfig) HandlerRemoveOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	admin, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	targetID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	target, member, ok := api.organizationMember(w, r, orgID, targetID)
+	if !ok {
+		return
+	}
+
+	// Service accounts exist only within their organization
+	if target.AccountType != auth.AccountTypeHuman {
+		RespondWithError(w, http.StatusBadRequest, "Service accounts cannot be removed, disable them instead")
+		return
+	}
+
+	// Admins cannot remove members holding permissions they do not have themselves
+	if target.ID != admin.UserID {
+		current, err := api.Permissions.Permissions(r.Context(), uuid.NullUUID{UUID: orgID, Valid: true}, member.Role)
+		if err != nil && !errors.Is(err, authz.ErrUnknownRole) {
+			RespondWithError(w, http.StatusInternalServerError, "Failed to look up role")
+			return
+		}
+		if !authz.Grants(api.callerPermissions(r, admin), current...) {
+			RespondWithError(w, http.StatusForbidden, "You cannot remove a member with permissions you do not have")
+			return
+		}
+	}
+
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		if member.Role == authz.RoleOwner {
+			remains, err := otherOwnerRemains(r.Context(), q, orgID, target.ID)
+			if err != nil {
+				return err
+			}
+			if !remains {
+				return errLastOwner
+			}
+		}
+
+		if _, err := q.RemoveOrganizationMember(r.Context(), database.RemoveOrganizationMemberParams{
+			OrganizationID: orgID,
+			UserID:         target.ID,
+		}); err != nil {
+			return err
+		}
+
+		if err := q.ClearUserDefaultOrganization(r.Context(), database.ClearUserDefaultOrganizationParams{
+			ID:             target.ID,
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+		}); err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			Action:         auditMemberRemoved,
+			TargetType:     auditTargetMember,
+			TargetID:       target.ID,
+			Before:         map[string]string{"username": target.Username, "role": member.Role},
+		})
+	})
+	if errors.Is(err, errLastOwner) {
+		RespondWithError(w, http.StatusConflict, "The last owner cannot leave, transfer ownership first")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to remove member")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventMemberRemoved, loginAttempt{
+		username: target.Username,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: target.ID, Valid: true},
+	}, "role "+member.Role+" in organization "+orgID.String()+" by "+admin.Username)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// otherOwnerRemains locks the owners of an organization and reports whether one besides userID remains.
+// Locking keeps concurrent demotions from leaving the organization without an owner.
+func otherOwnerRemains(ctx context.Context, q *database.Queries, orgID, userID uuid.UUID) (bool, error) {
+	owners, err := q.LockOrganizationOwners(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+	for _, owner := range owners {
+		if owner != userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// organizationMember loads a user together with their membership in the organization,
+// responding with 404 unless they belong to it
+func (api *ApiConfig) organizationMember(w http.ResponseWriter, r *http.Request, orgID, userID uuid.UUID) (database.GetUserByIDRow, database.OrganizationMember, bool) {
+	var user database.GetUserByIDRow
+
+	member, err := api.Queries.GetOrganizationMembership(r.Context(), database.GetOrganizationMembershipParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "User not found")
+		return user, member, false
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get organization member")
+		return user, member, false
+	}
+
+	user, err = api.Queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, "User not found")
+		return user, member, false
+	}
+
+	return user, member, true
+}
@@ -8,14 +8,17 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/authz"
 	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/internal/oidc"
 	"github.com/omed0/go-hello-world/models"
+	"github.com/sqlc-dev/pqtype"
 )
 
 // HandlerCreateOrganization creates a new organization
 func (api *ApiConfig) HandlerCreateOrganization(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
 		return
@@ -45,34 +48,56 @@ func (api *ApiConfig) HandlerCreateOrganization(w http.ResponseWriter, r *http.R
 		Name: params.Name,
 	}
 
+	if params.Settings != nil {
+		settings, errMsg := encodeOrganizationSettings(params.Settings, pqtype.NullRawMessage{})
+		if errMsg != "" {
+			RespondWithError(w, http.StatusBadRequest, errMsg)
+			return
+		}
+		createParams.Settings = pqtype.NullRawMessage{RawMessage: settings, Valid: true}
+	}
+
 	if params.Description != nil {
 		createParams.Description.Valid = true
 		createParams.Description.String = *params.Description
 	}
 
-	org, err := api.Queries.CreateOrganization(r.Context(), createParams)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Failed to create organization: "+err.Error())
-		return
-	}
+	// The creator owns the new organization; it becomes their default only if they had none
+	var org database.Organization
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		org, err = q.CreateOrganization(r.Context(), createParams)
+		if err != nil {
+			return err
+		}
 
-	// Update user to be the owner of this organization
-	_, err = api.Queries.UpdateUserRole(r.Context(), database.UpdateUserRoleParams{
-		ID:   userID,
-		Role: "owner",
-	})
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Failed to set user as owner")
-		return
-	}
+		if _, err := q.AddOrganizationMember(r.Context(), database.AddOrganizationMemberParams{
+			OrganizationID: org.ID,
+			UserID:         principal.UserID,
+			Role:           authz.RoleOwner,
+		}); err != nil {
+			return err
+		}
 
-	// Set user's organization
-	_, err = api.Queries.UpdateUserOrganization(r.Context(), database.UpdateUserOrganizationParams{
-		ID:             userID,
-		OrganizationID: uuid.NullUUID{UUID: org.ID, Valid: true},
+		if !principal.DefaultOrganizationID.Valid {
+			if _, err := q.UpdateUserOrganization(r.Context(), database.UpdateUserOrganizationParams{
+				ID:             principal.UserID,
+				OrganizationID: uuid.NullUUID{UUID: org.ID, Valid: true},
+			}); err != nil {
+				return err
+			}
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: org.ID, Valid: true},
+			Action:         auditOrganizationCreated,
+			TargetType:     auditTargetOrganization,
+			TargetID:       org.ID,
+			After:          models.DatabaseOrganizationToOrganization(org),
+		})
 	})
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Failed to assign user to organization")
+		RespondWithError(w, http.StatusInternalServerError, "Failed to create organization: "+err.Error())
 		return
 	}
 
@@ -89,25 +114,16 @@ func (api *ApiConfig) HandlerGetOrganization(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Get user ID from context
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
 		return
 	}
 
-	// Get user to check permissions
-	user, err := api.Queries.GetUserByID(r.Context(), userID)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Failed to get user details")
-		return
-	}
-
 	// Check if user has access to this organization
-	if user.Role != "admin" && user.Role != "owner" {
-		if !user.OrganizationID.Valid || user.OrganizationID.UUID != orgID {
-			RespondWithError(w, http.StatusForbidden, "Access denied to this organization")
-			return
-		}
+	if !principal.InOrganization(orgID) {
+		RespondWithError(w, http.StatusForbidden, "Access denied to this organization")
+		return
 	}
 
 	// Get organization
@@ -120,7 +136,7 @@ func (api *ApiConfig) HandlerGetOrganization(w http.ResponseWriter, r *http.Requ
 	RespondWithJSON(w, http.StatusOK, models.DatabaseOrganizationToOrganization(org))
 }
 
-// HandlerGetOrganizationUsers gets all users in an organization
+// HandlerGetOrganizationUsers gets all members of an organization with their roles
 func (api *ApiConfig) HandlerGetOrganizationUsers(w http.ResponseWriter, r *http.Request) {
 	orgIDStr := chi.URLParam(r, "orgId")
 	orgID, err := uuid.Parse(orgIDStr)
@@ -130,41 +146,32 @@ func (api *ApiConfig) HandlerGetOrganizationUsers(w http.ResponseWriter, r *http
 	}
 
 	// Get user ID from context
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
 		return
 	}
 
-	// Get user to check permissions
-	user, err := api.Queries.GetUserByID(r.Context(), userID)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Failed to get user details")
-		return
-	}
-
 	// Check if user has access to this organization
-	if user.Role != "admin" && user.Role != "owner" {
-		if !user.OrganizationID.Valid || user.OrganizationID.UUID != orgID {
-			RespondWithError(w, http.StatusForbidden, "Access denied to this organization")
-			return
-		}
+	if !principal.InOrganization(orgID) {
+		RespondWithError(w, http.StatusForbidden, "Access denied to this organization")
+		return
 	}
 
-	// Get organization users
-	users, err := api.Queries.GetUsersByOrganization(r.Context(), uuid.NullUUID{UUID: orgID, Valid: true})
+	// Get organization members
+	rows, err := api.Queries.ListOrganizationMembers(r.Context(), orgID)
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "Failed to get organization users")
 		return
 	}
 
 	// Convert to response models
-	var responseUsers []models.User
-	for _, dbUser := range users {
-		responseUsers = append(responseUsers, models.DatabaseUserRowToUser(dbUser))
+	members := make([]models.OrganizationMember, len(rows))
+	for i, row := range rows {
+		members[i] = models.DatabaseOrganizationMemberRowToOrganizationMember(row)
 	}
 
-	RespondWithJSON(w, http.StatusOK, responseUsers)
+	RespondWithJSON(w, http.StatusOK, members)
 }
 
 // HandlerUpdateOrganization updates organization details
@@ -177,22 +184,15 @@ func (api *ApiConfig) HandlerUpdateOrganization(w http.ResponseWriter, r *http.R
 	}
 
 	// Get user ID from context
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
 		return
 	}
 
-	// Get user to check permissions - only owners and admins can update organizations
-	user, err := api.Queries.GetUserByID(r.Context(), userID)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Failed to get user details")
-		return
-	}
-
-	// Check permissions
-	if user.Role != "admin" && user.Role != "owner" {
-		RespondWithError(w, http.StatusForbidden, "Only owners and admins can update organizations")
+	// Check if user has access to this organization
+	if !principal.InOrganization(orgID) {
+		RespondWithError(w, http.StatusForbidden, "Access denied to this organization")
 		return
 	}
 
@@ -231,7 +231,39 @@ func (api *ApiConfig) HandlerUpdateOrganization(w http.ResponseWriter, r *http.R
 		updateParams.Description.String = *params.Description
 	}
 
-	org, err := api.Queries.UpdateOrganization(r.Context(), updateParams)
+	current, err := api.Queries.GetOrganizationByID(r.Context(), orgID)
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, "Organization not found")
+		return
+	}
+
+	// Settings are replaced as a whole when given
+	if params.Settings != nil {
+		settings, errMsg := encodeOrganizationSettings(params.Settings, current.Settings)
+		if errMsg != "" {
+			RespondWithError(w, http.StatusBadRequest, errMsg)
+			return
+		}
+		updateParams.Settings = pqtype.NullRawMessage{RawMessage: settings, Valid: true}
+	}
+
+	var org database.Organization
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		org, err = q.UpdateOrganization(r.Context(), updateParams)
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			Action:         auditOrganizationUpdated,
+			TargetType:     auditTargetOrganization,
+			TargetID:       orgID,
+			Before:         models.DatabaseOrganizationToOrganization(current),
+			After:          models.DatabaseOrganizationToOrganization(org),
+		})
+	})
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "Failed to update organization")
 		return
@@ -250,27 +282,33 @@ func (api *ApiConfig) HandlerDeleteOrganization(w http.ResponseWriter, r *http.R
 	}
 
 	// Get user ID from context
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
 		return
 	}
 
-	// Get user to check permissions - only owners can delete organizations
-	user, err := api.Queries.GetUserByID(r.Context(), userID)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Failed to get user details")
-		return
-	}
-
-	// Check permissions - only owners can delete organizations
-	if user.Role != "owner" {
-		RespondWithError(w, http.StatusForbidden, "Only organization owners can delete organizations")
+	// Check if user has access to this organization
+	if !principal.InOrganization(orgID) {
+		RespondWithError(w, http.StatusForbidden, "Access denied to this organization")
 		return
 	}
 
 	// Soft delete organization
-	_, err = api.Queries.SoftDeleteOrganization(r.Context(), orgID)
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		org, err := q.SoftDeleteOrganization(r.Context(), orgID)
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			Action:         auditOrganizationDeleted,
+			TargetType:     auditTargetOrganization,
+			TargetID:       orgID,
+			Before:         models.DatabaseOrganizationToOrganization(org),
+		})
+	})
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "Failed to delete organization")
 		return
@@ -278,3 +316,45 @@ func (api *ApiConfig) HandlerDeleteOrganization(w http.ResponseWriter, r *http.R
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// encodeOrganizationSettings validates the settings keys the server interprets and encodes the settings as JSON.
+// A missing or redacted OIDC client secret is carried over from the previous settings.
+func encodeOrganizationSettings(settings map[string]interface{}, previous pqtype.NullRawMessage) ([]byte, string) {
+	if provider, ok := settings["oidc"].(map[string]interface{}); ok {
+		if secret, _ := provider["client_secret"].(string); secret == "" || secret == models.RedactedSecret {
+			delete(provider, "client_secret")
+
+			var old models.OrganizationSettings
+			if previous.Valid && json.Unmarshal(previous.RawMessage, &old) == nil && old.OIDC != nil && old.OIDC.ClientSecret != "" {
+				provider["client_secret"] = old.OIDC.ClientSecret
+			}
+		}
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, "Invalid organization settings"
+	}
+
+	var known models.OrganizationSettings
+	if err := json.Unmarshal(data, &known); err != nil {
+		return nil, "Invalid organization settings: " + err.Error()
+	}
+
+	if known.PasswordPolicy != "" {
+		if _, ok := auth.PasswordPolicyByName(known.PasswordPolicy); !ok {
+			return nil, "Unknown password policy: " + known.PasswordPolicy
+		}
+	}
+
+	if known.OIDC != nil {
+		if err := known.OIDC.Validate(); err != nil {
+			return nil, "Invalid OIDC settings: " + err.Error()
+		}
+		if err := oidc.ValidatePublicIssuer(known.OIDC.Issuer); err != nil {
+			return nil, "Invalid OIDC settings: " + err.Error()
+		}
+	}
+
+	return data, ""
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/omed0/go-hello-world/models"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// TestEncodeOrganizationSettingsKeepsOIDCSecret checks that echoing back the redacted secret keeps the stored one
+func TestEncodeOrganizationSettingsKeepsOIDCSecret(t *testing.T) {
+	previous := pqtype.NullRawMessage{
+		RawMessage: json.RawMessage(`{"oidc":{"issuer":"https://idp.example","client_id":"app","client_secret":"s3cret"}}`),
+		Valid:      true,
+	}
+
+	settings := map[string]interface{}{
+		"oidc": map[string]interface{}{
+			"issuer":        "https://idp.example",
+			"client_id":     "app",
+			"client_secret": models.RedactedSecret,
+		},
+	}
+
+	data, errMsg := encodeOrganizationSettings(settings, previous)
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	}
+
+	var decoded models.OrganizationSettings
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.OIDC == nil || decoded.OIDC.ClientSecret != "s3cret" {
+		t.Errorf("expected stored secret to be kept, got %s", data)
+	}
+}
+
+// TestEncodeOrganizationSettingsRejectsInvalidOIDC checks that provider settings are validated
+func TestEncodeOrganizationSettingsRejectsInvalidOIDC(t *testing.T) {
+	settings := map[string]interface{}{
+		"oidc": map[string]interface{}{
+			"issuer":       "https://idp.example",
+			"client_id":    "app",
+			"role_mapping": map[string]interface{}{"everyone": "superuser"},
+		},
+	}
+
+	if _, errMsg := encodeOrganizationSettings(settings, pqtype.NullRawMessage{}); errMsg == "" {
+		t.Error("expected an unknown mapped role to be rejected")
+	}
+}
+
+// TestEncodeOrganizationSettingsRejectsInternalIssuer checks that organizations cannot point SSO at the server's network
+func TestEncodeOrganizationSettingsRejectsInternalIssuer(t *testing.T) {
+	for _, issuer := range []string{"http://idp.example", "https://169.254.169.254", "https://localhost:8443"} {
+		settings := map[string]interface{}{
+			"oidc": map[string]interface{}{"issuer": issuer, "client_id": "app"},
+		}
+
+		if _, errMsg := encodeOrganizationSettings(settings, pqtype.NullRawMessage{}); errMsg == "" {
+			t.Errorf("expected issuer %s to be rejected", issuer)
+		}
+	}
+}
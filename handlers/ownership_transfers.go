@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/authz"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/internal/notify"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// Auth events logged for ownership transfers
+const (
+	authEventOwnershipTransferRequested = "ownership_transfer_requested"
+	authEventOwnershipTransferCancelled = "ownership_transfer_cancelled"
+	authEventOwnershipTransferred       = "ownership_transferred"
+)
+
+// ownershipTransferTTL is how long the new owner has to confirm a transfer
+const ownershipTransferTTL = 72 * time.Hour
+
+// errOwnershipChanged is returned when the owner who started a transfer is no longer an owner
+var errOwnershipChanged = errors.New("the requesting owner is no longer an owner")
+
+// HandlerCreateOwnershipTransfer offers ownership of the organization to another member.
+// Nothing changes until that member confirms; a new transfer replaces any pending one.
+func (api *ApiConfig) HandlerCreateOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	owner, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	if owner.Role != authz.RoleOwner {
+		RespondWithError(w, http.StatusForbidden, "Only owners can transfer ownership")
+		return
+	}
+
+	var params models.CreateOwnershipTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+	if params.UserID == uuid.Nil {
+		RespondWithError(w, http.StatusBadRequest, "user_id is required")
+		return
+	}
+	if params.UserID == owner.UserID {
+		RespondWithError(w, http.StatusBadRequest, "You already own this organization")
+		return
+	}
+
+	target, member, ok := api.organizationMember(w, r, orgID, params.UserID)
+	if !ok {
+		return
+	}
+	if target.AccountType != auth.AccountTypeHuman || target.DisabledAt.Valid {
+		RespondWithError(w, http.StatusBadRequest, "Ownership can only be transferred to an active user")
+		return
+	}
+	if member.Role == authz.RoleOwner {
+		RespondWithError(w, http.StatusConflict, "User is already an owner")
+		return
+	}
+
+	var transfer database.OrganizationOwnershipTransfer
+	err := api.withTx(r.Context(), func(q *database.Queries) error {
+		if _, err := q.CancelOwnershipTransfers(r.Context(), orgID); err != nil {
+			return err
+		}
+
+		var err error
+		transfer, err = q.CreateOwnershipTransfer(r.Context(), database.CreateOwnershipTransferParams{
+			ID:             uuid.New(),
+			OrganizationID: orgID,
+			FromUserID:     owner.UserID,
+			ToUserID:       target.ID,
+			ExpiresAt:      time.Now().UTC().Add(ownershipTransferTTL),
+		})
+		return err
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to start ownership transfer")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventOwnershipTransferRequested, loginAttempt{
+		username: target.Username,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: target.ID, Valid: true},
+	}, "organization "+orgID.String()+" by "+owner.Username)
+
+	if err := api.Notifier.Send(r.Context(), notify.Message{
+		UserID:    target.ID,
+		Recipient: target.Username,
+		Subject:   "Organization ownership transfer",
+		Body: fmt.Sprintf("%s wants to make you the owner of organization %s. Confirm with POST /v1/organizations/%s/ownership-transfer/accept before %s",
+			owner.Username, orgID, orgID, transfer.ExpiresAt.Format(time.RFC3339)),
+	}); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to send ownership transfer", "transfer_id", transfer.ID, "error", err)
+	}
+
+	RespondWithJSON(w, http.StatusCreated, models.DatabaseOwnershipTransferToOwnershipTransfer(transfer))
+}
+
+// HandlerGetOwnershipTransfer returns the organization's pending ownership transfer
+func (api *ApiConfig) HandlerGetOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	_, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	transfer, err := api.Queries.GetPendingOwnershipTransfer(r.Context(), orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "No pending ownership transfer")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get ownership transfer")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.DatabaseOwnershipTransferToOwnershipTransfer(transfer))
+}
+
+// HandlerCancelOwnershipTransfer withdraws or declines the pending ownership transfer.
+// Owners and the member it was offered to can cancel it.
+func (api *ApiConfig) HandlerCancelOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	principal, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	transfer, err := api.Queries.GetPendingOwnershipTransfer(r.Context(), orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "No pending ownership transfer")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get ownership transfer")
+		return
+	}
+
+	if principal.Role != authz.RoleOwner && principal.UserID != transfer.ToUserID {
+		RespondWithError(w, http.StatusForbidden, "Only owners and the new owner can cancel the transfer")
+		return
+	}
+
+	if _, err := api.Queries.CancelOwnershipTransfers(r.Context(), orgID); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to cancel ownership transfer")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventOwnershipTransferCancelled, loginAttempt{
+		username: principal.Username,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: principal.UserID, Valid: true},
+	}, "organization "+orgID.String())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerAcceptOwnershipTransfer confirms a pending transfer: the caller becomes an owner and the owner who
+// started it becomes an admin
+func (api *ApiConfig) HandlerAcceptOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	principal, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	transfer, err := api.Queries.GetPendingOwnershipTransfer(r.Context(), orgID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && transfer.ToUserID != principal.UserID) {
+		RespondWithError(w, http.StatusNotFound, "No pending ownership transfer for you")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get ownership transfer")
+		return
+	}
+
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		transfer, err = q.AcceptOwnershipTransfer(r.Context(), transfer.ID)
+		if err != nil {
+			return err
+		}
+
+		// Lock the owners so the previous owner cannot be demoted or removed concurrently
+		owners, err := q.LockOrganizationOwners(r.Context(), orgID)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(owners, transfer.FromUserID) {
+			return errOwnershipChanged
+		}
+
+		if _, err := q.UpdateOrganizationMemberRole(r.Context(), database.UpdateOrganizationMemberRoleParams{
+			OrganizationID: orgID,
+			UserID:         principal.UserID,
+			Role:           authz.RoleOwner,
+		}); err != nil {
+			return err
+		}
+
+		if _, err := q.UpdateOrganizationMemberRole(r.Context(), database.UpdateOrganizationMemberRoleParams{
+			OrganizationID: orgID,
+			UserID:         transfer.FromUserID,
+			Role:           authz.RoleAdmin,
+		}); err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			Action:         auditOwnershipTransferred,
+			TargetType:     auditTargetOrganization,
+			TargetID:       orgID,
+			Before:         map[string]string{"owner_id": transfer.FromUserID.String()},
+			After:          map[string]string{"owner_id": principal.UserID.String()},
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "No pending ownership transfer for you")
+		return
+	}
+	if errors.Is(err, errOwnershipChanged) {
+		RespondWithError(w, http.StatusConflict, "The owner who started the transfer is no longer an owner")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to transfer ownership")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventOwnershipTransferred, loginAttempt{
+		username: principal.Username,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: principal.UserID, Valid: true},
+	}, "organization "+orgID.String()+" from "+transfer.FromUserID.String())
+
+	RespondWithJSON(w, http.StatusOK, models.DatabaseOwnershipTransferToOwnershipTransfer(transfer))
+}
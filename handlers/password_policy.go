@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// ErrUnknownPasswordPolicy is returned when PASSWORD_POLICY names a policy that does not exist
+var ErrUnknownPasswordPolicy = errors.New("PASSWORD_POLICY is not a known password policy")
+
+// PasswordPolicyErrorResponse lists the rules a rejected password failed
+type PasswordPolicyErrorResponse struct {
+	Error   string                   `json:"error"`
+	Policy  string                   `json:"policy"`
+	Reasons []auth.PasswordViolation `json:"reasons"`
+}
+
+// passwordPolicyFor returns the password policy selected by an organization's settings,
+// falling back to the global policy
+func (api *ApiConfig) passwordPolicyFor(ctx context.Context, orgID uuid.NullUUID) auth.PasswordPolicy {
+	if orgID.Valid {
+		if org, err := api.Queries.GetOrganizationByID(ctx, orgID.UUID); err == nil && org.Settings.Valid {
+			var settings models.OrganizationSettings
+			if json.Unmarshal(org.Settings.RawMessage, &settings) == nil {
+				if policy, ok := auth.PasswordPolicyByName(settings.PasswordPolicy); ok {
+					return policy
+				}
+			}
+		}
+	}
+
+	if policy, ok := auth.PasswordPolicyByName(api.Config.PasswordPolicy); ok {
+		return policy
+	}
+	return auth.DefaultPasswordPolicy()
+}
+
+// checkPasswordPolicy validates a new password and responds with the failed rules if it is rejected.
+// It reports whether the password was accepted.
+func (api *ApiConfig) checkPasswordPolicy(w http.ResponseWriter, r *http.Request, orgID uuid.NullUUID, password, username string) bool {
+	err := api.passwordPolicyFor(r.Context(), orgID).Validate(password, username)
+	if err == nil {
+		return true
+	}
+
+	var policyErr *auth.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	RespondWithJSON(w, http.StatusBadRequest, PasswordPolicyErrorResponse{
+		Error:   "Password does not meet the password policy",
+		Policy:  policyErr.Policy,
+		Reasons: policyErr.Violations,
+	})
+	return false
+}
+
+// HandlerGetPasswordPolicy returns the password policy that applies to the current user
+func (api *ApiConfig) HandlerGetPasswordPolicy(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, api.passwordPolicyFor(r.Context(), principal.OrganizationID))
+}
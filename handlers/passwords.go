@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/internal/notify"
+	"github.com/omed0/go-hello-world/internal/tracing"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// passwordResetAccepted is returned for every reset request so usernames cannot be enumerated
+const passwordResetAccepted = "If the account exists, a password reset token has been sent"
+
+// errResetTokenUsed is returned when a reset token was consumed by a concurrent request
+var errResetTokenUsed = errors.New("reset token already used")
+
+// MessageResponse represents a response that only carries a message
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// HandlerChangePassword changes the current user's password after re-checking the current one
+func (api *ApiConfig) HandlerChangePassword(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	var params models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if params.CurrentPassword == "" || params.NewPassword == "" {
+		RespondWithError(w, http.StatusBadRequest, "Current and new password are required")
+		return
+	}
+
+	user, err := api.Queries.GetUserByID(r.Context(), principal.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get user details")
+		return
+	}
+
+	valid, err := api.verifyPassword(r.Context(), params.CurrentPassword, user.PasswordHash)
+	if err != nil || !valid {
+		RespondWithError(w, http.StatusForbidden, "Current password is incorrect")
+		return
+	}
+
+	if params.NewPassword == params.CurrentPassword {
+		RespondWithError(w, http.StatusBadRequest, "New password must be different from the current password")
+		return
+	}
+
+	if !api.checkPasswordPolicy(w, r, user.OrganizationID, params.NewPassword, user.Username) {
+		return
+	}
+
+	passwordHash, err := api.hashPassword(r.Context(), params.NewPassword)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to process password")
+		return
+	}
+
+	// Keep the credential used for this request; every other session and key is revoked
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		return setPassword(r.Context(), q, principal.UserID, passwordHash, principal.SessionID, principal.APIKeyID)
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerRequestPasswordReset sends a single-use reset token to the user through the notification sink
+func (api *ApiConfig) HandlerRequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var params models.PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	params.Username = strings.TrimSpace(params.Username)
+	if params.Username == "" {
+		RespondWithError(w, http.StatusBadRequest, "Username is required")
+		return
+	}
+
+	// Service accounts have no password to reset; the response is the same so accounts cannot be probed
+	if user, err := api.Queries.GetUserByUsername(r.Context(), params.Username); err == nil && user.AccountType == auth.AccountTypeHuman {
+		if err := api.sendPasswordResetToken(r.Context(), user.ID, user.Username, uuid.NullUUID{}); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to send password reset token", "error", err)
+		}
+	}
+
+	RespondWithJSON(w, http.StatusAccepted, MessageResponse{Message: passwordResetAccepted})
+}
+
+// HandlerResetPassword sets a new password using a reset token
+func (api *ApiConfig) HandlerResetPassword(w http.ResponseWriter, r *http.Request) {
+	var params models.PasswordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	params.Token = strings.TrimSpace(params.Token)
+	if params.Token == "" || params.NewPassword == "" {
+		RespondWithError(w, http.StatusBadRequest, "Token and new password are required")
+		return
+	}
+
+	resetToken, err := api.Queries.GetValidPasswordResetToken(r.Context(), auth.HashToken(params.Token))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid or expired reset token")
+		return
+	}
+
+	user, err := api.Queries.GetUserByID(r.Context(), resetToken.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get user details")
+		return
+	}
+
+	if !api.checkPasswordPolicy(w, r, user.OrganizationID, params.NewPassword, user.Username) {
+		return
+	}
+
+	passwordHash, err := api.hashPassword(r.Context(), params.NewPassword)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to process password")
+		return
+	}
+
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		// Consume the token first so concurrent requests cannot reuse it
+		if _, err := q.UsePasswordResetToken(r.Context(), resetToken.ID); err != nil {
+			return errResetTokenUsed
+		}
+		return setPassword(r.Context(), q, resetToken.UserID, passwordHash, uuid.Nil, uuid.Nil)
+	})
+	if errors.Is(err, errResetTokenUsed) {
+		RespondWithError(w, http.StatusBadRequest, "Invalid or expired reset token")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerAdminResetPassword lets an admin send a reset token to a member of their organization
+func (api *ApiConfig) HandlerAdminResetPassword(w http.ResponseWriter, r *http.Request) {
+	admin, target, ok := api.orgMemberForAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	if err := api.sendPasswordResetToken(r.Context(), target.ID, target.Username, uuid.NullUUID{UUID: admin.UserID, Valid: true}); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to send password reset token")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusAccepted, MessageResponse{Message: "Password reset token sent to " + target.Username})
+}
+
+// sendPasswordResetToken replaces any pending reset token for the user and delivers a new one
+func (api *ApiConfig) sendPasswordResetToken(ctx context.Context, userID uuid.UUID, username string, requestedBy uuid.NullUUID) error {
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	err = api.withTx(ctx, func(q *database.Queries) error {
+		if err := q.InvalidateUserPasswordResetTokens(ctx, userID); err != nil {
+			return err
+		}
+
+		_, err := q.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+			ID:          uuid.New(),
+			UserID:      userID,
+			TokenHash:   auth.HashToken(token),
+			RequestedBy: requestedBy,
+			ExpiresAt:   time.Now().UTC().Add(api.Config.PasswordResetTTL),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return api.Notifier.Send(ctx, notify.Message{
+		UserID:    userID,
+		Recipient: username,
+		Subject:   "Password reset",
+		Body: fmt.Sprintf("Use this token with POST /v1/password/reset to choose a new password: %s (valid for %s)",
+			token, api.Config.PasswordResetTTL),
+	})
+}
+
+// verifyPassword checks a password against its stored hash in a span of its own, since argon2 is
+// deliberately slow and often dominates login latency
+func (api *ApiConfig) verifyPassword(ctx context.Context, password, hash string) (bool, error) {
+	_, span := api.Tracer.Start(ctx, "argon2 verify", tracing.SpanKindInternal)
+	defer span.End()
+	return auth.VerifyPassword(password, hash, api.Passwords)
+}
+
+// hashPassword hashes a password with the current parameters in a span of its own
+func (api *ApiConfig) hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := api.Tracer.Start(ctx, "argon2 hash", tracing.SpanKindInternal)
+	defer span.End()
+	return auth.HashPassword(password, api.Passwords)
+}
+
+// newDummyPasswordHash hashes a random password with the current parameters, giving failed logins
+// for unknown accounts the same argon2 cost as those for real ones
+func newDummyPasswordHash(passwords *auth.PasswordConfig) (string, error) {
+	password, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	return auth.HashPassword(password, passwords)
+}
+
+// rehashPasswordIfNeeded upgrades a verified password to the current hashing parameters and pepper.
+// Failures are only logged because the login itself already succeeded.
+func (api *ApiConfig) rehashPasswordIfNeeded(ctx context.Context, userID uuid.UUID, password, currentHash string) {
+	if !auth.NeedsRehash(currentHash, api.Passwords) {
+		return
+	}
+
+	newHash, err := api.hashPassword(ctx, password)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to rehash password", "error", err)
+		return
+	}
+
+	// Only replace the hash that was verified, in case the password changed concurrently
+	if err := api.Queries.RehashUserPassword(ctx, database.RehashUserPasswordParams{
+		ID:      userID,
+		OldHash: currentHash,
+		NewHash: newHash,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to store rehashed password", "error", err)
+	}
+}
+
+// setPassword stores a new password hash and invalidates every session, API key and reset token
+// of the user except the session and key given, which may be uuid.Nil
+func setPassword(ctx context.Context, q *database.Queries, userID uuid.UUID, passwordHash string, keepSessionID, keepKeyID uuid.UUID) error {
+	if _, err := q.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: passwordHash,
+	}); err != nil {
+		return err
+	}
+
+	if err := q.RevokeOtherUserSessions(ctx, database.RevokeOtherUserSessionsParams{
+		UserID: userID,
+		ID:     keepSessionID,
+	}); err != nil {
+		return err
+	}
+
+	if err := q.RevokeOtherUserAPIKeys(ctx, database.RevokeOtherUserAPIKeysParams{
+		UserID: userID,
+		ID:     keepKeyID,
+	}); err != nil {
+		return err
+	}
+
+	return q.InvalidateUserPasswordResetTokens(ctx, userID)
+}
@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/authz"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// Auth events logged for role management
+const (
+	authEventRoleCreated = "role_created"
+	authEventRoleUpdated = "role_updated"
+	authEventRoleDeleted = "role_deleted"
+	authEventRoleChanged = "member_role_changed"
+)
+
+// roleNamePattern keeps custom role names short identifiers that fit the role column
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// errRoleExists is returned when an organization already has a role with the requested name
+var errRoleExists = errors.New("role already exists")
+
+// HandlerGetRoles lists the built-in roles and the custom roles of an organization
+func (api *ApiConfig) HandlerGetRoles(w http.ResponseWriter, r *http.Request) {
+	_, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	custom, err := api.Queries.ListOrganizationRoles(r.Context(), orgID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get roles")
+		return
+	}
+
+	var roles []models.Role
+	for _, role := range authz.BuiltinRoles() {
+		roles = append(roles, models.BuiltinRoleToRole(role))
+	}
+	for _, role := range custom {
+		roles = append(roles, models.DatabaseOrganizationRoleToRole(role))
+	}
+
+	RespondWithJSON(w, http.StatusOK, roles)
+}
+
+// HandlerCreateRole defines a custom role in the admin's organization
+func (api *ApiConfig) HandlerCreateRole(w http.ResponseWriter, r *http.Request) {
+	admin, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var params models.CreateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	// Validate input
+	if !roleNamePattern.MatchString(params.Name) {
+		RespondWithError(w, http.StatusBadRequest, "Role name must be 2-50 lowercase letters, digits, '-' or '_' and start with a letter")
+		return
+	}
+	if authz.IsBuiltinRole(params.Name) {
+		RespondWithError(w, http.StatusConflict, "Role name is reserved for a built-in role")
+		return
+	}
+
+	permissions, ok := api.grantablePermissions(w, r, admin, params.Permissions)
+	if !ok {
+		return
+	}
+
+	var role database.OrganizationRole
+	err := api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		role, err = q.CreateOrganizationRole(r.Context(), database.CreateOrganizationRoleParams{
+			ID:             uuid.New(),
+			OrganizationID: orgID,
+			Name:           params.Name,
+			Description:    nullString(params.Description),
+			Permissions:    permissions,
+		})
+		if err != nil {
+			return errRoleExists
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			Action:         auditRoleCreated,
+			TargetType:     auditTargetRole,
+			TargetID:       role.ID,
+			After:          models.DatabaseOrganizationRoleToRole(role),
+		})
+	})
+	if errors.Is(err, errRoleExists) {
+		RespondWithError(w, http.StatusConflict, "Role already exists")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to create role")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventRoleCreated, loginAttempt{ip: api.clientIP(r)},
+		"role "+role.Name+" in organization "+orgID.String()+" by "+admin.Username)
+
+	RespondWithJSON(w, http.StatusCreated, models.DatabaseOrganizationRoleToRole(role))
+}
+
+// HandlerUpdateRole replaces the description and permissions of a custom role
+func (api *ApiConfig) HandlerUpdateRole(w http.ResponseWriter, r *http.Request) {
+	admin, role, ok := api.customRoleForAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var params models.UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	// Removing permissions is an escalation too when the admin could not have granted them
+	if !authz.Grants(api.callerPermissions(r, admin), role.Permissions...) {
+		RespondWithError(w, http.StatusForbidden, "You cannot change a role with permissions you do not have")
+		return
+	}
+
+	permissions, ok := api.grantablePermissions(w, r, admin, params.Permissions)
+	if !ok {
+		return
+	}
+
+	var updated database.OrganizationRole
+	err := api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		updated, err = q.UpdateOrganizationRole(r.Context(), database.UpdateOrganizationRoleParams{
+			ID:             role.ID,
+			OrganizationID: role.OrganizationID,
+			Description:    nullString(params.Description),
+			Permissions:    permissions,
+		})
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: role.OrganizationID, Valid: true},
+			Action:         auditRoleUpdated,
+			TargetType:     auditTargetRole,
+			TargetID:       role.ID,
+			Before:         models.DatabaseOrganizationRoleToRole(role),
+			After:          models.DatabaseOrganizationRoleToRole(updated),
+		})
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to update role")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventRoleUpdated, loginAttempt{ip: api.clientIP(r)},
+		"role "+role.Name+" in organization "+role.OrganizationID.String()+" by "+admin.Username)
+
+	RespondWithJSON(w, http.StatusOK, models.DatabaseOrganizationRoleToRole(updated))
+}
+
+// HandlerDeleteRole removes a custom role that no member holds anymore
+func (api *ApiConfig) HandlerDeleteRole(w http.ResponseWriter, r *http.Request) {
+	admin, role, ok := api.customRoleForAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := api.Queries.CountOrganizationRoleMembers(r.Context(), database.CountOrganizationRoleMembersParams{
+		OrganizationID: role.OrganizationID,
+		Role:           role.Name,
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to delete role")
+		return
+	}
+	if members > 0 {
+		RespondWithError(w, http.StatusConflict, "Role is still assigned to members, assign them another role first")
+		return
+	}
+
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		if _, err := q.DeleteOrganizationRole(r.Context(), database.DeleteOrganizationRoleParams{
+			ID:             role.ID,
+			OrganizationID: role.OrganizationID,
+		}); err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: role.OrganizationID, Valid: true},
+			Action:         auditRoleDeleted,
+			TargetType:     auditTargetRole,
+			TargetID:       role.ID,
+			Before:         models.DatabaseOrganizationRoleToRole(role),
+		})
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to delete role")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventRoleDeleted, loginAttempt{ip: api.clientIP(r)},
+		"role "+role.Name+" in organization "+role.OrganizationID.String()+" by "+admin.Username)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerAssignRole gives a member of the admin's organization a built-in or custom role
+func (api *ApiConfig) HandlerAssignRole(w http.ResponseWriter, r *http.Request) {
+	admin, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	targetID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	target, member, ok := api.organizationMember(w, r, orgID, targetID)
+	if !ok {
+		return
+	}
+
+	if target.ID == admin.UserID {
+		RespondWithError(w, http.StatusForbidden, "You cannot change your own role")
+		return
+	}
+
+	var params models.AssignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	org := uuid.NullUUID{UUID: orgID, Valid: true}
+	granted, err := api.Permissions.Permissions(r.Context(), org, params.Role)
+	if errors.Is(err, authz.ErrUnknownRole) {
+		RespondWithError(w, http.StatusBadRequest, "Unknown role: "+params.Role)
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to look up role")
+		return
+	}
+
+	// Admins can neither hand out nor take away more than they hold themselves
+	current, err := api.Permissions.Permissions(r.Context(), org, member.Role)
+	if err != nil && !errors.Is(err, authz.ErrUnknownRole) {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to look up role")
+		return
+	}
+	if !authz.Grants(api.callerPermissions(r, admin), slices.Concat(granted, current)...) {
+		RespondWithError(w, http.StatusForbidden, "You cannot assign or revoke permissions you do not have")
+		return
+	}
+
+	var updated database.OrganizationMember
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		if member.Role == authz.RoleOwner && params.Role != authz.RoleOwner {
+			remains, err := otherOwnerRemains(r.Context(), q, orgID, target.ID)
+			if err != nil {
+				return err
+			}
+			if !remains {
+				return errLastOwner
+			}
+		}
+
+		var err error
+		updated, err = q.UpdateOrganizationMemberRole(r.Context(), database.UpdateOrganizationMemberRoleParams{
+			OrganizationID: orgID,
+			UserID:         target.ID,
+			Role:           params.Role,
+		})
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: org,
+			Action:         auditMemberRoleChanged,
+			TargetType:     auditTargetMember,
+			TargetID:       target.ID,
+			Before:         map[string]string{"role": member.Role},
+			After:          map[string]string{"role": updated.Role},
+		})
+	})
+	if errors.Is(err, errLastOwner) {
+		RespondWithError(w, http.StatusConflict, "The last owner cannot be demoted, transfer ownership first")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to update role")
+		return
+	}
+
+	api.logAuthEvent(r.Context(), authEventRoleChanged, loginAttempt{
+		username: target.Username,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: target.ID, Valid: true},
+	}, member.Role+" -> "+updated.Role+" in organization "+orgID.String()+" by "+admin.Username)
+
+	RespondWithJSON(w, http.StatusOK, models.DatabaseOrganizationMemberRowToOrganizationMember(database.ListOrganizationMembersRow{
+		OrganizationMember: updated,
+		Username:           target.Username,
+		AccountType:        target.AccountType,
+		DisabledAt:         target.DisabledAt,
+	}))
+}
+
+// customRoleForAdmin loads the custom role named in the URL if it belongs to the admin's organization
+func (api *ApiConfig) customRoleForAdmin(w http.ResponseWriter, r *http.Request) (*auth.Principal, database.OrganizationRole, bool) {
+	var role database.OrganizationRole
+
+	admin, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return admin, role, false
+	}
+
+	roleID, err := uuid.Parse(chi.URLParam(r, "roleId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid role ID")
+		return admin, role, false
+	}
+
+	role, err = api.Queries.GetOrganizationRole(r.Context(), database.GetOrganizationRoleParams{
+		ID:             roleID,
+		OrganizationID: orgID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "Role not found")
+		return admin, role, false
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get role")
+		return admin, role, false
+	}
+
+	return admin, role, true
+}
+
+// grantablePermissions validates the permissions of a custom role and checks the admin holds all of them,
+// returning them sorted and without duplicates
+func (api *ApiConfig) grantablePermissions(w http.ResponseWriter, r *http.Request, admin *auth.Principal, permissions []string) ([]string, bool) {
+	if len(permissions) == 0 {
+		RespondWithError(w, http.StatusBadRequest, "At least one permission is required")
+		return nil, false
+	}
+	if err := authz.ValidatePermissions(permissions); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	if !authz.Grants(api.callerPermissions(r, admin), permissions...) {
+		RespondWithError(w, http.StatusForbidden, "You cannot grant permissions you do not have")
+		return nil, false
+	}
+
+	permissions = slices.Clone(permissions)
+	slices.Sort(permissions)
+	return slices.Compact(permissions), true
+}
+
+// callerPermissions returns what the admin's own role grants; a lookup failure grants nothing
+func (api *ApiConfig) callerPermissions(r *http.Request, admin *auth.Principal) []string {
+	permissions, err := api.Permissions.PrincipalPermissions(r.Context(), admin)
+	if err != nil {
+		return nil
+	}
+	return permissions
+}
+
+// nullString converts an optional string to a nullable column value
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
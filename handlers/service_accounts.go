@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/authz"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// Auth events logged for service account management
+const (
+	authEventServiceAccountCreated  = "service_account_created"
+	authEventServiceAccountDisabled = "service_account_disabled"
+	authEventServiceAccountEnabled  = "service_account_enabled"
+	authEventServiceAccountKey      = "service_account_key_created"
+)
+
+// defaultServiceAccountKeyName names the first key of a service account when the request does not
+const defaultServiceAccountKeyName = "default"
+
+var errUsernameTaken = errors.New("username taken")
+
+// HandlerCreateServiceAccount creates a service account in the admin's organization together with its first API key
+func (api *ApiConfig) HandlerCreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	admin, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var params models.CreateServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	params.Name = strings.TrimSpace(params.Name)
+	if valid, errMsg := validateUsername(params.Name); !valid {
+		RespondWithError(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	keyParams := models.CreateAPIKeyRequest{Name: params.KeyName, Scopes: params.Scopes, ExpiresAt: params.ExpiresAt}
+	if strings.TrimSpace(keyParams.Name) == "" {
+		keyParams.Name = defaultServiceAccountKeyName
+	}
+	if !validateServiceAccountKey(w, r, &keyParams) {
+		return
+	}
+
+	var response models.ServiceAccountWithKey
+	err := api.withTx(r.Context(), func(q *database.Queries) error {
+		exists, err := q.UsernameExists(r.Context(), params.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errUsernameTaken
+		}
+
+		account, err := q.CreateServiceAccount(r.Context(), database.CreateServiceAccountParams{
+			ID:             uuid.New(),
+			Username:       params.Name,
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			CreatedBy:      uuid.NullUUID{UUID: admin.UserID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.AddOrganizationMember(r.Context(), database.AddOrganizationMemberParams{
+			OrganizationID: orgID,
+			UserID:         account.ID,
+			Role:           authz.RoleUser,
+		}); err != nil {
+			return err
+		}
+
+		key, err := createAPIKey(r.Context(), q, account.ID, keyParams)
+		if err != nil {
+			return err
+		}
+
+		response = models.ServiceAccountWithKey{
+			ServiceAccount: models.DatabaseUserToServiceAccount(account),
+			APIKey:         key,
+		}
+
+		if err := api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			Action:         auditServiceAccountCreated,
+			TargetType:     auditTargetServiceAccount,
+			TargetID:       account.ID,
+			After:          response.ServiceAccount,
+		}); err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+			Action:         auditAPIKeyCreated,
+			TargetType:     auditTargetAPIKey,
+			TargetID:       key.ID,
+			After:          auditAPIKey{APIKey: key.APIKey, UserID: account.ID},
+		})
+	})
+	if errors.Is(err, errUsernameTaken) {
+		RespondWithError(w, http.StatusConflict, "Username already exists")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to create service account")
+		return
+	}
+
+	api.logServiceAccountEvent(r, authEventServiceAccountCreated, response.ServiceAccount, admin.Username)
+	RespondWithJSON(w, http.StatusCreated, response)
+}
+
+// HandlerGetServiceAccounts lists the service accounts of the admin's organization
+func (api *ApiConfig) HandlerGetServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	_, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	accounts, err := api.Queries.ListServiceAccounts(r.Context(), uuid.NullUUID{UUID: orgID, Valid: true})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get service accounts")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.DatabaseUsersToServiceAccounts(accounts))
+}
+
+// HandlerGetServiceAccountKeys lists the active API keys of a service account
+func (api *ApiConfig) HandlerGetServiceAccountKeys(w http.ResponseWriter, r *http.Request) {
+	_, account, ok := api.serviceAccountForAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := api.Queries.GetAPIKeysByUser(r.Context(), account.ID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get API keys")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.DatabaseAPIKeysToAPIKeys(keys))
+}
+
+// HandlerCreateServiceAccountKey issues an additional scoped API key for a service account
+func (api *ApiConfig) HandlerCreateServiceAccountKey(w http.ResponseWriter, r *http.Request) {
+	admin, account, ok := api.serviceAccountForAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var params models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if !validateServiceAccountKey(w, r, &params) {
+		return
+	}
+
+	var key models.APIKeyWithSecret
+	err := api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		key, err = createAPIKey(r.Context(), q, account.ID, params)
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: account.OrganizationID,
+			Action:         auditAPIKeyCreated,
+			TargetType:     auditTargetAPIKey,
+			TargetID:       key.ID,
+			After:          auditAPIKey{APIKey: key.APIKey, UserID: account.ID},
+		})
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	api.logServiceAccountEvent(r, authEventServiceAccountKey, models.DatabaseUserToServiceAccount(account), admin.Username)
+	RespondWithJSON(w, http.StatusCreated, key)
+}
+
+// HandlerRevokeServiceAccountKey permanently revokes an API key of a service account
+func (api *ApiConfig) HandlerRevokeServiceAccountKey(w http.ResponseWriter, r *http.Request) {
+	_, account, ok := api.serviceAccountForAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	keyID, err := uuid.Parse(chi.URLParam(r, "keyId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	err = api.revokeAPIKey(r, account.OrganizationID, account.ID, keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerDisableServiceAccount stops a service account's keys from authenticating until it is enabled again
+func (api *ApiConfig) HandlerDisableServiceAccount(w http.ResponseWriter, r *http.Request) {
+	api.setServiceAccountDisabled(w, r, true)
+}
+
+// HandlerEnableServiceAccount lets a disabled service account's keys authenticate again
+func (api *ApiConfig) HandlerEnableServiceAccount(w http.ResponseWriter, r *http.Request) {
+	api.setServiceAccountDisabled(w, r, false)
+}
+
+func (api *ApiConfig) setServiceAccountDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	admin, account, ok := api.serviceAccountForAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	// Nothing to do if the account is already in the requested state, which also keeps the original timestamp
+	if account.DisabledAt.Valid == disabled {
+		RespondWithJSON(w, http.StatusOK, models.DatabaseUserToServiceAccount(account))
+		return
+	}
+
+	disabledAt := sql.NullTime{}
+	eventType, action := authEventServiceAccountEnabled, auditServiceAccountEnabled
+	if disabled {
+		disabledAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		eventType, action = authEventServiceAccountDisabled, auditServiceAccountDisabled
+	}
+
+	var updated database.User
+	err := api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		updated, err = q.SetUserDisabledAt(r.Context(), database.SetUserDisabledAtParams{
+			ID:         account.ID,
+			DisabledAt: disabledAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: account.OrganizationID,
+			Action:         action,
+			TargetType:     auditTargetServiceAccount,
+			TargetID:       account.ID,
+			Before:         models.DatabaseUserToServiceAccount(account),
+			After:          models.DatabaseUserToServiceAccount(updated),
+		})
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to update service account")
+		return
+	}
+
+	response := models.DatabaseUserToServiceAccount(updated)
+	api.logServiceAccountEvent(r, eventType, response, admin.Username)
+	RespondWithJSON(w, http.StatusOK, response)
+}
+
+// validateServiceAccountKey validates a key request for a service account, which never gets unrestricted access
+func validateServiceAccountKey(w http.ResponseWriter, r *http.Request, params *models.CreateAPIKeyRequest) bool {
+	if slices.Contains(params.Scopes, auth.ScopeAll) {
+		RespondWithError(w, http.StatusBadRequest, "Service account keys must list explicit scopes")
+		return false
+	}
+
+	if status, errMsg := validateAPIKeyRequest(r.Context(), params); errMsg != "" {
+		RespondWithError(w, status, errMsg)
+		return false
+	}
+	return true
+}
+
+// organizationAdmin returns the current user if they belong to the organization named in the URL
+func (api *ApiConfig) organizationAdmin(w http.ResponseWriter, r *http.Request) (*auth.Principal, uuid.UUID, bool) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organization ID")
+		return nil, orgID, false
+	}
+
+	admin, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return nil, orgID, false
+	}
+
+	if !admin.InOrganization(orgID) {
+		RespondWithError(w, http.StatusForbidden, "Access denied to this organization")
+		return nil, orgID, false
+	}
+
+	return admin, orgID, true
+}
+
+// serviceAccountForAdmin loads the service account named in the URL if it belongs to the admin's organization
+func (api *ApiConfig) serviceAccountForAdmin(w http.ResponseWriter, r *http.Request) (*auth.Principal, database.User, bool) {
+	var account database.User
+
+	admin, orgID, ok := api.organizationAdmin(w, r)
+	if !ok {
+		return admin, account, false
+	}
+
+	accountID, err := uuid.Parse(chi.URLParam(r, "accountId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid service account ID")
+		return admin, account, false
+	}
+
+	account, err = api.Queries.GetServiceAccount(r.Context(), database.GetServiceAccountParams{
+		ID:             accountID,
+		OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, "Service account not found")
+		return admin, account, false
+	}
+
+	return admin, account, true
+}
+
+// logServiceAccountEvent records a management action on a service account along with the admin who took it
+func (api *ApiConfig) logServiceAccountEvent(r *http.Request, eventType string, account models.ServiceAccount, adminUsername string) {
+	api.logAuthEvent(r.Context(), eventType, loginAttempt{
+		username: account.Name,
+		ip:       api.clientIP(r),
+		userID:   uuid.NullUUID{UUID: account.ID, Valid: true},
+	}, "by "+adminUsername)
+}
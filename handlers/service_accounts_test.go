@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// TestValidateServiceAccountKeyRequiresExplicitScopes checks that service accounts never get unrestricted keys
+func TestValidateServiceAccountKeyRequiresExplicitScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		want   bool
+		status int
+	}{
+		{"explicit scopes", []string{auth.ScopeTasksWrite}, true, http.StatusOK},
+		{"unrestricted", []string{auth.ScopeTasksRead, auth.ScopeAll}, false, http.StatusBadRequest},
+		{"no scopes", nil, false, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			params := models.CreateAPIKeyRequest{Name: "ci", Scopes: tt.scopes}
+
+			if got := validateServiceAccountKey(w, r, &params); got != tt.want {
+				t.Errorf("validateServiceAccountKey = %v, want %v", got, tt.want)
+			}
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/authz"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// authEventSessionRevoked is logged when a session is signed out remotely
+const authEventSessionRevoked = "session_revoked"
+
+// HandlerGetSessions lists the current user's active sessions, marking the one making the request
+func (api *ApiConfig) HandlerGetSessions(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	api.respondWithSessions(w, r, principal.UserID)
+}
+
+// HandlerRevokeSession signs one of the current user's sessions out
+func (api *ApiConfig) HandlerRevokeSession(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	api.revokeSession(w, r, principal.UserID, "")
+}
+
+// HandlerAdminGetUserSessions lists the active sessions of a member of the admin's organization
+func (api *ApiConfig) HandlerAdminGetUserSessions(w http.ResponseWriter, r *http.Request) {
+	_, target, ok := api.orgMemberForAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	api.respondWithSessions(w, r, target.ID)
+}
+
+// HandlerAdminRevokeUserSession signs out a session of a member of the admin's organization
+func (api *ApiConfig) HandlerAdminRevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	admin, target, ok := api.orgMemberForAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	api.revokeSession(w, r, target.ID, "revoked by "+admin.Username)
+}
+
+func (api *ApiConfig) respondWithSessions(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	dbSessions, err := api.Queries.ListActiveUserSessions(r.Context(), userID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get sessions")
+		return
+	}
+
+	sessions := models.DatabaseSessionsToSessions(dbSessions)
+	if principal, err := auth.PrincipalFromContext(r.Context()); err == nil && principal.SessionID != uuid.Nil {
+		for i := range sessions {
+			sessions[i].Current = sessions[i].ID == principal.SessionID
+		}
+	}
+
+	RespondWithJSON(w, http.StatusOK, sessions)
+}
+
+// revokeSession revokes the session named in the URL if it belongs to userID and logs the sign-out
+func (api *ApiConfig) revokeSession(w http.ResponseWriter, r *http.Request, userID uuid.UUID, detail string) {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if _, err := api.Queries.RevokeSession(r.Context(), database.RevokeSessionParams{
+		ID:     sessionID,
+		UserID: userID,
+	}); err != nil {
+		RespondWithError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	attempt := loginAttempt{ip: api.clientIP(r), userID: uuid.NullUUID{UUID: userID, Valid: true}}
+	if detail == "" {
+		detail = "session " + sessionID.String()
+	} else {
+		detail = "session " + sessionID.String() + " " + detail
+	}
+	api.logAuthEvent(r.Context(), authEventSessionRevoked, attempt, detail)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// orgMemberForAdmin returns the admin making the request and loads the user named by the userId URL parameter,
+// responding with an error unless the user belongs to the organization the admin is acting in and holds no
+// permission, in any of their organizations, that the admin lacks
+func (api *ApiConfig) orgMemberForAdmin(w http.ResponseWriter, r *http.Request) (*auth.Principal, database.GetUserByIDRow, bool) {
+	var target database.GetUserByIDRow
+
+	targetID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return nil, target, false
+	}
+
+	admin, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return nil, target, false
+	}
+
+	target, err = api.Queries.GetUserByID(r.Context(), targetID)
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, "User not found")
+		return nil, target, false
+	}
+
+	// Admins can only manage members of the organization they are acting in
+	if !admin.OrganizationID.Valid {
+		RespondWithError(w, http.StatusForbidden, "Access denied to this user")
+		return nil, target, false
+	}
+	_, err = api.Queries.GetOrganizationMembership(r.Context(), database.GetOrganizationMembershipParams{
+		OrganizationID: admin.OrganizationID.UUID,
+		UserID:         target.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusForbidden, "Access denied to this user")
+		return nil, target, false
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get organization member")
+		return nil, target, false
+	}
+
+	// Sessions and credentials span every organization the target belongs to, so the admin
+	// must hold whatever the target holds in any of them
+	if target.ID != admin.UserID {
+		targetPerms, err := api.memberPermissionsEverywhere(r, target.ID)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Failed to look up role")
+			return nil, target, false
+		}
+		if !authz.Grants(api.callerPermissions(r, admin), targetPerms...) {
+			RespondWithError(w, http.StatusForbidden, "You cannot manage a user with permissions you do not have")
+			return nil, target, false
+		}
+	}
+
+	return admin, target, true
+}
+
+// memberPermissionsEverywhere returns the union of what the user's roles grant across all their organizations
+func (api *ApiConfig) memberPermissionsEverywhere(r *http.Request, userID uuid.UUID) ([]string, error) {
+	memberships, err := api.Queries.ListUserMemberships(r.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var permissions []string
+	for _, m := range memberships {
+		org := uuid.NullUUID{UUID: m.OrganizationMember.OrganizationID, Valid: true}
+		granted, err := api.Permissions.Permissions(r.Context(), org, m.OrganizationMember.Role)
+		if errors.Is(err, authz.ErrUnknownRole) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, granted...)
+	}
+	return permissions, nil
+}
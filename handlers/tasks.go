@@ -107,7 +107,7 @@ func parseTaskID(taskIDStr string) (uuid.UUID, error) {
 
 // checkTaskOwnership verifies task ownership without additional DB query
 func checkTaskOwnership(task database.Task, userID uuid.UUID) error {
-	if task.UserID != userID {
+	if !task.UserID.Valid || task.UserID.UUID != userID {
 		return &ValidationError{Message: errAccessDenied}
 	}
 	return nil
@@ -157,7 +157,7 @@ func decodeAndValidateTaskRequest(r *http.Request, dst interface{}) error {
 // HandlerCreateTask creates a new task
 func (api *ApiConfig) HandlerCreateTask(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errUserNotFound)
 		return
@@ -169,16 +169,32 @@ func (api *ApiConfig) HandlerCreateTask(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	task, err := api.Queries.CreateTask(r.Context(), database.CreateTaskParams{
-		ID:          uuid.New(),
-		Title:       params.Title,
-		Description: params.Description,
-		UserID:      userID,
+	var task database.Task
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		task, err = q.CreateTask(r.Context(), database.CreateTaskParams{
+			ID:          uuid.New(),
+			Title:       params.Title,
+			Description: params.Description,
+			UserID:      uuid.NullUUID{UUID: principal.UserID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: principal.OrganizationID,
+			Action:         auditTaskCreated,
+			TargetType:     auditTargetTask,
+			TargetID:       task.ID,
+			After:          models.DatabaseTaskToTask(task),
+		})
 	})
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errCreateTaskFailed)
 		return
 	}
+	api.Metrics.TasksCreated.Inc()
 
 	RespondWithJSON(w, http.StatusCreated, models.DatabaseTaskToTask(task))
 }
@@ -186,13 +202,13 @@ func (api *ApiConfig) HandlerCreateTask(w http.ResponseWriter, r *http.Request)
 // HandlerGetTasks gets all tasks for the authenticated user
 func (api *ApiConfig) HandlerGetTasks(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errUserNotFound)
 		return
 	}
 
-	tasks, err := api.Queries.GetTasksByUserId(r.Context(), userID)
+	tasks, err := api.Queries.GetTasksByUserId(r.Context(), uuid.NullUUID{UUID: principal.UserID, Valid: true})
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errSearchTasksFailed)
 		return
@@ -210,7 +226,7 @@ func (api *ApiConfig) HandlerGetTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user ID from context (set by auth middleware)
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errUserNotFound)
 		return
@@ -223,7 +239,7 @@ func (api *ApiConfig) HandlerGetTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify task ownership
-	if err := checkTaskOwnership(task, userID); err != nil {
+	if err := checkTaskOwnership(task, principal.UserID); err != nil {
 		RespondWithError(w, http.StatusForbidden, err.Error())
 		return
 	}
@@ -240,7 +256,7 @@ func (api *ApiConfig) HandlerUpdateTask(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Get user ID from context (set by auth middleware)
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errUserNotFound)
 		return
@@ -254,7 +270,7 @@ func (api *ApiConfig) HandlerUpdateTask(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Verify task ownership
-	if err := checkTaskOwnership(task, userID); err != nil {
+	if err := checkTaskOwnership(task, principal.UserID); err != nil {
 		RespondWithError(w, http.StatusForbidden, err.Error())
 		return
 	}
@@ -265,30 +281,48 @@ func (api *ApiConfig) HandlerUpdateTask(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Update task title and description
-	updatedTask, err := api.Queries.UpdateTaskPartial(r.Context(), database.UpdateTaskPartialParams{
-		Column1: params.Title,
-		Column2: params.Description,
-		ID:      taskID,
+	var updatedTask database.Task
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		// Update task title and description
+		var err error
+		updatedTask, err = q.UpdateTaskPartial(r.Context(), database.UpdateTaskPartialParams{
+			Column1: params.Title,
+			Column2: params.Description,
+			ID:      taskID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// If completion status is being updated, handle it separately
+		if params.IsCompleted != nil {
+			if *params.IsCompleted && !task.IsCompleted {
+				// Mark as completed
+				updatedTask, err = q.CompleteTask(r.Context(), taskID)
+			} else if !*params.IsCompleted && task.IsCompleted {
+				// Mark as incomplete
+				updatedTask, err = q.UndoCompleteTask(r.Context(), taskID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: principal.OrganizationID,
+			Action:         auditTaskUpdated,
+			TargetType:     auditTargetTask,
+			TargetID:       taskID,
+			Before:         models.DatabaseTaskToTask(task),
+			After:          models.DatabaseTaskToTask(updatedTask),
+		})
 	})
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errUpdateTaskFailed)
 		return
 	}
-
-	// If completion status is being updated, handle it separately
-	if params.IsCompleted != nil {
-		if *params.IsCompleted && !task.IsCompleted {
-			// Mark as completed
-			updatedTask, err = api.Queries.CompleteTask(r.Context(), taskID)
-		} else if !*params.IsCompleted && task.IsCompleted {
-			// Mark as incomplete
-			updatedTask, err = api.Queries.UndoCompleteTask(r.Context(), taskID)
-		}
-		if err != nil {
-			RespondWithError(w, http.StatusInternalServerError, errUpdateTaskFailed)
-			return
-		}
+	if updatedTask.IsCompleted && !task.IsCompleted {
+		api.Metrics.TasksCompleted.Inc()
 	}
 
 	RespondWithJSON(w, http.StatusOK, models.DatabaseTaskToTask(updatedTask))
@@ -303,7 +337,7 @@ func (api *ApiConfig) HandlerDeleteTask(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Get user ID from context (set by auth middleware)
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errUserNotFound)
 		return
@@ -317,13 +351,26 @@ func (api *ApiConfig) HandlerDeleteTask(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Verify task ownership
-	if err := checkTaskOwnership(task, userID); err != nil {
+	if err := checkTaskOwnership(task, principal.UserID); err != nil {
 		RespondWithError(w, http.StatusForbidden, err.Error())
 		return
 	}
 
 	// Soft delete the task
-	if _, err := api.Queries.SoftDeleteTask(r.Context(), taskID); err != nil {
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		if _, err := q.SoftDeleteTask(r.Context(), taskID); err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: principal.OrganizationID,
+			Action:         auditTaskDeleted,
+			TargetType:     auditTargetTask,
+			TargetID:       taskID,
+			Before:         models.DatabaseTaskToTask(task),
+		})
+	})
+	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errDeleteTaskFailed)
 		return
 	}
@@ -334,7 +381,7 @@ func (api *ApiConfig) HandlerDeleteTask(w http.ResponseWriter, r *http.Request)
 // HandlerSearchTasks searches tasks with filters
 func (api *ApiConfig) HandlerSearchTasks(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errUserNotFound)
 		return
@@ -344,7 +391,7 @@ func (api *ApiConfig) HandlerSearchTasks(w http.ResponseWriter, r *http.Request)
 	query := strings.TrimSpace(r.URL.Query().Get("query"))
 	limit := parseLimit(r.URL.Query().Get("limit"))
 
-	tasks, err := api.Queries.GetTasksByUserId(r.Context(), userID)
+	tasks, err := api.Queries.GetTasksByUserId(r.Context(), uuid.NullUUID{UUID: principal.UserID, Valid: true})
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errSearchTasksFailed)
 		return
@@ -370,7 +417,7 @@ func (api *ApiConfig) HandlerToggleTaskCompletion(w http.ResponseWriter, r *http
 	}
 
 	// Get user ID from context (set by auth middleware)
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errUserNotFound)
 		return
@@ -384,7 +431,7 @@ func (api *ApiConfig) HandlerToggleTaskCompletion(w http.ResponseWriter, r *http
 	}
 
 	// Verify task ownership
-	if err := checkTaskOwnership(task, userID); err != nil {
+	if err := checkTaskOwnership(task, principal.UserID); err != nil {
 		RespondWithError(w, http.StatusForbidden, err.Error())
 		return
 	}
@@ -395,25 +442,41 @@ func (api *ApiConfig) HandlerToggleTaskCompletion(w http.ResponseWriter, r *http
 		return
 	}
 
-	var updatedTask database.Task
-
-	// Toggle completion based on request
-	if params.IsCompleted && !task.IsCompleted {
-		// Mark as completed
-		updatedTask, err = api.Queries.CompleteTask(r.Context(), taskID)
-	} else if !params.IsCompleted && task.IsCompleted {
-		// Mark as incomplete
-		updatedTask, err = api.Queries.UndoCompleteTask(r.Context(), taskID)
-	} else {
-		// No change needed, return current state
+	// No change needed, return current state
+	if params.IsCompleted == task.IsCompleted {
 		RespondWithJSON(w, http.StatusOK, models.DatabaseTaskToTask(task))
 		return
 	}
 
+	var updatedTask database.Task
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		// Toggle completion based on request
+		var err error
+		if params.IsCompleted {
+			updatedTask, err = q.CompleteTask(r.Context(), taskID)
+		} else {
+			updatedTask, err = q.UndoCompleteTask(r.Context(), taskID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: principal.OrganizationID,
+			Action:         auditTaskUpdated,
+			TargetType:     auditTargetTask,
+			TargetID:       taskID,
+			Before:         models.DatabaseTaskToTask(task),
+			After:          models.DatabaseTaskToTask(updatedTask),
+		})
+	})
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, errUpdateTaskFailed)
 		return
 	}
+	if updatedTask.IsCompleted && !task.IsCompleted {
+		api.Metrics.TasksCompleted.Inc()
+	}
 
 	RespondWithJSON(w, http.StatusOK, models.DatabaseTaskToTask(updatedTask))
 }
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/models"
+)
+
+// authEventRefreshTokenReused is logged when a rotated refresh token is replayed and its session revoked
+const authEventRefreshTokenReused = "refresh_token_reused"
+
+// clientNameHeader lets clients name themselves in the session list
+const clientNameHeader = "X-Client-Name"
+
+// maxClientNameLength matches the sessions.client column
+const maxClientNameLength = 100
+
+// issueSession creates a new session for the user and returns a signed access token with its refresh token.
+// The client name, IP address and user agent of the request are recorded for the session list;
+// an empty client falls back to the X-Client-Name header. Every kind of login ends here, so successful
+// logins are counted here too.
+func (api *ApiConfig) issueSession(r *http.Request, userID uuid.UUID, client string) (models.TokenResponse, error) {
+	refreshToken, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	if client == "" {
+		client = strings.TrimSpace(r.Header.Get(clientNameHeader))
+	}
+	client = clientName(client)
+	ip := api.clientIP(r)
+	userAgent := strings.ToValidUTF8(r.UserAgent(), "")
+
+	session, err := api.Queries.CreateSession(r.Context(), database.CreateSessionParams{
+		ID:               uuid.New(),
+		UserID:           userID,
+		RefreshTokenHash: auth.HashToken(refreshToken),
+		ExpiresAt:        time.Now().UTC().Add(api.Config.RefreshTokenTTL),
+		Client:           sql.NullString{String: client, Valid: client != ""},
+		IpAddress:        sql.NullString{String: ip, Valid: ip != ""},
+		UserAgent:        sql.NullString{String: userAgent, Valid: userAgent != ""},
+	})
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	api.Metrics.Logins.Inc("success")
+
+	return api.newTokenResponse(session, refreshToken)
+}
+
+// clientName makes a client-supplied name safe to store, dropping invalid UTF-8 and cutting it
+// to the column length on a character boundary
+func clientName(name string) string {
+	name = strings.ToValidUTF8(name, "")
+	if utf8.RuneCountInString(name) <= maxClientNameLength {
+		return name
+	}
+	return string([]rune(name)[:maxClientNameLength])
+}
+
+// newTokenResponse signs an access token bound to the given session
+func (api *ApiConfig) newTokenResponse(session database.Session, refreshToken string) (models.TokenResponse, error) {
+	accessToken, expiresAt, err := auth.IssueAccessToken([]byte(api.Config.JWTSecret), session.UserID, session.ID, api.Config.AccessTokenTTL)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	return models.TokenResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    auth.TokenTypeBearer,
+		ExpiresIn:    int(api.Config.AccessTokenTTL.Seconds()),
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// HandlerRefreshToken exchanges a refresh token for a new access token, rotating the refresh token
+func (api *ApiConfig) HandlerRefreshToken(w http.ResponseWriter, r *http.Request) {
+	var params models.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	params.RefreshToken = strings.TrimSpace(params.RefreshToken)
+	if params.RefreshToken == "" {
+		RespondWithError(w, http.StatusBadRequest, "Refresh token is required")
+		return
+	}
+
+	oldHash := auth.HashToken(params.RefreshToken)
+	session, err := api.Queries.GetSessionByRefreshTokenHash(r.Context(), oldHash)
+	if err != nil {
+		// A token that was already rotated away is being replayed; either copy may be stolen, so end the session
+		if retired, err := api.Queries.GetSessionByRetiredRefreshTokenHash(r.Context(), oldHash); err == nil {
+			api.revokeReusedSession(r, retired)
+		}
+		RespondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+
+	// Rotate the refresh token so each one can only be used once
+	newRefreshToken, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to refresh token")
+		return
+	}
+
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		session, err = q.RotateSessionRefreshToken(r.Context(), database.RotateSessionRefreshTokenParams{
+			ID:        session.ID,
+			OldHash:   oldHash,
+			NewHash:   auth.HashToken(newRefreshToken),
+			ExpiresAt: time.Now().UTC().Add(api.Config.RefreshTokenTTL),
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.RetireRefreshToken(r.Context(), database.RetireRefreshTokenParams{
+			TokenHash: oldHash,
+			SessionID: session.ID,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Another request rotated the same token first
+		api.revokeReusedSession(r, session)
+		RespondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to refresh token")
+		return
+	}
+
+	response, err := api.newTokenResponse(session, newRefreshToken)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to refresh token")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, response)
+}
+
+// revokeReusedSession ends a session whose refresh token was presented more than once
+func (api *ApiConfig) revokeReusedSession(r *http.Request, session database.Session) {
+	if _, err := api.Queries.RevokeSession(r.Context(), database.RevokeSessionParams{
+		ID:     session.ID,
+		UserID: session.UserID,
+	}); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.ErrorContext(r.Context(), "Failed to revoke session after refresh token reuse", "session_id", session.ID, "error", err)
+		}
+		return
+	}
+
+	attempt := loginAttempt{ip: api.clientIP(r), userID: uuid.NullUUID{UUID: session.UserID, Valid: true}}
+	api.logAuthEvent(r.Context(), authEventRefreshTokenReused, attempt, "session "+session.ID.String())
+}
+
+// HandlerLogout revokes the session behind the current access token
+func (api *ApiConfig) HandlerLogout(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	if principal.SessionID == uuid.Nil {
+		RespondWithError(w, http.StatusBadRequest, "Logout requires a bearer token session")
+		return
+	}
+
+	if _, err := api.Queries.RevokeSession(r.Context(), database.RevokeSessionParams{
+		ID:     principal.SessionID,
+		UserID: principal.UserID,
+	}); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerLogoutAll revokes every active session of the current user
+func (api *ApiConfig) HandlerLogoutAll(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	if err := api.Queries.RevokeUserSessions(r.Context(), principal.UserID); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestClientNameCutsOnCharacterBoundary checks that long multibyte names stay valid UTF-8
+func TestClientNameCutsOnCharacterBoundary(t *testing.T) {
+	name := clientName(strings.Repeat("é", maxClientNameLength+10))
+
+	if !utf8.ValidString(name) {
+		t.Fatalf("expected valid UTF-8, got %q", name)
+	}
+	if n := utf8.RuneCountInString(name); n != maxClientNameLength {
+		t.Errorf("expected %d characters, got %d", maxClientNameLength, n)
+	}
+}
+
+// TestClientNameDropsInvalidBytes checks that invalid UTF-8 from the header is removed
+func TestClientNameDropsInvalidBytes(t *testing.T) {
+	if name := clientName("cli\xff\xfetool"); name != "clitool" {
+		t.Errorf("expected invalid bytes to be dropped, got %q", name)
+	}
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/models"
+)
+
+const (
+	// mfaTokenTTL bounds how long the second login step may take after the password check
+	mfaTokenTTL = 5 * time.Minute
+
+	recoveryCodeCount = 10
+)
+
+// Two-factor errors
+var (
+	// errTOTPAlreadyEnabled is returned when verifying an enrollment that was completed concurrently
+	errTOTPAlreadyEnabled = errors.New("two-factor authentication already enabled")
+
+	// errMFATokenUsed is returned when an MFA token already completed a login
+	errMFATokenUsed = errors.New("MFA token already used")
+
+	// errInvalidTwoFactorCode is returned when a login's TOTP or recovery code does not check out
+	errInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+// HandlerGetTwoFactorStatus reports whether two-factor authentication is enabled for the current user
+func (api *ApiConfig) HandlerGetTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	status := models.TwoFactorStatus{}
+	if totp, err := api.Queries.GetUserTOTP(r.Context(), principal.UserID); err == nil && totp.EnabledAt.Valid {
+		status.Enabled = true
+		status.RecoveryCodesRemaining, err = api.Queries.CountUnusedRecoveryCodes(r.Context(), principal.UserID)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Failed to get two-factor status")
+			return
+		}
+	}
+
+	RespondWithJSON(w, http.StatusOK, status)
+}
+
+// HandlerEnrollTwoFactor starts a TOTP enrollment and returns the secret to add to an authenticator app
+func (api *ApiConfig) HandlerEnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to generate two-factor secret")
+		return
+	}
+
+	// Starting over replaces a pending secret but never an enabled one
+	if _, err := api.Queries.UpsertPendingUserTOTP(r.Context(), database.UpsertPendingUserTOTPParams{
+		UserID: principal.UserID,
+		Secret: secret,
+	}); err != nil {
+		RespondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(auth.TokenIssuer, principal.Username, secret),
+	})
+}
+
+// HandlerVerifyTwoFactor confirms a pending enrollment with a code from the authenticator app
+// and returns the initial recovery codes
+func (api *ApiConfig) HandlerVerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	var params models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	totp, err := api.Queries.GetUserTOTP(r.Context(), principal.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Two-factor enrollment has not been started")
+		return
+	}
+
+	if totp.EnabledAt.Valid {
+		RespondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled")
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(totp.Secret, params.Code, time.Now())
+	if !ok {
+		RespondWithError(w, http.StatusBadRequest, "Invalid two-factor code")
+		return
+	}
+
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to generate recovery codes")
+		return
+	}
+
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		if _, err := q.EnableUserTOTP(r.Context(), database.EnableUserTOTPParams{
+			UserID:       principal.UserID,
+			LastUsedStep: step,
+		}); err != nil {
+			return errTOTPAlreadyEnabled
+		}
+		return replaceRecoveryCodes(r.Context(), q, principal.UserID, codes)
+	})
+	if errors.Is(err, errTOTPAlreadyEnabled) {
+		RespondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to enable two-factor authentication")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// HandlerRegenerateRecoveryCodes replaces all recovery codes after checking a current TOTP code
+func (api *ApiConfig) HandlerRegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	var params models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if !api.checkTwoFactorCode(w, r, principal, params.Code) {
+		return
+	}
+
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to generate recovery codes")
+		return
+	}
+
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		return replaceRecoveryCodes(r.Context(), q, principal.UserID, codes)
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to generate recovery codes")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// HandlerDisableTwoFactor turns off two-factor authentication; both the password and a current code are required
+func (api *ApiConfig) HandlerDisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
+		return
+	}
+
+	var params models.DisableTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if params.Password == "" || params.Code == "" {
+		RespondWithError(w, http.StatusBadRequest, "Password and two-factor code are required")
+		return
+	}
+
+	user, err := api.Queries.GetUserByID(r.Context(), principal.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get user details")
+		return
+	}
+
+	valid, err := api.verifyPassword(r.Context(), params.Password, user.PasswordHash)
+	if err != nil || !valid {
+		RespondWithError(w, http.StatusForbidden, "Password is incorrect")
+		return
+	}
+
+	if !api.checkTwoFactorCode(w, r, principal, params.Code) {
+		return
+	}
+
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		if err := q.DeleteUserRecoveryCodes(r.Context(), principal.UserID); err != nil {
+			return err
+		}
+		return q.DeleteUserTOTP(r.Context(), principal.UserID)
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to disable two-factor authentication")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerLoginTwoFactor completes a login that was challenged for a second factor
+func (api *ApiConfig) HandlerLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var params models.LoginTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	params.MFAToken = strings.TrimSpace(params.MFAToken)
+	if params.MFAToken == "" || (params.Code == "" && params.RecoveryCode == "") {
+		RespondWithError(w, http.StatusBadRequest, "MFA token and a code or recovery code are required")
+		return
+	}
+
+	claims, err := auth.ParseToken([]byte(api.Config.JWTSecret), params.MFAToken, auth.TokenTypeMFA)
+	if err != nil {
+		RespondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token")
+		return
+	}
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		RespondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token")
+		return
+	}
+
+	user, err := api.Queries.GetUserByID(r.Context(), claims.Subject)
+	if err != nil {
+		RespondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token")
+		return
+	}
+
+	// Accounts disabled since the password step cannot finish signing in
+	if user.AccountType != auth.AccountTypeHuman || user.DisabledAt.Valid {
+		RespondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token")
+		return
+	}
+
+	// Wrong codes count against the same lockout as wrong passwords
+	attempt := api.newLoginAttempt(r, user.Username)
+	attempt.userID = uuid.NullUUID{UUID: user.ID, Valid: true}
+	if remaining := api.checkLoginLocked(r.Context(), attempt); remaining > 0 {
+		respondLoginLocked(w, remaining)
+		return
+	}
+
+	if err := api.Queries.DeleteExpiredMFATokens(r.Context()); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete expired MFA tokens", "error", err)
+	}
+
+	// Each challenge completes at most one login. The token is claimed before a code is spent on it,
+	// and a wrong code rolls the claim back so the user can try again.
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		if _, err := q.UseMFAToken(r.Context(), database.UseMFATokenParams{
+			Jti:       jti,
+			UserID:    user.ID,
+			ExpiresAt: time.Unix(claims.ExpiresAt, 0).UTC(),
+		}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errMFATokenUsed
+			}
+			return err
+		}
+
+		if params.Code != "" {
+			if !useTOTPCode(r.Context(), q, user.ID, params.Code) {
+				return errInvalidTwoFactorCode
+			}
+			return nil
+		}
+		_, err := q.UseRecoveryCode(r.Context(), database.UseRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(params.RecoveryCode)),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errInvalidTwoFactorCode
+		}
+		return err
+	})
+	if errors.Is(err, errMFATokenUsed) {
+		RespondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token")
+		return
+	}
+	if errors.Is(err, errInvalidTwoFactorCode) {
+		api.recordLoginFailure(r.Context(), attempt)
+		RespondWithError(w, http.StatusUnauthorized, "Invalid two-factor code")
+		return
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Authentication failed")
+		return
+	}
+
+	tokens, err := api.issueSession(r, user.ID, "")
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Authentication failed")
+		return
+	}
+	api.clearLoginFailures(r.Context(), attempt)
+
+	RespondWithJSON(w, http.StatusOK, models.LoginResponse{
+		User:          models.DatabaseUserRowToUser(user),
+		TokenResponse: tokens,
+	})
+}
+
+// checkTwoFactorCode verifies a TOTP code from a signed-in user, counting wrong codes against the login lockout
+func (api *ApiConfig) checkTwoFactorCode(w http.ResponseWriter, r *http.Request, principal *auth.Principal, code string) bool {
+	attempt := api.newLoginAttempt(r, principal.Username)
+	attempt.userID = uuid.NullUUID{UUID: principal.UserID, Valid: true}
+	if remaining := api.checkLoginLocked(r.Context(), attempt); remaining > 0 {
+		respondLoginLocked(w, remaining)
+		return false
+	}
+
+	if !useTOTPCode(r.Context(), api.Queries, principal.UserID, code) {
+		api.recordLoginFailure(r.Context(), attempt)
+		RespondWithError(w, http.StatusForbidden, "Invalid two-factor code")
+		return false
+	}
+
+	return true
+}
+
+// useTOTPCode checks a code against the user's enabled TOTP secret and records its time step
+// so the same code cannot be replayed
+func useTOTPCode(ctx context.Context, q *database.Queries, userID uuid.UUID, code string) bool {
+	totp, err := q.GetUserTOTP(ctx, userID)
+	if err != nil || !totp.EnabledAt.Valid {
+		return false
+	}
+
+	step, ok := auth.ValidateTOTP(totp.Secret, code, time.Now())
+	if !ok {
+		return false
+	}
+
+	_, err = q.UseTOTPStep(ctx, database.UseTOTPStepParams{
+		UserID:       userID,
+		LastUsedStep: step,
+	})
+	return err == nil
+}
+
+// replaceRecoveryCodes discards the user's recovery codes and stores hashes of the given ones
+func replaceRecoveryCodes(ctx context.Context, q *database.Queries, userID uuid.UUID, codes []string) error {
+	if err := q.DeleteUserRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+
+	for _, code := range codes {
+		if err := q.CreateRecoveryCode(ctx, database.CreateRecoveryCodeParams{
+			ID:       uuid.New(),
+			UserID:   userID,
+			CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(code)),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -22,7 +22,6 @@ func (api *ApiConfig) HandlerLogin(w http.ResponseWriter, r *http.Request) {
 
 	// Trim whitespace
 	params.Username = strings.TrimSpace(params.Username)
-	params.Password = strings.TrimSpace(params.Password)
 
 	// Validate input
 	if params.Username == "" || params.Password == "" {
@@ -30,26 +29,60 @@ func (api *ApiConfig) HandlerLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Hash the password for comparison
-	passwordHash, err := auth.HashPassword(params.Password, nil)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Authentication failed")
+	// Refuse attempts from locked-out usernames or clients before checking the password
+	attempt := api.newLoginAttempt(r, params.Username)
+	if remaining := api.checkLoginLocked(r.Context(), attempt); remaining > 0 {
+		respondLoginLocked(w, remaining)
 		return
 	}
 
-	// Get user by username and password
-	user, err := api.Queries.GetUserByUsernameAndPassword(r.Context(), database.GetUserByUsernameAndPasswordParams{
-		Username:     params.Username,
-		PasswordHash: passwordHash,
-	})
-	if err != nil {
+	// Get user by username and verify the password against the stored hash. Unknown usernames, service
+	// accounts and disabled accounts are checked against a dummy hash so every failure takes as long.
+	user, err := api.Queries.GetUserByUsername(r.Context(), params.Username)
+	if err == nil {
+		attempt.userID = uuid.NullUUID{UUID: user.ID, Valid: true}
+	}
+	canSignIn := err == nil && user.AccountType == auth.AccountTypeHuman && !user.DisabledAt.Valid
+	passwordHash := api.dummyPasswordHash
+	if canSignIn {
+		passwordHash = user.PasswordHash
+	}
+
+	valid, err := api.verifyPassword(r.Context(), params.Password, passwordHash)
+	if !canSignIn || err != nil || !valid {
+		api.recordLoginFailure(r.Context(), attempt)
 		RespondWithError(w, http.StatusUnauthorized, "Invalid username or password")
 		return
 	}
+	api.rehashPasswordIfNeeded(r.Context(), user.ID, params.Password, user.PasswordHash)
+
+	// Accounts with two-factor enabled get a challenge instead of a session
+	if totp, err := api.Queries.GetUserTOTP(r.Context(), user.ID); err == nil && totp.EnabledAt.Valid {
+		mfaToken, err := auth.IssueMFAToken([]byte(api.Config.JWTSecret), user.ID, mfaTokenTTL)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Authentication failed")
+			return
+		}
+
+		RespondWithJSON(w, http.StatusOK, models.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			ExpiresIn:   int(mfaTokenTTL.Seconds()),
+		})
+		return
+	}
+
+	// Issue a short-lived access token and a refresh token
+	tokens, err := api.issueSession(r, user.ID, "")
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Authentication failed")
+		return
+	}
+	api.clearLoginFailures(r.Context(), attempt)
 
-	// Return user details and API key
 	response := models.LoginResponse{
-		User: models.DatabaseUserRowToUser(user),
+		User:          models.DatabaseUserRowToUser(user),
+		TokenResponse: tokens,
 	}
 
 	RespondWithJSON(w, http.StatusOK, response)
@@ -71,14 +104,13 @@ func (api *ApiConfig) HandlerCreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Validate password strength
-	if err := auth.ValidatePasswordStrength(params.Password); err != nil {
-		RespondWithError(w, http.StatusBadRequest, err.Error())
+	// New users belong to no organization yet, so the global password policy applies
+	if !api.checkPasswordPolicy(w, r, uuid.NullUUID{}, params.Password, params.Username) {
 		return
 	}
 
 	// Hash password
-	passwordHash, err := auth.HashPassword(params.Password, nil)
+	passwordHash, err := api.hashPassword(r.Context(), params.Password)
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "Failed to process password")
 		return
@@ -124,14 +156,7 @@ func (api *ApiConfig) HandlerCreateUser(w http.ResponseWriter, r *http.Request)
 		createParams.Gender.String = *params.Gender
 	}
 
-	// Set default role as 'user'
-	createParams.Column6 = "user"
-
-	// Handle organization ID
-	if params.OrganizationID != nil {
-		createParams.Column7 = *params.OrganizationID
-	}
-
+	// Organizations are joined afterwards by accepting an invitation
 	user, err := api.Queries.CreateUserWithPassword(r.Context(), createParams)
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "Failed to create user: "+err.Error())
@@ -143,14 +168,14 @@ func (api *ApiConfig) HandlerCreateUser(w http.ResponseWriter, r *http.Request)
 
 func (api *ApiConfig) HandlerGetUser(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
 		return
 	}
 
 	// Get user by ID instead of API key since we already have it from middleware
-	user, err := api.Queries.GetUserByID(r.Context(), userID)
+	user, err := api.Queries.GetUserByID(r.Context(), principal.UserID)
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "Failed to get user: "+err.Error())
 		return
@@ -162,7 +187,7 @@ func (api *ApiConfig) HandlerGetUser(w http.ResponseWriter, r *http.Request) {
 // HandlerUpdateUser updates user information
 func (api *ApiConfig) HandlerUpdateUser(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	principal, err := auth.PrincipalFromContext(r.Context())
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "User not found in context")
 		return
@@ -207,7 +232,7 @@ func (api *ApiConfig) HandlerUpdateUser(w http.ResponseWriter, r *http.Request)
 
 	// Create update parameters
 	updateParams := database.UpdateUserParams{
-		ID: userID,
+		ID: principal.UserID,
 	}
 
 	if params.Username != nil {
@@ -224,8 +249,30 @@ func (api *ApiConfig) HandlerUpdateUser(w http.ResponseWriter, r *http.Request)
 		updateParams.Gender.String = *params.Gender
 	}
 
+	before, err := api.Queries.GetUserByID(r.Context(), principal.UserID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Failed to get user details")
+		return
+	}
+
 	// Update user
-	user, err := api.Queries.UpdateUser(r.Context(), updateParams)
+	var user database.User
+	err = api.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		user, err = q.UpdateUser(r.Context(), updateParams)
+		if err != nil {
+			return err
+		}
+
+		return api.audit(r, q, auditChange{
+			OrganizationID: principal.OrganizationID,
+			Action:         auditUserUpdated,
+			TargetType:     auditTargetUser,
+			TargetID:       user.ID,
+			Before:         models.DatabaseUserRowToUser(before),
+			After:          models.DatabaseUserToUser(user),
+		})
+	})
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "Failed to update user: "+err.Error())
 		return
@@ -76,6 +76,15 @@ func parseLimit(limitStr string) int {
 	return limit
 }
 
+// parseOffset parses a pagination offset, treating anything invalid as the first page
+func parseOffset(offsetStr string) int {
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		return 0
+	}
+	return offset
+}
+
 func (e *ValidationError) Error() string {
 	return e.Message
 }
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Authentication errors returned by authenticators
+var (
+	// ErrNoCredentials means the request carries no credential the authenticator understands,
+	// so a chain moves on to the next authenticator
+	ErrNoCredentials = errors.New("no credentials")
+
+	ErrUnsupportedScheme  = errors.New("unsupported authorization scheme, expected: ApiKey, Bearer or Basic")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidCertificate = errors.New("client certificate is not mapped to an account")
+	ErrAccountLocked      = errors.New("too many failed attempts, try again later")
+
+	// ErrTwoFactorRequired is returned by basic authentication, since a password alone must not be enough
+	ErrTwoFactorRequired = errors.New("two-factor authentication is enabled for this account, log in or use an API key instead")
+)
+
+// Authenticator identifies the caller of a request from one kind of credential
+type Authenticator interface {
+	// Authenticate returns ErrNoCredentials when the request has no credential of its kind
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface
+type AuthenticatorFunc func(r *http.Request) (*Principal, error)
+
+// Authenticate calls f(r)
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) {
+	return f(r)
+}
+
+// Chain tries authenticators in order; the first one that finds its credential decides the outcome
+type Chain []Authenticator
+
+// Authenticate runs the chain, failing if no authenticator recognizes the request's credentials
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+
+	if r.Header.Get("Authorization") == "" {
+		return nil, ErrMissingAuthHeader
+	}
+	return nil, ErrUnsupportedScheme
+}
+
+// IsAuthenticationError reports whether err means the caller's credentials were missing or rejected,
+// as opposed to a failure while checking them
+func IsAuthenticationError(err error) bool {
+	for _, target := range []error{
+		ErrNoCredentials,
+		ErrMissingAuthHeader,
+		ErrUnsupportedScheme,
+		ErrInvalidAPIKeyFormat,
+		ErrInvalidAPIKey,
+		ErrInvalidBearerFormat,
+		ErrInvalidToken,
+		ErrInvalidCredentials,
+		ErrInvalidCertificate,
+		ErrAccountLocked,
+		ErrTwoFactorRequired,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// schemeAuthenticator accepts any credential of one scheme as the given principal
+func schemeAuthenticator(scheme string, principal *Principal, err error) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		if !HasAuthScheme(r.Header, scheme) {
+			return nil, ErrNoCredentials
+		}
+		return principal, err
+	})
+}
+
+func TestChainUsesFirstMatchingAuthenticator(t *testing.T) {
+	keyUser := &Principal{UserID: uuid.New(), Method: MethodAPIKey}
+	tokenUser := &Principal{UserID: uuid.New(), Method: MethodBearer}
+
+	chain := Chain{
+		schemeAuthenticator("ApiKey", keyUser, nil),
+		schemeAuthenticator("Bearer", tokenUser, nil),
+		schemeAuthenticator("Basic", nil, ErrInvalidCredentials),
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		want   *Principal
+		err    error
+	}{
+		{"api key", "ApiKey abc", keyUser, nil},
+		{"bearer", "bearer a.b.c", tokenUser, nil},
+		{"rejected credential stops the chain", "Basic dXNlcjpwYXNz", nil, ErrInvalidCredentials},
+		{"missing header", "", nil, ErrMissingAuthHeader},
+		{"unknown scheme", "Digest abc", nil, ErrUnsupportedScheme},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+
+			got, err := chain.Authenticate(r)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("principal = %+v, want %+v", got, tt.want)
+			}
+			if err != nil && !IsAuthenticationError(err) {
+				t.Errorf("expected %v to be an authentication error", err)
+			}
+		})
+	}
+}
+
+func TestGetAPIKeyIgnoresSchemeCase(t *testing.T) {
+	for _, scheme := range []string{"ApiKey", "APIKEY", "apikey"} {
+		h := http.Header{}
+		h.Set("Authorization", scheme+" abc123")
+
+		key, err := GetAPIKey(h)
+		if err != nil || key != "abc123" {
+			t.Errorf("GetAPIKey with scheme %q = %q, %v", scheme, key, err)
+		}
+	}
+
+	h := http.Header{}
+	h.Set("Authorization", "ApiKey not-hex!")
+	if _, err := GetAPIKey(h); err != ErrInvalidAPIKeyFormat {
+		t.Errorf("expected ErrInvalidAPIKeyFormat, got %v", err)
+	}
+}
+
+func TestPrincipalScopes(t *testing.T) {
+	session := &Principal{Method: MethodBearer}
+	if !session.HasScope(ScopeOrgsAdmin) {
+		t.Error("expected an unscoped principal to have every scope")
+	}
+
+	key := &Principal{Method: MethodAPIKey, Scopes: []string{ScopeTasksWrite}}
+	if !key.HasScope(ScopeTasksRead) || key.HasScope(ScopeUserRead) {
+		t.Errorf("unexpected scope checks for %v", key.Scopes)
+	}
+}
+
+func TestPrincipalContext(t *testing.T) {
+	if _, err := PrincipalFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()); err != ErrUnauthorized {
+		t.Errorf("expected ErrUnauthorized without a principal, got %v", err)
+	}
+
+	principal := &Principal{UserID: uuid.New()}
+	ctx := WithPrincipal(httptest.NewRequest(http.MethodGet, "/", nil).Context(), principal)
+	if got, err := PrincipalFromContext(ctx); err != nil || got != principal {
+		t.Errorf("PrincipalFromContext = %v, %v", got, err)
+	}
+}
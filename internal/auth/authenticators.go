@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// apiKeyTouchInterval limits how often last_used_at is written for a busy key
+const apiKeyTouchInterval = time.Minute
+
+// sessionTouchInterval limits how often last_seen_at is written for an active session
+const sessionTouchInterval = time.Minute
+
+// APIKeyAuthenticator accepts "Authorization: ApiKey <key>" with a key that is active and belongs to an enabled account
+type APIKeyAuthenticator struct {
+	Queries *database.Queries
+}
+
+// Authenticate implements Authenticator
+func (a APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if !HasAuthScheme(r.Header, "ApiKey") {
+		return nil, ErrNoCredentials
+	}
+
+	apiKey, err := GetAPIKey(r.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys are stored hashed, so look them up by their digest; keys of disabled accounts are not found
+	row, err := a.Queries.GetActiveAPIKey(r.Context(), HashToken(apiKey))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	key := row.ApiKey
+
+	if !key.LastUsedAt.Valid || time.Since(key.LastUsedAt.Time) >= apiKeyTouchInterval {
+		if err := a.Queries.TouchAPIKeyLastUsed(r.Context(), key.ID); err != nil {
+			slog.WarnContext(r.Context(), "Failed to record API key usage", "error", err)
+		}
+	}
+
+	return &Principal{
+		UserID:                key.UserID,
+		Username:              row.Username,
+		AccountType:           row.AccountType,
+		DefaultOrganizationID: row.OrganizationID,
+		Scopes:                key.Scopes,
+		Method:                MethodAPIKey,
+		APIKeyID:              key.ID,
+	}, nil
+}
+
+// BearerAuthenticator accepts signed access tokens whose session is still active
+type BearerAuthenticator struct {
+	Queries *database.Queries
+	Secret  []byte
+}
+
+// Authenticate implements Authenticator
+func (a BearerAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if !HasAuthScheme(r.Header, TokenTypeBearer) {
+		return nil, ErrNoCredentials
+	}
+
+	token, err := GetBearerToken(r.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := ParseAccessToken(a.Secret, token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Reject tokens whose session was revoked by logout or whose account was disabled
+	row, err := a.Queries.GetActiveSessionByID(r.Context(), claims.SessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	session := row.Session
+	if session.UserID != claims.Subject {
+		return nil, ErrInvalidToken
+	}
+
+	if time.Since(session.LastSeenAt) >= sessionTouchInterval {
+		if err := a.Queries.TouchSessionLastSeen(r.Context(), session.ID); err != nil {
+			slog.WarnContext(r.Context(), "Failed to record session activity", "error", err)
+		}
+	}
+
+	return &Principal{
+		UserID:                session.UserID,
+		Username:              row.Username,
+		AccountType:           row.AccountType,
+		DefaultOrganizationID: row.OrganizationID,
+		Method:                MethodBearer,
+		SessionID:             session.ID,
+	}, nil
+}
+
+// LoginGuard throttles password guessing; basic authentication shares the login lockout through it
+type LoginGuard interface {
+	Locked(r *http.Request, username string) bool
+	RecordFailure(r *http.Request, username string, userID uuid.NullUUID)
+	RecordSuccess(r *http.Request, username string)
+}
+
+// BasicAuthenticator accepts "Authorization: Basic" with a username and password.
+// Service accounts, disabled accounts and accounts with two-factor enabled are refused.
+type BasicAuthenticator struct {
+	Queries   *database.Queries
+	Passwords *PasswordConfig
+	Guard     LoginGuard
+
+	// DummyHash is verified against when the username names no usable account, so failures
+	// for unknown and real accounts take as long
+	DummyHash string
+}
+
+// Authenticate implements Authenticator
+func (a BasicAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if !HasAuthScheme(r.Header, "Basic") {
+		return nil, ErrNoCredentials
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok || username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	if a.Guard != nil && a.Guard.Locked(r, username) {
+		return nil, ErrAccountLocked
+	}
+
+	user, err := a.Queries.GetUserByUsername(r.Context(), username)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	userID := uuid.NullUUID{UUID: user.ID, Valid: err == nil}
+	canSignIn := err == nil && user.AccountType == AccountTypeHuman && !user.DisabledAt.Valid
+	passwordHash := a.DummyHash
+	if canSignIn {
+		passwordHash = user.PasswordHash
+	}
+
+	valid, err := VerifyPassword(password, passwordHash, a.Passwords)
+	if !canSignIn || err != nil || !valid {
+		a.recordFailure(r, username, userID)
+		return nil, ErrInvalidCredentials
+	}
+
+	if totp, err := a.Queries.GetUserTOTP(r.Context(), user.ID); err == nil && totp.EnabledAt.Valid {
+		return nil, ErrTwoFactorRequired
+	}
+
+	if a.Guard != nil {
+		a.Guard.RecordSuccess(r, username)
+	}
+
+	return &Principal{
+		UserID:                user.ID,
+		Username:              user.Username,
+		AccountType:           user.AccountType,
+		DefaultOrganizationID: user.OrganizationID,
+		Method:                MethodBasic,
+	}, nil
+}
+
+func (a BasicAuthenticator) recordFailure(r *http.Request, username string, userID uuid.NullUUID) {
+	if a.Guard != nil {
+		a.Guard.RecordFailure(r, username, userID)
+	}
+}
+
+// CertificateResolver maps a verified client certificate to the account it identifies
+type CertificateResolver interface {
+	// ResolveCertificate returns ErrInvalidCertificate when the certificate is not mapped to an account
+	ResolveCertificate(r *http.Request, cert *x509.Certificate) (*Principal, error)
+}
+
+// ClientCertAuthenticator accepts TLS client certificates that were verified during the handshake
+type ClientCertAuthenticator struct {
+	Resolver CertificateResolver
+}
+
+// Authenticate implements Authenticator
+func (a ClientCertAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	// Only certificates that chained to a trusted client CA count as credentials
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+
+	principal, err := a.Resolver.ResolveCertificate(r, r.TLS.VerifiedChains[0][0])
+	if err != nil {
+		return nil, err
+	}
+	principal.Method = MethodClientCert
+	return principal, nil
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"crypto/x509"
+	"slices"
+	"strings"
+)
+
+// Ways a client certificate can be matched to an account, in the order they are tried
+const (
+	CertMatchURI        = "uri"
+	CertMatchDNS        = "dns"
+	CertMatchEmail      = "email"
+	CertMatchCommonName = "common_name"
+)
+
+// CertMatchTypes lists every supported certificate match type
+var CertMatchTypes = []string{CertMatchURI, CertMatchDNS, CertMatchEmail, CertMatchCommonName}
+
+// CertificateIdentity is one name a client certificate vouches for
+type CertificateIdentity struct {
+	Type  string
+	Value string
+}
+
+// CertificateIdentities returns the names in a certificate, subject alternative names before the subject common name
+func CertificateIdentities(cert *x509.Certificate) []CertificateIdentity {
+	var identities []CertificateIdentity
+	for _, uri := range cert.URIs {
+		identities = append(identities, CertificateIdentity{CertMatchURI, uri.String()})
+	}
+	for _, name := range cert.DNSNames {
+		identities = append(identities, CertificateIdentity{CertMatchDNS, NormalizeCertMatchValue(CertMatchDNS, name)})
+	}
+	for _, email := range cert.EmailAddresses {
+		identities = append(identities, CertificateIdentity{CertMatchEmail, NormalizeCertMatchValue(CertMatchEmail, email)})
+	}
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, CertificateIdentity{CertMatchCommonName, cert.Subject.CommonName})
+	}
+	return identities
+}
+
+// IsCertMatchType reports whether matchType is a supported certificate match type
+func IsCertMatchType(matchType string) bool {
+	return slices.Contains(CertMatchTypes, matchType)
+}
+
+// NormalizeCertMatchValue lowercases DNS names and email addresses, which compare case-insensitively
+func NormalizeCertMatchValue(matchType, value string) string {
+	value = strings.TrimSpace(value)
+	if matchType == CertMatchDNS || matchType == CertMatchEmail {
+		return strings.ToLower(value)
+	}
+	return value
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestCertificateIdentitiesPreferSANs(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/billing")
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "billing-service"},
+		URIs:           []*url.URL{spiffe},
+		DNSNames:       []string{"Billing.Internal.Example"},
+		EmailAddresses: []string{"Ops@Example.com"},
+	}
+
+	want := []CertificateIdentity{
+		{CertMatchURI, "spiffe://example.org/billing"},
+		{CertMatchDNS, "billing.internal.example"},
+		{CertMatchEmail, "ops@example.com"},
+		{CertMatchCommonName, "billing-service"},
+	}
+	if got := CertificateIdentities(cert); !reflect.DeepEqual(got, want) {
+		t.Errorf("CertificateIdentities = %v, want %v", got, want)
+	}
+
+	if got := CertificateIdentities(&x509.Certificate{}); len(got) != 0 {
+		t.Errorf("expected no identities for an empty certificate, got %v", got)
+	}
+}
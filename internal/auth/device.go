@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// DeviceCodeGrantType is the grant_type of the device authorization grant (RFC 8628)
+const DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// userCodeAlphabet leaves out vowels and look-alike characters so codes are easy to read and type
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// userCodeLength is the number of characters in a user code, shown as two groups of four
+const userCodeLength = 8
+
+// GenerateUserCode returns a random user code in the form XXXX-XXXX
+func GenerateUserCode() (string, error) {
+	max := big.NewInt(int64(len(userCodeAlphabet)))
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeAlphabet[n.Int64()]
+	}
+	return FormatUserCode(string(code)), nil
+}
+
+// NormalizeUserCode uppercases a user code and drops separators and whitespace, for storage and lookup
+func NormalizeUserCode(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// FormatUserCode splits a normalized user code into two dash-separated halves for display
+func FormatUserCode(code string) string {
+	if len(code) != userCodeLength {
+		return code
+	}
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateUserCode(t *testing.T) {
+	code, err := GenerateUserCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(code) != 9 || code[4] != '-' {
+		t.Fatalf("expected XXXX-XXXX, got %q", code)
+	}
+	for _, r := range NormalizeUserCode(code) {
+		if !strings.ContainsRune(userCodeAlphabet, r) {
+			t.Errorf("unexpected character %q in %q", r, code)
+		}
+	}
+}
+
+func TestNormalizeUserCode(t *testing.T) {
+	tests := map[string]string{
+		"BCDF-GHJK":   "BCDFGHJK",
+		"bcdf ghjk":   "BCDFGHJK",
+		" bcdfghjk\n": "BCDFGHJK",
+	}
+	for input, want := range tests {
+		if got := NormalizeUserCode(input); got != want {
+			t.Errorf("NormalizeUserCode(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	if got := FormatUserCode("BCDFGHJK"); got != "BCDF-GHJK" {
+		t.Errorf("FormatUserCode = %q", got)
+	}
+}
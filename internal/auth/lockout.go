@@ -0,0 +1,36 @@
+package auth
+
+import "time"
+
+// LockoutPolicy describes when repeated login failures lock out a username or client IP
+type LockoutPolicy struct {
+	// MaxAttempts is the number of failures allowed before the first lockout
+	MaxAttempts int
+	// Window is how long failures are remembered; the counter starts over after this much quiet time
+	Window time.Duration
+	// BaseLockout is the first lockout duration; it doubles with every further failure
+	BaseLockout time.Duration
+	// MaxLockout caps the lockout duration
+	MaxLockout time.Duration
+}
+
+// LockoutFor returns how long to lock out after the given number of consecutive failures,
+// or zero if the failures are still under the limit
+func (p LockoutPolicy) LockoutFor(failures int) time.Duration {
+	if p.MaxAttempts <= 0 || failures < p.MaxAttempts {
+		return 0
+	}
+
+	lockout := p.BaseLockout
+	for i := p.MaxAttempts; i < failures; i++ {
+		lockout *= 2
+		if lockout >= p.MaxLockout {
+			return p.MaxLockout
+		}
+	}
+
+	if lockout > p.MaxLockout {
+		return p.MaxLockout
+	}
+	return lockout
+}
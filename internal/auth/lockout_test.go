@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutForBacksOffExponentially(t *testing.T) {
+	policy := LockoutPolicy{
+		MaxAttempts: 5,
+		Window:      15 * time.Minute,
+		BaseLockout: time.Minute,
+		MaxLockout:  time.Hour,
+	}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{8, 8 * time.Minute},
+		{11, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := policy.LockoutFor(tt.failures); got != tt.want {
+			t.Errorf("LockoutFor(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLockoutForDisabled(t *testing.T) {
+	policy := LockoutPolicy{BaseLockout: time.Minute, MaxLockout: time.Hour}
+	if got := policy.LockoutFor(1000); got != 0 {
+		t.Errorf("expected no lockout when MaxAttempts is zero, got %s", got)
+	}
+}
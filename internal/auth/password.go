@@ -1,7 +1,9 @@
 package auth
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
 	"errors"
@@ -17,12 +19,17 @@ type PasswordConfig struct {
 	Memory  uint32
 	Threads uint8
 	KeyLen  uint32
+
+	// PepperID selects the pepper mixed into new hashes; empty disables peppering
+	PepperID string
+	// Peppers maps pepper key IDs to server-side secrets; retired IDs stay here so older hashes still verify
+	Peppers map[string][]byte
 }
 
 // DefaultPasswordConfig returns a secure default configuration for password hashing
 func DefaultPasswordConfig() *PasswordConfig {
 	return &PasswordConfig{
-		Time:    1,
+		Time:    3,
 		Memory:  64 * 1024, // 64 MB
 		Threads: 4,
 		KeyLen:  32,
@@ -32,6 +39,7 @@ func DefaultPasswordConfig() *PasswordConfig {
 var (
 	ErrInvalidHash         = errors.New("invalid hash format")
 	ErrIncompatibleVersion = errors.New("incompatible version of argon2")
+	ErrUnknownPepper       = errors.New("password hash uses an unknown pepper key ID")
 )
 
 // HashPassword creates a hash of the password using Argon2id
@@ -40,6 +48,11 @@ func HashPassword(password string, config *PasswordConfig) (string, error) {
 		config = DefaultPasswordConfig()
 	}
 
+	input, err := config.pepper(password, config.PepperID)
+	if err != nil {
+		return "", err
+	}
+
 	// Generate a random salt
 	salt := make([]byte, 16)
 	if _, err := rand.Read(salt); err != nil {
@@ -47,32 +60,89 @@ func HashPassword(password string, config *PasswordConfig) (string, error) {
 	}
 
 	// Generate the hash
-	hash := argon2.IDKey([]byte(password), salt, config.Time, config.Memory, config.Threads, config.KeyLen)
+	hash := argon2.IDKey(input, salt, config.Time, config.Memory, config.Threads, config.KeyLen)
 
 	// Encode the salt and hash
 	saltEncoded := base64.RawStdEncoding.EncodeToString(salt)
 	hashEncoded := base64.RawStdEncoding.EncodeToString(hash)
 
-	// Format: $argon2id$v=19$m=65536,t=1,p=4$salt$hash
-	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
-		argon2.Version, config.Memory, config.Time, config.Threads, saltEncoded, hashEncoded), nil
+	// Format: $argon2id$v=19$m=65536,t=3,p=4[,kid=id]$salt$hash
+	params := fmt.Sprintf("m=%d,t=%d,p=%d", config.Memory, config.Time, config.Threads)
+	if config.PepperID != "" {
+		params += ",kid=" + config.PepperID
+	}
+
+	return fmt.Sprintf("$argon2id$v=%d$%s$%s$%s", argon2.Version, params, saltEncoded, hashEncoded), nil
 }
 
-// VerifyPassword verifies a password against its hash
-func VerifyPassword(password, hash string) (bool, error) {
+// VerifyPassword verifies a password against its hash; config supplies the peppers and may be nil
+func VerifyPassword(password, hash string, config *PasswordConfig) (bool, error) {
+	if config == nil {
+		config = DefaultPasswordConfig()
+	}
+
 	// Parse the hash
-	config, salt, hashBytes, err := parseHash(hash)
+	params, salt, hashBytes, err := parseHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	input, err := config.pepper(password, params.PepperID)
 	if err != nil {
 		return false, err
 	}
 
 	// Generate hash from provided password
-	providedHash := argon2.IDKey([]byte(password), salt, config.Time, config.Memory, config.Threads, config.KeyLen)
+	providedHash := argon2.IDKey(input, salt, params.Time, params.Memory, params.Threads, params.KeyLen)
 
 	// Compare hashes using constant time comparison
 	return subtle.ConstantTimeCompare(hashBytes, providedHash) == 1, nil
 }
 
+// NeedsRehash reports whether a stored hash is weaker than the given configuration
+// or was made with a different pepper, so it should be replaced on the next successful login
+func NeedsRehash(hash string, config *PasswordConfig) bool {
+	if config == nil {
+		config = DefaultPasswordConfig()
+	}
+
+	params, _, _, err := parseHash(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory < config.Memory ||
+		params.Time < config.Time ||
+		params.Threads < config.Threads ||
+		params.KeyLen < config.KeyLen ||
+		params.PepperID != config.PepperID
+}
+
+// HashParameters returns the parameter segment of an encoded hash, e.g. "m=65536,t=3,p=4,kid=k1"
+func HashParameters(hash string) string {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return "invalid"
+	}
+	return parts[3]
+}
+
+// pepper mixes the server-side secret with the given key ID into the password
+func (c *PasswordConfig) pepper(password, pepperID string) ([]byte, error) {
+	if pepperID == "" {
+		return []byte(password), nil
+	}
+
+	secret, ok := c.Peppers[pepperID]
+	if !ok {
+		return nil, ErrUnknownPepper
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(password))
+	return mac.Sum(nil), nil
+}
+
 // parseHash extracts the configuration and salt from an encoded hash
 func parseHash(hash string) (*PasswordConfig, []byte, []byte, error) {
 	parts := strings.Split(hash, "$")
@@ -93,10 +163,33 @@ func parseHash(hash string) (*PasswordConfig, []byte, []byte, error) {
 	}
 
 	config := &PasswordConfig{}
-	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &config.Memory, &config.Time, &config.Threads); err != nil {
-		return nil, nil, nil, err
+	for _, param := range strings.Split(parts[3], ",") {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, nil, nil, ErrInvalidHash
+		}
+
+		var err error
+		switch key {
+		case "m":
+			_, err = fmt.Sscanf(value, "%d", &config.Memory)
+		case "t":
+			_, err = fmt.Sscanf(value, "%d", &config.Time)
+		case "p":
+			_, err = fmt.Sscanf(value, "%d", &config.Threads)
+		case "kid":
+			config.PepperID = value
+		default:
+			err = ErrInvalidHash
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if config.Memory == 0 || config.Time == 0 || config.Threads == 0 {
+		return nil, nil, nil, ErrInvalidHash
 	}
-	config.KeyLen = 32
 
 	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
 	if err != nil {
@@ -108,50 +201,11 @@ func parseHash(hash string) (*PasswordConfig, []byte, []byte, error) {
 		return nil, nil, nil, err
 	}
 
-	return config, salt, hashBytes, nil
-}
-
-// ValidatePasswordStrength validates password strength
-func ValidatePasswordStrength(password string) error {
-	if len(password) < 8 {
-		return errors.New("password must be at least 8 characters long")
-	}
-
-	hasUpper := false
-	hasLower := false
-	hasDigit := false
-	hasSpecial := false
-
-	for _, char := range password {
-		switch {
-		case char >= 'A' && char <= 'Z':
-			hasUpper = true
-		case char >= 'a' && char <= 'z':
-			hasLower = true
-		case char >= '0' && char <= '9':
-			hasDigit = true
-		case strings.ContainsRune("!@#$%^&*()_+-=[]{}|;:,.<>?", char):
-			hasSpecial = true
-		}
-	}
-
-	var missing []string
-	if !hasUpper {
-		missing = append(missing, "uppercase letter")
-	}
-	if !hasLower {
-		missing = append(missing, "lowercase letter")
-	}
-	if !hasDigit {
-		missing = append(missing, "digit")
-	}
-	if !hasSpecial {
-		missing = append(missing, "special character")
-	}
-
-	if len(missing) > 0 {
-		return fmt.Errorf("password must contain at least one: %s", strings.Join(missing, ", "))
+	// The key length is whatever length the stored hash was created with
+	config.KeyLen = uint32(len(hashBytes))
+	if config.KeyLen == 0 {
+		return nil, nil, nil, ErrInvalidHash
 	}
 
-	return nil
+	return config, salt, hashBytes, nil
 }
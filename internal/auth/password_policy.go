@@ -0,0 +1,231 @@
+package auth
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password policy names that can be selected globally or per organization
+const (
+	PasswordPolicyStandard = "standard"
+	PasswordPolicyStrict   = "strict"
+	PasswordPolicyLegacy   = "legacy"
+)
+
+// Password policy violation codes
+const (
+	ViolationTooShort         = "too_short"
+	ViolationTooLong          = "too_long"
+	ViolationMissingUppercase = "missing_uppercase"
+	ViolationMissingLowercase = "missing_lowercase"
+	ViolationMissingDigit     = "missing_digit"
+	ViolationMissingSymbol    = "missing_symbol"
+	ViolationTooFewClasses    = "too_few_character_classes"
+	ViolationContainsUsername = "contains_username"
+	ViolationCommonPassword   = "common_password"
+)
+
+// minUsernameSimilarityRunes skips the username check for very short usernames
+const minUsernameSimilarityRunes = 3
+
+// PasswordPolicy describes the rules a new password must satisfy
+type PasswordPolicy struct {
+	Name      string `json:"name"`
+	MinLength int    `json:"min_length"`
+	MaxLength int    `json:"max_length"`
+
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSymbol    bool `json:"require_symbol"`
+	// MinCharClasses requires this many of uppercase, lowercase, digits and symbols
+	MinCharClasses int `json:"min_char_classes"`
+
+	RejectUsername bool `json:"reject_username"`
+	RejectCommon   bool `json:"reject_common"`
+}
+
+// PasswordViolation is one rule a password failed
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password failed
+type PasswordPolicyError struct {
+	Policy     string
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "password does not meet the policy: " + strings.Join(messages, "; ")
+}
+
+// passwordPolicies are the built-in policies by name
+var passwordPolicies = map[string]PasswordPolicy{
+	// Length over composition, in line with NIST SP 800-63B; passphrases are welcome
+	PasswordPolicyStandard: {
+		Name:           PasswordPolicyStandard,
+		MinLength:      10,
+		MaxLength:      256,
+		RejectUsername: true,
+		RejectCommon:   true,
+	},
+	PasswordPolicyStrict: {
+		Name:           PasswordPolicyStrict,
+		MinLength:      14,
+		MaxLength:      256,
+		MinCharClasses: 3,
+		RejectUsername: true,
+		RejectCommon:   true,
+	},
+	// The original composition rules, kept for organizations that still require them
+	PasswordPolicyLegacy: {
+		Name:             PasswordPolicyLegacy,
+		MinLength:        8,
+		MaxLength:        256,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		RejectUsername:   true,
+		RejectCommon:     true,
+	},
+}
+
+// PasswordPolicyByName returns a built-in policy
+func PasswordPolicyByName(name string) (PasswordPolicy, bool) {
+	policy, ok := passwordPolicies[name]
+	return policy, ok
+}
+
+// DefaultPasswordPolicy returns the policy used when nothing else is configured
+func DefaultPasswordPolicy() PasswordPolicy {
+	return passwordPolicies[PasswordPolicyStandard]
+}
+
+// Validate checks a password against the policy. It returns a *PasswordPolicyError
+// listing every failed rule, or nil if the password is acceptable.
+func (p PasswordPolicy) Validate(password, username string) error {
+	var violations []PasswordViolation
+	add := func(code, format string, args ...interface{}) {
+		violations = append(violations, PasswordViolation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		add(ViolationTooShort, "must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		add(ViolationTooLong, "must be at most %d characters long", p.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUppercase && !hasUpper {
+		add(ViolationMissingUppercase, "must contain an uppercase letter")
+	}
+	if p.RequireLowercase && !hasLower {
+		add(ViolationMissingLowercase, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		add(ViolationMissingDigit, "must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		add(ViolationMissingSymbol, "must contain a symbol")
+	}
+
+	if p.MinCharClasses > 0 {
+		classes := 0
+		for _, has := range []bool{hasUpper, hasLower, hasDigit, hasSymbol} {
+			if has {
+				classes++
+			}
+		}
+		if classes < p.MinCharClasses {
+			add(ViolationTooFewClasses, "must mix at least %d of uppercase letters, lowercase letters, digits and symbols", p.MinCharClasses)
+		}
+	}
+
+	if p.RejectUsername && similarToUsername(password, username) {
+		add(ViolationContainsUsername, "must not contain the username")
+	}
+
+	if p.RejectCommon && IsCommonPassword(password) {
+		add(ViolationCommonPassword, "is too common")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Policy: p.Name, Violations: violations}
+	}
+	return nil
+}
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var commonPasswords = parseCommonPasswords(commonPasswordsFile)
+
+func parseCommonPasswords(file string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	for _, line := range strings.Split(file, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[line] = struct{}{}
+	}
+	return passwords
+}
+
+// leetReplacer undoes common character substitutions such as p@ssw0rd
+var leetReplacer = strings.NewReplacer("@", "a", "4", "a", "3", "e", "1", "i", "!", "i", "0", "o", "$", "s", "5", "s", "7", "t")
+
+// IsCommonPassword reports whether a password is on the blocklist, also after stripping
+// trailing digits and symbols and undoing common character substitutions
+func IsCommonPassword(password string) bool {
+	lower := strings.ToLower(password)
+	base := strings.TrimRightFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) })
+
+	for _, candidate := range []string{lower, base, leetReplacer.Replace(lower), leetReplacer.Replace(base)} {
+		if _, ok := commonPasswords[candidate]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// similarToUsername reports whether the password contains the username, forwards or reversed
+func similarToUsername(password, username string) bool {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if utf8.RuneCountInString(username) < minUsernameSimilarityRunes {
+		return false
+	}
+
+	lower := strings.ToLower(password)
+	runes := []rune(username)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return strings.Contains(lower, username) || strings.Contains(lower, string(runes))
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func violationCodes(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+
+	var policyErr *PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected *PasswordPolicyError, got %T", err)
+	}
+
+	codes := make([]string, len(policyErr.Violations))
+	for i, v := range policyErr.Violations {
+		codes[i] = v.Code
+	}
+	return codes
+}
+
+func TestStandardPolicy(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+
+	tests := []struct {
+		name     string
+		password string
+		username string
+		want     []string
+	}{
+		{"passphrase", "correct horse battery staple", "alice", nil},
+		{"unicode symbols count", "grüße aus köln", "alice", nil},
+		{"too short", "tiny", "alice", []string{ViolationTooShort}},
+		{"common with suffix", "Password1!", "alice", []string{ViolationCommonPassword}},
+		{"common with substitutions", "P@ssw0rd2024", "alice", []string{ViolationCommonPassword}},
+		{"contains username", "my-name-is-alice", "Alice", []string{ViolationContainsUsername}},
+		{"contains reversed username", "ecila-backwards", "alice", []string{ViolationContainsUsername}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violationCodes(t, policy.Validate(tt.password, tt.username))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got violations %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got violations %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLegacyPolicyReportsEveryMissingClass(t *testing.T) {
+	policy, ok := PasswordPolicyByName(PasswordPolicyLegacy)
+	if !ok {
+		t.Fatal("legacy policy not found")
+	}
+
+	got := violationCodes(t, policy.Validate("lowercaseonly", ""))
+	want := []string{ViolationMissingUppercase, ViolationMissingDigit, ViolationMissingSymbol}
+	if len(got) != len(want) {
+		t.Fatalf("got violations %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("got violations %v, want %v", got, want)
+		}
+	}
+}
+
+func TestStrictPolicyCharacterClasses(t *testing.T) {
+	policy, _ := PasswordPolicyByName(PasswordPolicyStrict)
+
+	if err := policy.Validate("only lowercase words here", ""); err == nil {
+		t.Error("expected a single character class to be rejected")
+	}
+	if err := policy.Validate("Mixed Case words 42 here", ""); err != nil {
+		t.Errorf("expected three character classes to pass, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+// testPasswordConfig keeps argon2 cheap so the tests run quickly
+func testPasswordConfig() *PasswordConfig {
+	return &PasswordConfig{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32}
+}
+
+func TestHashAndVerifyPassword(t *testing.T) {
+	config := testPasswordConfig()
+
+	hash, err := HashPassword("Correct-horse1", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := VerifyPassword("Correct-horse1", hash, config); err != nil || !ok {
+		t.Errorf("expected password to verify, got ok=%v err=%v", ok, err)
+	}
+	if ok, _ := VerifyPassword("wrong", hash, config); ok {
+		t.Error("expected wrong password to be rejected")
+	}
+}
+
+func TestVerifyPasswordUsesStoredKeyLength(t *testing.T) {
+	config := testPasswordConfig()
+	config.KeyLen = 64
+
+	hash, err := HashPassword("Correct-horse1", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := VerifyPassword("Correct-horse1", hash, testPasswordConfig()); err != nil || !ok {
+		t.Errorf("expected 64-byte hash to verify, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestPepperedHashes(t *testing.T) {
+	config := testPasswordConfig()
+	config.PepperID = "k1"
+	config.Peppers = map[string][]byte{"k1": []byte("first"), "k2": []byte("second")}
+
+	hash, err := HashPassword("Correct-horse1", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(hash, ",kid=k1$") {
+		t.Fatalf("expected key ID in hash, got %s", hash)
+	}
+
+	// Rotating to a new pepper keeps old hashes verifiable but marks them for rehash
+	config.PepperID = "k2"
+	if ok, err := VerifyPassword("Correct-horse1", hash, config); err != nil || !ok {
+		t.Errorf("expected hash with retired pepper to verify, got ok=%v err=%v", ok, err)
+	}
+	if !NeedsRehash(hash, config) {
+		t.Error("expected hash with retired pepper to need a rehash")
+	}
+
+	delete(config.Peppers, "k1")
+	if _, err := VerifyPassword("Correct-horse1", hash, config); err != ErrUnknownPepper {
+		t.Errorf("expected ErrUnknownPepper, got %v", err)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	config := testPasswordConfig()
+
+	hash, err := HashPassword("Correct-horse1", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if NeedsRehash(hash, config) {
+		t.Error("expected hash with current parameters not to need a rehash")
+	}
+
+	stronger := testPasswordConfig()
+	stronger.Time = 2
+	if !NeedsRehash(hash, stronger) {
+		t.Error("expected hash with fewer iterations to need a rehash")
+	}
+
+	weaker := testPasswordConfig()
+	weaker.Memory = 512
+	if NeedsRehash(hash, weaker) {
+		t.Error("expected hash stronger than the policy not to need a rehash")
+	}
+}
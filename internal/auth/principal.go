@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Authentication methods recorded on a principal
+const (
+	MethodAPIKey     = "api_key"
+	MethodBearer     = "bearer"
+	MethodBasic      = "basic"
+	MethodClientCert = "client_cert"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID      uuid.UUID
+	Username    string
+	AccountType string
+
+	// DefaultOrganizationID is the organization used when a request does not select one
+	DefaultOrganizationID uuid.NullUUID
+
+	// OrganizationID is the organization the request acts in and Role the caller's role there;
+	// both are set once the active organization is resolved, and OrganizationID is empty outside any organization
+	OrganizationID uuid.NullUUID
+	Role           string
+
+	// Scopes restricts what the caller may do; nil means unrestricted
+	Scopes []string
+
+	// Method is how the caller authenticated, one of the Method constants
+	Method string
+
+	// SessionID and APIKeyID identify the credential used; they are uuid.Nil for other methods
+	SessionID uuid.UUID
+	APIKeyID  uuid.UUID
+}
+
+// HasScope reports whether the principal may act within the required scope
+func (p *Principal) HasScope(required string) bool {
+	return p.Scopes == nil || HasScope(p.Scopes, required)
+}
+
+// IsServiceAccount reports whether the principal is a service account rather than a person
+func (p *Principal) IsServiceAccount() bool {
+	return p.AccountType == AccountTypeService
+}
+
+// InOrganization reports whether the principal is acting in the given organization
+func (p *Principal) InOrganization(orgID uuid.UUID) bool {
+	return p.OrganizationID.Valid && p.OrganizationID.UUID == orgID
+}
+
+// principalKey is the context key of the request's principal
+type principalKey struct{}
+
+// WithPrincipal stores the authenticated principal in the request context
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext retrieves the authenticated principal from the request context
+func PrincipalFromContext(ctx context.Context) (*Principal, error) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	if !ok || principal == nil {
+		return nil, ErrUnauthorized
+	}
+	return principal, nil
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// API key scopes
+const (
+	ScopeAll        = "*"
+	ScopeUserRead   = "user:read"
+	ScopeUserWrite  = "user:write"
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+	ScopeOrgsRead   = "orgs:read"
+	ScopeOrgsAdmin  = "orgs:admin"
+)
+
+// ValidScopes lists every scope that can be granted to an API key
+var ValidScopes = []string{
+	ScopeAll,
+	ScopeUserRead,
+	ScopeUserWrite,
+	ScopeTasksRead,
+	ScopeTasksWrite,
+	ScopeOrgsRead,
+	ScopeOrgsAdmin,
+}
+
+// impliedScopes maps a scope to the narrower scopes it also grants
+var impliedScopes = map[string][]string{
+	ScopeUserWrite:  {ScopeUserRead},
+	ScopeTasksWrite: {ScopeTasksRead},
+	ScopeOrgsAdmin:  {ScopeOrgsRead},
+}
+
+// ValidateScopes checks that every requested scope is known
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required, valid scopes: %s", strings.Join(ValidScopes, ", "))
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(ValidScopes, scope) {
+			return fmt.Errorf("unknown scope %q, valid scopes: %s", scope, strings.Join(ValidScopes, ", "))
+		}
+	}
+	return nil
+}
+
+// HasScope reports whether the granted scopes allow the required scope
+func HasScope(granted []string, required string) bool {
+	for _, scope := range granted {
+		if scope == ScopeAll || scope == required {
+			return true
+		}
+		if slices.Contains(impliedScopes[scope], required) {
+			return true
+		}
+	}
+	return false
+}
+
+// apiKeyPrefixLength is how many leading characters of a key are kept to identify it
+const apiKeyPrefixLength = 8
+
+// GenerateAPIKey returns a new random 64 character hex API key
+func GenerateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// APIKeyPrefix returns the non-secret leading part of a key shown in key listings
+func APIKeyPrefix(key string) string {
+	if len(key) < apiKeyPrefixLength {
+		return key
+	}
+	return key[:apiKeyPrefixLength]
+}
@@ -0,0 +1,37 @@
+package auth
+
+import "testing"
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		granted  []string
+		required string
+		want     bool
+	}{
+		{[]string{ScopeAll}, ScopeOrgsAdmin, true},
+		{[]string{ScopeTasksRead}, ScopeTasksRead, true},
+		{[]string{ScopeTasksRead}, ScopeTasksWrite, false},
+		{[]string{ScopeTasksWrite}, ScopeTasksRead, true},
+		{[]string{ScopeOrgsAdmin}, ScopeOrgsRead, true},
+		{[]string{ScopeOrgsRead}, ScopeTasksRead, false},
+		{nil, ScopeUserRead, false},
+	}
+
+	for _, tt := range tests {
+		if got := HasScope(tt.granted, tt.required); got != tt.want {
+			t.Errorf("HasScope(%v, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	if err := ValidateScopes([]string{ScopeTasksRead, ScopeOrgsAdmin}); err != nil {
+		t.Errorf("expected valid scopes, got %v", err)
+	}
+	if err := ValidateScopes(nil); err == nil {
+		t.Error("expected error for empty scopes")
+	}
+	if err := ValidateScopes([]string{"tasks:destroy"}); err == nil {
+		t.Error("expected error for unknown scope")
+	}
+}
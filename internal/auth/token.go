@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token issuer and types used in signed tokens
+const (
+	TokenIssuer     = "go-hello-world"
+	TokenTypeAccess = "access"
+	TokenTypeMFA    = "mfa"
+	TokenTypeBearer = "Bearer"
+)
+
+// Token errors
+var (
+	ErrMissingSecret    = errors.New("token signing secret is not configured")
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrTokenExpired     = errors.New("token has expired")
+	ErrWrongTokenType   = errors.New("unexpected token type")
+)
+
+// TokenClaims holds the claims carried by a signed access token
+type TokenClaims struct {
+	Issuer    string    `json:"iss"`
+	Subject   uuid.UUID `json:"sub"`
+	SessionID uuid.UUID `json:"sid"`
+	ID        string    `json:"jti,omitempty"`
+	Type      string    `json:"typ"`
+	IssuedAt  int64     `json:"iat"`
+	ExpiresAt int64     `json:"exp"`
+}
+
+// tokenHeader is the fixed JWT header for HS256 tokens
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// IssueAccessToken creates a signed HS256 JWT for the given user and session
+func IssueAccessToken(secret []byte, userID, sessionID uuid.UUID, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	token, err := SignToken(secret, TokenClaims{
+		Issuer:    TokenIssuer,
+		Subject:   userID,
+		SessionID: sessionID,
+		Type:      TokenTypeAccess,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	return token, expiresAt, err
+}
+
+// IssueMFAToken creates a short-lived token proving the password step of a two-factor login succeeded.
+// Its unique ID lets the second step accept it only once.
+func IssueMFAToken(secret []byte, userID uuid.UUID, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return SignToken(secret, TokenClaims{
+		Issuer:    TokenIssuer,
+		Subject:   userID,
+		ID:        uuid.NewString(),
+		Type:      TokenTypeMFA,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+}
+
+// SignToken encodes and signs arbitrary token claims
+func SignToken(secret []byte, claims TokenClaims) (string, error) {
+	if len(secret) == 0 {
+		return "", ErrMissingSecret
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + sign(secret, signingInput), nil
+}
+
+// ParseAccessToken verifies the signature and expiry of an access token and returns its claims
+func ParseAccessToken(secret []byte, token string) (*TokenClaims, error) {
+	return ParseToken(secret, token, TokenTypeAccess)
+}
+
+// ParseToken verifies a signed token and checks that it has the expected type
+func ParseToken(secret []byte, token, expectedType string) (*TokenClaims, error) {
+	if len(secret) == 0 {
+		return nil, ErrMissingSecret
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return nil, ErrMalformedToken
+	}
+
+	expected := sign(secret, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return nil, ErrInvalidSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	var claims TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if claims.Type != expectedType {
+		return nil, ErrWrongTokenType
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	return &claims, nil
+}
+
+// GenerateOpaqueToken returns a random URL-safe token suitable for refresh tokens
+func GenerateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex-encoded SHA-256 digest of a token for storage
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func sign(secret []byte, signingInput string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
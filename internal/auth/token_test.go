@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestIssueAndParseAccessToken(t *testing.T) {
+	secret := []byte("test-secret")
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	token, expiresAt, err := IssueAccessToken(secret, userID, sessionID, time.Minute)
+	if err != nil {
+		t.Fatalf("IssueAccessToken returned error: %v", err)
+	}
+	if time.Until(expiresAt) <= 0 {
+		t.Errorf("expected expiry in the future, got %v", expiresAt)
+	}
+
+	claims, err := ParseAccessToken(secret, token)
+	if err != nil {
+		t.Fatalf("ParseAccessToken returned error: %v", err)
+	}
+	if claims.Subject != userID || claims.SessionID != sessionID {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestParseAccessTokenRejectsInvalidTokens(t *testing.T) {
+	secret := []byte("test-secret")
+
+	expired, _, err := IssueAccessToken(secret, uuid.New(), uuid.New(), -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseAccessToken(secret, expired); err != ErrTokenExpired {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+
+	valid, _, err := IssueAccessToken(secret, uuid.New(), uuid.New(), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseAccessToken([]byte("other-secret"), valid); err != ErrInvalidSignature {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+
+	parts := strings.Split(valid, ".")
+	tampered := parts[0] + "." + parts[1] + "x." + parts[2]
+	if _, err := ParseAccessToken(secret, tampered); err == nil {
+		t.Error("expected tampered token to be rejected")
+	}
+
+	if _, err := ParseAccessToken(secret, "not-a-token"); err != ErrMalformedToken {
+		t.Errorf("expected ErrMalformedToken, got %v", err)
+	}
+}
+
+func TestIssueMFATokenHasUniqueID(t *testing.T) {
+	secret := []byte("test-secret")
+	userID := uuid.New()
+
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		token, err := IssueMFAToken(secret, userID, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims, err := ParseToken(secret, token, TokenTypeMFA)
+		if err != nil {
+			t.Fatalf("ParseToken returned error: %v", err)
+		}
+		if _, err := uuid.Parse(claims.ID); err != nil || seen[claims.ID] {
+			t.Errorf("expected a fresh token ID, got %q", claims.ID)
+		}
+		seen[claims.ID] = true
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B test vectors (SHA-1), truncated to six digits
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode returned error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTPAllowsSkew(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	previous, _ := TOTPCode(secret, TOTPStep(now)-1)
+	if step, ok := ValidateTOTP(secret, previous, now); !ok || step != TOTPStep(now)-1 {
+		t.Errorf("expected previous period code to validate, got step=%d ok=%v", step, ok)
+	}
+
+	stale, _ := TOTPCode(secret, TOTPStep(now)-3)
+	if _, ok := ValidateTOTP(secret, stale, now); ok {
+		t.Error("expected code from three periods ago to be rejected")
+	}
+
+	if _, ok := ValidateTOTP(secret, "12345", now); ok {
+		t.Error("expected short code to be rejected")
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("unexpected recovery code format: %q", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate recovery code: %q", code)
+		}
+		seen[code] = true
+
+		if NormalizeRecoveryCode(code) != code[:5]+code[6:] {
+			t.Errorf("unexpected normalized code for %q", code)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package authz decides what a caller may do from the permissions granted to their role.
+// Permissions are named resource:action; the built-in roles are fixed and organizations can define custom roles.
+package authz
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// Built-in roles, from least to most privileged; each role has every permission of the roles before it
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+	RoleOwner     = "owner"
+)
+
+// Permissions
+const (
+	TaskRead   = "task:read"
+	TaskCreate = "task:create"
+	TaskUpdate = "task:update"
+	TaskDelete = "task:delete"
+
+	OrgCreate = "org:create"
+	OrgRead   = "org:read"
+	OrgUpdate = "org:update"
+	OrgDelete = "org:delete"
+
+	OrgMembersRead           = "org:members:read"
+	OrgMembersManage         = "org:members:manage"
+	OrgSessionsManage        = "org:sessions:manage"
+	OrgRolesManage           = "org:roles:manage"
+	OrgServiceAccountsManage = "org:service_accounts:manage"
+	OrgCertificatesManage    = "org:certificates:manage"
+	OrgAuditRead             = "org:audit:read"
+)
+
+// ValidPermissions lists every permission a role can be granted
+var ValidPermissions = []string{
+	TaskRead,
+	TaskCreate,
+	TaskUpdate,
+	TaskDelete,
+	OrgCreate,
+	OrgRead,
+	OrgUpdate,
+	OrgDelete,
+	OrgMembersRead,
+	OrgMembersManage,
+	OrgSessionsManage,
+	OrgRolesManage,
+	OrgServiceAccountsManage,
+	OrgCertificatesManage,
+	OrgAuditRead,
+}
+
+// ErrUnknownRole is returned for a role that is neither built in nor defined by the caller's organization
+var ErrUnknownRole = errors.New("unknown role")
+
+// ErrNotMember is returned when a caller selects an organization they do not belong to
+var ErrNotMember = errors.New("not a member of this organization")
+
+// Role is a named set of permissions
+type Role struct {
+	Name        string
+	Permissions []string
+}
+
+// builtinRoles is ordered from least to most privileged
+var builtinRoles = []Role{
+	{RoleUser, []string{TaskRead, TaskCreate, TaskUpdate, TaskDelete, OrgCreate, OrgRead, OrgMembersRead}},
+	{RoleModerator, []string{OrgSessionsManage}},
+	{RoleAdmin, []string{OrgUpdate, OrgMembersManage, OrgRolesManage, OrgServiceAccountsManage, OrgCertificatesManage, OrgAuditRead}},
+	{RoleOwner, []string{OrgDelete}},
+}
+
+// BuiltinRoles returns the built-in roles with their complete permission sets, least privileged first
+func BuiltinRoles() []Role {
+	roles := make([]Role, len(builtinRoles))
+	var permissions []string
+	for i, role := range builtinRoles {
+		permissions = append(permissions, role.Permissions...)
+		roles[i] = Role{Name: role.Name, Permissions: slices.Clone(permissions)}
+	}
+	return roles
+}
+
+// BuiltinRole returns the built-in role with the given name
+func BuiltinRole(name string) (Role, bool) {
+	for _, role := range BuiltinRoles() {
+		if role.Name == name {
+			return role, true
+		}
+	}
+	return Role{}, false
+}
+
+// IsBuiltinRole reports whether name is one of the built-in roles, which custom roles cannot shadow
+func IsBuiltinRole(name string) bool {
+	_, ok := BuiltinRole(name)
+	return ok
+}
+
+// ValidatePermissions checks that every permission is known
+func ValidatePermissions(permissions []string) error {
+	for _, permission := range permissions {
+		if !slices.Contains(ValidPermissions, permission) {
+			return fmt.Errorf("unknown permission %q, valid permissions: %s", permission, strings.Join(ValidPermissions, ", "))
+		}
+	}
+	return nil
+}
+
+// Grants reports whether the permission set includes every one of the required permissions
+func Grants(granted []string, required ...string) bool {
+	for _, permission := range required {
+		if !slices.Contains(granted, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// RoleStore looks up the custom roles organizations define; *database.Queries implements it
+type RoleStore interface {
+	GetOrganizationRoleByName(ctx context.Context, arg database.GetOrganizationRoleByNameParams) (database.OrganizationRole, error)
+}
+
+// MembershipStore looks up a user's membership in an organization; *database.Queries implements it
+type MembershipStore interface {
+	GetOrganizationMembership(ctx context.Context, arg database.GetOrganizationMembershipParams) (database.OrganizationMember, error)
+}
+
+// Engine resolves roles to permissions and answers permission checks
+type Engine struct {
+	Roles   RoleStore
+	Members MembershipStore
+}
+
+// Activate makes orgID the organization the principal acts in, with their role in it.
+// With uuid.Nil the principal acts outside any organization, on their own resources, with the user role.
+func (e *Engine) Activate(ctx context.Context, principal *auth.Principal, orgID uuid.UUID) error {
+	principal.OrganizationID = uuid.NullUUID{}
+	principal.Role = RoleUser
+	if orgID == uuid.Nil {
+		return nil
+	}
+
+	member, err := e.Members.GetOrganizationMembership(ctx, database.GetOrganizationMembershipParams{
+		OrganizationID: orgID,
+		UserID:         principal.UserID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotMember
+	}
+	if err != nil {
+		return err
+	}
+
+	principal.OrganizationID = uuid.NullUUID{UUID: orgID, Valid: true}
+	principal.Role = member.Role
+	return nil
+}
+
+// Permissions returns the permissions of a role within an organization; built-in roles take precedence
+func (e *Engine) Permissions(ctx context.Context, orgID uuid.NullUUID, role string) ([]string, error) {
+	if builtin, ok := BuiltinRole(role); ok {
+		return builtin.Permissions, nil
+	}
+	if !orgID.Valid || e.Roles == nil {
+		return nil, ErrUnknownRole
+	}
+
+	custom, err := e.Roles.GetOrganizationRoleByName(ctx, database.GetOrganizationRoleByNameParams{
+		OrganizationID: orgID.UUID,
+		Name:           role,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUnknownRole
+	}
+	if err != nil {
+		return nil, err
+	}
+	return custom.Permissions, nil
+}
+
+// PrincipalPermissions returns the permissions of the principal's role in their organization;
+// a role that no longer exists grants nothing
+func (e *Engine) PrincipalPermissions(ctx context.Context, principal *auth.Principal) ([]string, error) {
+	permissions, err := e.Permissions(ctx, principal.OrganizationID, principal.Role)
+	if errors.Is(err, ErrUnknownRole) {
+		return nil, nil
+	}
+	return permissions, err
+}
+
+// Allowed reports whether the principal's role grants the permission
+func (e *Engine) Allowed(ctx context.Context, principal *auth.Principal, permission string) (bool, error) {
+	permissions, err := e.PrincipalPermissions(ctx, principal)
+	if err != nil {
+		return false, err
+	}
+	return Grants(permissions, permission), nil
+}
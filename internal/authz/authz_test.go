@@ -0,0 +1,126 @@
+package authz
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// fakeRoles serves custom roles from memory
+type fakeRoles map[string][]string
+
+func (f fakeRoles) GetOrganizationRoleByName(ctx context.Context, arg database.GetOrganizationRoleByNameParams) (database.OrganizationRole, error) {
+	permissions, ok := f[arg.Name]
+	if !ok {
+		return database.OrganizationRole{}, sql.ErrNoRows
+	}
+	return database.OrganizationRole{OrganizationID: arg.OrganizationID, Name: arg.Name, Permissions: permissions}, nil
+}
+
+// fakeMembers serves memberships from memory, keyed by organization
+type fakeMembers map[uuid.UUID]map[uuid.UUID]string
+
+func (f fakeMembers) GetOrganizationMembership(ctx context.Context, arg database.GetOrganizationMembershipParams) (database.OrganizationMember, error) {
+	role, ok := f[arg.OrganizationID][arg.UserID]
+	if !ok {
+		return database.OrganizationMember{}, sql.ErrNoRows
+	}
+	return database.OrganizationMember{OrganizationID: arg.OrganizationID, UserID: arg.UserID, Role: role}, nil
+}
+
+func TestBuiltinRolesAreHierarchical(t *testing.T) {
+	roles := BuiltinRoles()
+	for i := 1; i < len(roles); i++ {
+		if !Grants(roles[i].Permissions, roles[i-1].Permissions...) {
+			t.Errorf("%s is missing permissions of %s", roles[i].Name, roles[i-1].Name)
+		}
+	}
+
+	owner, _ := BuiltinRole(RoleOwner)
+	admin, _ := BuiltinRole(RoleAdmin)
+	if !Grants(owner.Permissions, OrgDelete) || Grants(admin.Permissions, OrgDelete) {
+		t.Error("only owners should be able to delete an organization")
+	}
+	if err := ValidatePermissions(owner.Permissions); err != nil {
+		t.Errorf("built-in permissions should be valid: %v", err)
+	}
+}
+
+func TestEnginePermissions(t *testing.T) {
+	engine := &Engine{Roles: fakeRoles{"auditor": {OrgRead, OrgMembersRead}}}
+	org := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	ctx := context.Background()
+
+	tests := []struct {
+		principal  auth.Principal
+		permission string
+		want       bool
+	}{
+		{auth.Principal{Role: RoleUser}, TaskUpdate, true},
+		{auth.Principal{Role: RoleUser, OrganizationID: org}, OrgMembersManage, false},
+		{auth.Principal{Role: RoleAdmin, OrganizationID: org}, OrgMembersManage, true},
+		{auth.Principal{Role: "auditor", OrganizationID: org}, OrgMembersRead, true},
+		{auth.Principal{Role: "auditor", OrganizationID: org}, TaskCreate, false},
+		{auth.Principal{Role: "auditor"}, OrgRead, false},
+		{auth.Principal{Role: "deleted-role", OrganizationID: org}, TaskRead, false},
+	}
+
+	for _, tt := range tests {
+		got, err := engine.Allowed(ctx, &tt.principal, tt.permission)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Allowed(%s, %s) = %v, want %v", tt.principal.Role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePermissions(t *testing.T) {
+	if err := ValidatePermissions([]string{TaskRead, OrgRolesManage}); err != nil {
+		t.Errorf("expected valid permissions, got %v", err)
+	}
+	if err := ValidatePermissions([]string{"task:destroy"}); err == nil {
+		t.Error("expected error for unknown permission")
+	}
+}
+
+func TestActivateUsesMembershipRole(t *testing.T) {
+	user, owned, joined, other := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	engine := &Engine{Members: fakeMembers{
+		owned:  {user: RoleOwner},
+		joined: {user: RoleUser},
+	}}
+	principal := &auth.Principal{UserID: user}
+	ctx := context.Background()
+
+	if err := engine.Activate(ctx, principal, owned); err != nil {
+		t.Fatal(err)
+	}
+	if !principal.InOrganization(owned) || principal.Role != RoleOwner {
+		t.Errorf("expected owner of the first organization, got %s in %v", principal.Role, principal.OrganizationID)
+	}
+
+	// Being an owner in one organization must not carry over to another
+	if err := engine.Activate(ctx, principal, joined); err != nil {
+		t.Fatal(err)
+	}
+	if principal.Role != RoleUser {
+		t.Errorf("expected user role in the second organization, got %s", principal.Role)
+	}
+
+	if err := engine.Activate(ctx, principal, other); err != ErrNotMember {
+		t.Errorf("expected ErrNotMember, got %v", err)
+	}
+	if principal.OrganizationID.Valid || principal.Role != RoleUser {
+		t.Error("a failed activation should leave the principal outside any organization")
+	}
+
+	if err := engine.Activate(ctx, principal, uuid.Nil); err != nil || principal.OrganizationID.Valid {
+		t.Errorf("expected no active organization, got %v, %v", principal.OrganizationID, err)
+	}
+}
@@ -3,12 +3,15 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
 	Port            string
+	LogFormat       string
+	LogLevel        string
 	DatabaseURL     string
 	ServerTimeout   time.Duration
 	ReadTimeout     time.Duration
@@ -17,12 +20,95 @@ type Config struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+
+	// Token authentication
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Password reset
+	PasswordResetTTL    time.Duration
+	NotificationLogFile string
+
+	// Organization invitations
+	InvitationTTL time.Duration
+
+	// Account self-deletion
+	AccountDeletionGracePeriod   time.Duration
+	AccountDeletionCheckInterval time.Duration
+
+	// Login brute-force protection
+	LoginMaxAttempts      int
+	LoginMaxAttemptsPerIP int
+	LoginAttemptWindow    time.Duration
+	LoginLockoutBase      time.Duration
+	LoginLockoutMax       time.Duration
+	TrustProxyHeaders     bool
+
+	// Accept "Authorization: Basic" with a username and password on protected routes
+	BasicAuthEnabled bool
+
+	// Prometheus metrics, served on the main port unless MetricsPort is set
+	MetricsEnabled bool
+	MetricsPort    string
+
+	// Tracing; the exporter is none, stdout, file or otlp
+	TracingExporter     string
+	TracingFile         string
+	TracingOTLPEndpoint string
+	TracingServiceName  string
+	TracingSampleRatio  float64
+
+	// Rate limiting; limits are requests/period such as 20/1m, or off
+	RateLimitEnabled      bool
+	RateLimitBackend      string
+	RateLimitIP           string
+	RateLimitAuth         string
+	RateLimitRead         string
+	RateLimitWrite        string
+	RateLimitOrganization string
+
+	// Native TLS; certificates are reloaded when the files change or on SIGHUP
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSClientCAFile   string
+	TLSClientAuth     string
+	TLSReloadInterval time.Duration
+
+	// Password hashing (argon2id) and optional pepper keyed by ID
+	PasswordHashTime    int
+	PasswordHashMemory  int
+	PasswordHashThreads int
+	PasswordHashKeyLen  int
+	PasswordPepperID    string
+	PasswordPeppers     map[string]string
+	PasswordPolicy      string
+
+	// Base URL clients use to reach the server, for links such as the device verification page
+	PublicURL string
+
+	// Device authorization grant (RFC 8628)
+	DeviceCodeTTL      time.Duration
+	DeviceCodeInterval time.Duration
+
+	// Global OpenID Connect provider; organizations can override it in their settings
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       []string
+	OIDCRoleClaim    string
+	OIDCRoleMapping  map[string]string
+	OIDCDefaultRole  string
+	OIDCOrgClaim     string
 }
 
 // LoadConfig loads configuration from environment variables with sensible defaults
 func LoadConfig() *Config {
 	return &Config{
 		Port:            getEnvOrDefault("PORT", "8080"),
+		LogFormat:       getEnvOrDefault("LOG_FORMAT", "text"),
+		LogLevel:        getEnvOrDefault("LOG_LEVEL", "info"),
 		DatabaseURL:     getEnvOrDefault("DB_URL", ""),
 		ServerTimeout:   getEnvDurationOrDefault("SERVER_TIMEOUT", 30*time.Second),
 		ReadTimeout:     getEnvDurationOrDefault("READ_TIMEOUT", 10*time.Second),
@@ -31,6 +117,71 @@ func LoadConfig() *Config {
 		MaxOpenConns:    getEnvIntOrDefault("DB_MAX_OPEN_CONNS", 25),
 		MaxIdleConns:    getEnvIntOrDefault("DB_MAX_IDLE_CONNS", 25),
 		ConnMaxLifetime: getEnvDurationOrDefault("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+		JWTSecret:       getEnvOrDefault("JWT_SECRET", ""),
+		AccessTokenTTL:  getEnvDurationOrDefault("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDurationOrDefault("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		PasswordResetTTL:    getEnvDurationOrDefault("PASSWORD_RESET_TTL", time.Hour),
+		NotificationLogFile: getEnvOrDefault("NOTIFICATION_LOG_FILE", "notifications.log"),
+
+		InvitationTTL: getEnvDurationOrDefault("INVITATION_TTL", 7*24*time.Hour),
+
+		AccountDeletionGracePeriod:   getEnvDurationOrDefault("ACCOUNT_DELETION_GRACE_PERIOD", 7*24*time.Hour),
+		AccountDeletionCheckInterval: getEnvDurationOrDefault("ACCOUNT_DELETION_CHECK_INTERVAL", 10*time.Minute),
+
+		LoginMaxAttempts:      getEnvIntOrDefault("LOGIN_MAX_ATTEMPTS", 5),
+		LoginMaxAttemptsPerIP: getEnvIntOrDefault("LOGIN_MAX_ATTEMPTS_PER_IP", 20),
+		LoginAttemptWindow:    getEnvDurationOrDefault("LOGIN_ATTEMPT_WINDOW", 15*time.Minute),
+		LoginLockoutBase:      getEnvDurationOrDefault("LOGIN_LOCKOUT_BASE", time.Minute),
+		LoginLockoutMax:       getEnvDurationOrDefault("LOGIN_LOCKOUT_MAX", time.Hour),
+		TrustProxyHeaders:     getEnvBoolOrDefault("TRUST_PROXY_HEADERS", false),
+		BasicAuthEnabled:      getEnvBoolOrDefault("BASIC_AUTH_ENABLED", false),
+
+		MetricsEnabled: getEnvBoolOrDefault("METRICS_ENABLED", true),
+		MetricsPort:    getEnvOrDefault("METRICS_PORT", ""),
+
+		TracingExporter:     getEnvOrDefault("TRACING_EXPORTER", "none"),
+		TracingFile:         getEnvOrDefault("TRACING_FILE", "traces.jsonl"),
+		TracingOTLPEndpoint: getEnvOrDefault("TRACING_OTLP_ENDPOINT", "http://localhost:4318/v1/traces"),
+		TracingServiceName:  getEnvOrDefault("TRACING_SERVICE_NAME", "go-hello-world"),
+		TracingSampleRatio:  getEnvFloatOrDefault("TRACING_SAMPLE_RATIO", 1),
+
+		RateLimitEnabled:      getEnvBoolOrDefault("RATE_LIMIT_ENABLED", true),
+		RateLimitBackend:      getEnvOrDefault("RATE_LIMIT_BACKEND", "memory"),
+		RateLimitIP:           getEnvOrDefault("RATE_LIMIT_IP", "600/1m"),
+		RateLimitAuth:         getEnvOrDefault("RATE_LIMIT_AUTH", "20/1m"),
+		RateLimitRead:         getEnvOrDefault("RATE_LIMIT_READ", "300/1m"),
+		RateLimitWrite:        getEnvOrDefault("RATE_LIMIT_WRITE", "60/1m"),
+		RateLimitOrganization: getEnvOrDefault("RATE_LIMIT_ORGANIZATION", "1200/1m"),
+
+		TLSCertFile:       getEnvOrDefault("TLS_CERT_FILE", ""),
+		TLSKeyFile:        getEnvOrDefault("TLS_KEY_FILE", ""),
+		TLSClientCAFile:   getEnvOrDefault("TLS_CLIENT_CA_FILE", ""),
+		TLSClientAuth:     getEnvOrDefault("TLS_CLIENT_AUTH", ""),
+		TLSReloadInterval: getEnvDurationOrDefault("TLS_RELOAD_INTERVAL", 30*time.Second),
+
+		PasswordHashTime:    getEnvIntOrDefault("PASSWORD_HASH_TIME", 3),
+		PasswordHashMemory:  getEnvIntOrDefault("PASSWORD_HASH_MEMORY_KB", 64*1024),
+		PasswordHashThreads: getEnvIntOrDefault("PASSWORD_HASH_THREADS", 4),
+		PasswordHashKeyLen:  getEnvIntOrDefault("PASSWORD_HASH_KEY_LEN", 32),
+		PasswordPepperID:    getEnvOrDefault("PASSWORD_PEPPER_ID", ""),
+		PasswordPeppers:     getEnvMapOrDefault("PASSWORD_PEPPERS", nil),
+		PasswordPolicy:      getEnvOrDefault("PASSWORD_POLICY", "standard"),
+
+		PublicURL: getEnvOrDefault("PUBLIC_URL", "http://localhost:8080"),
+
+		DeviceCodeTTL:      getEnvDurationOrDefault("DEVICE_CODE_TTL", 15*time.Minute),
+		DeviceCodeInterval: getEnvDurationOrDefault("DEVICE_CODE_INTERVAL", 5*time.Second),
+
+		OIDCIssuer:       getEnvOrDefault("OIDC_ISSUER", ""),
+		OIDCClientID:     getEnvOrDefault("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret: getEnvOrDefault("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:  getEnvOrDefault("OIDC_REDIRECT_URL", "http://localhost:8080/v1/auth/oidc/callback"),
+		OIDCScopes:       getEnvListOrDefault("OIDC_SCOPES", nil),
+		OIDCRoleClaim:    getEnvOrDefault("OIDC_ROLE_CLAIM", ""),
+		OIDCRoleMapping:  getEnvMapOrDefault("OIDC_ROLE_MAPPING", nil),
+		OIDCDefaultRole:  getEnvOrDefault("OIDC_DEFAULT_ROLE", ""),
+		OIDCOrgClaim:     getEnvOrDefault("OIDC_ORG_CLAIM", ""),
 	}
 }
 
@@ -51,6 +202,57 @@ func getEnvIntOrDefault(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloatOrDefault(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBoolOrDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvMapOrDefault parses a comma-separated list of key:value pairs
+func getEnvMapOrDefault(key string, defaultValue map[string]string) map[string]string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && k != "" {
+			parsed[k] = v
+		}
+	}
+	return parsed
+}
+
+// getEnvListOrDefault parses a comma-separated list
+func getEnvListOrDefault(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var parsed []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			parsed = append(parsed, item)
+		}
+	}
+	return parsed
+}
+
 func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if parsed, err := time.ParseDuration(value); err == nil {
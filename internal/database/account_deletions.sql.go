@@ -0,0 +1,185 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: account_deletions.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const cancelAccountDeletion = `-- name: CancelAccountDeletion :execrows
+DELETE FROM account_deletions
+WHERE user_id = $1
+`
+
+func (q *Queries) CancelAccountDeletion(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cancelAccountDeletion, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAccountDeletion = `-- name: GetAccountDeletion :one
+SELECT user_id, task_action, transfer_to, scheduled_for, requested_at FROM account_deletions
+WHERE user_id = $1
+`
+
+func (q *Queries) GetAccountDeletion(ctx context.Context, userID uuid.UUID) (AccountDeletion, error) {
+	row := q.db.QueryRowContext(ctx, getAccountDeletion, userID)
+	var i AccountDeletion
+	err := row.Scan(
+		&i.UserID,
+		&i.TaskAction,
+		&i.TransferTo,
+		&i.ScheduledFor,
+		&i.RequestedAt,
+	)
+	return i, err
+}
+
+const listDueAccountDeletions = `-- name: ListDueAccountDeletions :many
+SELECT user_id, task_action, transfer_to, scheduled_for, requested_at FROM account_deletions d
+WHERE d.scheduled_for <= NOW()
+  AND NOT EXISTS (
+      SELECT 1 FROM organization_members m
+      WHERE m.user_id = d.user_id AND m.role = 'owner'
+        AND NOT EXISTS (
+            SELECT 1 FROM organization_members other
+            WHERE other.organization_id = m.organization_id AND other.role = 'owner' AND other.user_id <> m.user_id
+        )
+  )
+ORDER BY d.scheduled_for
+LIMIT $1
+`
+
+// Sole owners are skipped so they cannot hold up the accounts scheduled after them
+func (q *Queries) ListDueAccountDeletions(ctx context.Context, limit int32) ([]AccountDeletion, error) {
+	rows, err := q.db.QueryContext(ctx, listDueAccountDeletions, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AccountDeletion
+	for rows.Next() {
+		var i AccountDeletion
+		if err := rows.Scan(
+			&i.UserID,
+			&i.TaskAction,
+			&i.TransferTo,
+			&i.ScheduledFor,
+			&i.RequestedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSoleOwnedOrganizations = `-- name: ListSoleOwnedOrganizations :many
+SELECT organizations.id, organizations.name
+FROM organization_members m
+JOIN organizations ON organizations.id = m.organization_id
+WHERE m.user_id = $1 AND m.role = 'owner'
+  AND NOT EXISTS (
+      SELECT 1 FROM organization_members other
+      WHERE other.organization_id = m.organization_id AND other.role = 'owner' AND other.user_id <> m.user_id
+  )
+ORDER BY organizations.name
+`
+
+type ListSoleOwnedOrganizationsRow struct {
+	ID   uuid.UUID
+	Name string
+}
+
+func (q *Queries) ListSoleOwnedOrganizations(ctx context.Context, userID uuid.UUID) ([]ListSoleOwnedOrganizationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSoleOwnedOrganizations, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSoleOwnedOrganizationsRow
+	for rows.Next() {
+		var i ListSoleOwnedOrganizationsRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const scheduleAccountDeletion = `-- name: ScheduleAccountDeletion :one
+INSERT INTO account_deletions (user_id, task_action, transfer_to, scheduled_for)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET task_action = EXCLUDED.task_action,
+    transfer_to = EXCLUDED.transfer_to,
+    scheduled_for = EXCLUDED.scheduled_for,
+    requested_at = NOW()
+RETURNING user_id, task_action, transfer_to, scheduled_for, requested_at
+`
+
+type ScheduleAccountDeletionParams struct {
+	UserID       uuid.UUID
+	TaskAction   string
+	TransferTo   uuid.NullUUID
+	ScheduledFor time.Time
+}
+
+func (q *Queries) ScheduleAccountDeletion(ctx context.Context, arg ScheduleAccountDeletionParams) (AccountDeletion, error) {
+	row := q.db.QueryRowContext(ctx, scheduleAccountDeletion,
+		arg.UserID,
+		arg.TaskAction,
+		arg.TransferTo,
+		arg.ScheduledFor,
+	)
+	var i AccountDeletion
+	err := row.Scan(
+		&i.UserID,
+		&i.TaskAction,
+		&i.TransferTo,
+		&i.ScheduledFor,
+		&i.RequestedAt,
+	)
+	return i, err
+}
+
+const sharesOrganization = `-- name: SharesOrganization :one
+SELECT EXISTS (
+    SELECT 1 FROM organization_members a
+    JOIN organization_members b ON b.organization_id = a.organization_id
+    WHERE a.user_id = $1 AND b.user_id = $2
+)
+`
+
+type SharesOrganizationParams struct {
+	UserID      uuid.UUID
+	OtherUserID uuid.UUID
+}
+
+func (q *Queries) SharesOrganization(ctx context.Context, arg SharesOrganizationParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, sharesOrganization, arg.UserID, arg.OtherUserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,488 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: admin.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const adminCountOrganizations = `-- name: AdminCountOrganizations :one
+SELECT COUNT(*) FROM organizations o
+WHERE ($1::text IS NULL OR o.name ILIKE '%' || $1::text || '%')
+  AND ($2::boolean OR o.deleted_at IS NULL)
+`
+
+type AdminCountOrganizationsParams struct {
+	Search         sql.NullString
+	IncludeDeleted bool
+}
+
+func (q *Queries) AdminCountOrganizations(ctx context.Context, arg AdminCountOrganizationsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, adminCountOrganizations, arg.Search, arg.IncludeDeleted)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const adminCountUsers = `-- name: AdminCountUsers :one
+SELECT COUNT(*) FROM users u
+WHERE ($1::text IS NULL OR u.username ILIKE '%' || $1::text || '%')
+  AND ($2::text IS NULL OR u.account_type = $2::text)
+  AND ($3::boolean IS NULL OR (u.disabled_at IS NOT NULL) = $3::boolean)
+  AND ($4::uuid IS NULL OR EXISTS (
+      SELECT 1 FROM organization_members m
+      WHERE m.user_id = u.id AND m.organization_id = $4::uuid
+  ))
+`
+
+type AdminCountUsersParams struct {
+	Search         sql.NullString
+	AccountType    sql.NullString
+	Suspended      sql.NullBool
+	OrganizationID uuid.NullUUID
+}
+
+func (q *Queries) AdminCountUsers(ctx context.Context, arg AdminCountUsersParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, adminCountUsers,
+		arg.Search,
+		arg.AccountType,
+		arg.Suspended,
+		arg.OrganizationID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const adminGetOrganization = `-- name: AdminGetOrganization :one
+SELECT id, name, description, settings, created_at, updated_at, deleted_at FROM organizations WHERE id = $1
+`
+
+func (q *Queries) AdminGetOrganization(ctx context.Context, id uuid.UUID) (Organization, error) {
+	row := q.db.QueryRowContext(ctx, adminGetOrganization, id)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Settings,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const adminGetUser = `-- name: AdminGetUser :one
+SELECT id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin FROM users WHERE id = $1
+`
+
+func (q *Queries) AdminGetUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, adminGetUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Age,
+		&i.Gender,
+		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
+	)
+	return i, err
+}
+
+const adminListOrganizations = `-- name: AdminListOrganizations :many
+SELECT o.id, o.name, o.description, o.settings, o.created_at, o.updated_at, o.deleted_at,
+       (SELECT COUNT(*) FROM organization_members m WHERE m.organization_id = o.id) AS member_count
+FROM organizations o
+WHERE ($1::text IS NULL OR o.name ILIKE '%' || $1::text || '%')
+  AND ($2::boolean OR o.deleted_at IS NULL)
+ORDER BY o.created_at DESC, o.id
+LIMIT $4 OFFSET $3
+`
+
+type AdminListOrganizationsParams struct {
+	Search         sql.NullString
+	IncludeDeleted bool
+	PageOffset     int32
+	PageLimit      int32
+}
+
+type AdminListOrganizationsRow struct {
+	Organization Organization
+	MemberCount  int64
+}
+
+func (q *Queries) AdminListOrganizations(ctx context.Context, arg AdminListOrganizationsParams) ([]AdminListOrganizationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, adminListOrganizations,
+		arg.Search,
+		arg.IncludeDeleted,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AdminListOrganizationsRow
+	for rows.Next() {
+		var i AdminListOrganizationsRow
+		if err := rows.Scan(
+			&i.Organization.ID,
+			&i.Organization.Name,
+			&i.Organization.Description,
+			&i.Organization.Settings,
+			&i.Organization.CreatedAt,
+			&i.Organization.UpdatedAt,
+			&i.Organization.DeletedAt,
+			&i.MemberCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const adminListUsers = `-- name: AdminListUsers :many
+SELECT u.id, u.username, u.created_at, u.updated_at, u.password_hash, u.age, u.gender, u.organization_id, u.account_type, u.disabled_at, u.created_by, u.is_system_admin, o.name AS organization_name
+FROM users u
+LEFT JOIN organizations o ON o.id = u.organization_id
+WHERE ($1::text IS NULL OR u.username ILIKE '%' || $1::text || '%')
+  AND ($2::text IS NULL OR u.account_type = $2::text)
+  AND ($3::boolean IS NULL OR (u.disabled_at IS NOT NULL) = $3::boolean)
+  AND ($4::uuid IS NULL OR EXISTS (
+      SELECT 1 FROM organization_members m
+      WHERE m.user_id = u.id AND m.organization_id = $4::uuid
+  ))
+ORDER BY u.created_at DESC, u.id
+LIMIT $6 OFFSET $5
+`
+
+type AdminListUsersParams struct {
+	Search         sql.NullString
+	AccountType    sql.NullString
+	Suspended      sql.NullBool
+	OrganizationID uuid.NullUUID
+	PageOffset     int32
+	PageLimit      int32
+}
+
+type AdminListUsersRow struct {
+	ID               uuid.UUID
+	Username         string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	PasswordHash     string
+	Age              sql.NullInt32
+	Gender           sql.NullString
+	OrganizationID   uuid.NullUUID
+	AccountType      string
+	DisabledAt       sql.NullTime
+	CreatedBy        uuid.NullUUID
+	IsSystemAdmin    bool
+	OrganizationName sql.NullString
+}
+
+func (q *Queries) AdminListUsers(ctx context.Context, arg AdminListUsersParams) ([]AdminListUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, adminListUsers,
+		arg.Search,
+		arg.AccountType,
+		arg.Suspended,
+		arg.OrganizationID,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AdminListUsersRow
+	for rows.Next() {
+		var i AdminListUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PasswordHash,
+			&i.Age,
+			&i.Gender,
+			&i.OrganizationID,
+			&i.AccountType,
+			&i.DisabledAt,
+			&i.CreatedBy,
+			&i.IsSystemAdmin,
+			&i.OrganizationName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countOrganizationDependents = `-- name: CountOrganizationDependents :one
+WITH owned_service_accounts AS (
+    SELECT u.id FROM users u
+    JOIN organization_members m ON m.user_id = u.id
+    WHERE m.organization_id = $1 AND u.account_type = 'service'
+      AND NOT EXISTS (
+          SELECT 1 FROM organization_members other
+          WHERE other.user_id = u.id AND other.organization_id <> $1
+      )
+)
+SELECT
+    (SELECT COUNT(*) FROM organization_members m WHERE m.organization_id = $1) AS members,
+    (SELECT COUNT(*) FROM organization_roles r WHERE r.organization_id = $1) AS custom_roles,
+    (SELECT COUNT(*) FROM organization_invitations i WHERE i.organization_id = $1) AS invitations,
+    (SELECT COUNT(*) FROM organization_ownership_transfers t WHERE t.organization_id = $1) AS ownership_transfers,
+    (SELECT COUNT(*) FROM users u WHERE u.organization_id = $1 AND u.account_type = 'human') AS default_organization_users,
+    (SELECT COUNT(*) FROM owned_service_accounts) AS service_accounts,
+    (SELECT COUNT(*) FROM api_keys k
+     WHERE k.user_id IN (SELECT id FROM owned_service_accounts) AND k.revoked_at IS NULL) AS service_account_keys,
+    (SELECT COUNT(*) FROM client_certificate_mappings c
+     WHERE c.user_id IN (SELECT id FROM owned_service_accounts)) AS service_account_certificates,
+    (SELECT COUNT(*) FROM tasks t
+     WHERE t.user_id IN (SELECT id FROM owned_service_accounts)) AS service_account_tasks
+`
+
+type CountOrganizationDependentsRow struct {
+	Members                    int64
+	CustomRoles                int64
+	Invitations                int64
+	OwnershipTransfers         int64
+	DefaultOrganizationUsers   int64
+	ServiceAccounts            int64
+	ServiceAccountKeys         int64
+	ServiceAccountCertificates int64
+	ServiceAccountTasks        int64
+}
+
+// Service accounts belong to the organization when it is their only membership, whatever users.organization_id says
+func (q *Queries) CountOrganizationDependents(ctx context.Context, id uuid.UUID) (CountOrganizationDependentsRow, error) {
+	row := q.db.QueryRowContext(ctx, countOrganizationDependents, id)
+	var i CountOrganizationDependentsRow
+	err := row.Scan(
+		&i.Members,
+		&i.CustomRoles,
+		&i.Invitations,
+		&i.OwnershipTransfers,
+		&i.DefaultOrganizationUsers,
+		&i.ServiceAccounts,
+		&i.ServiceAccountKeys,
+		&i.ServiceAccountCertificates,
+		&i.ServiceAccountTasks,
+	)
+	return i, err
+}
+
+const deleteOrganizationServiceAccountCertificates = `-- name: DeleteOrganizationServiceAccountCertificates :many
+DELETE FROM client_certificate_mappings
+WHERE user_id IN (
+    SELECT u.id FROM users u
+    JOIN organization_members m ON m.user_id = u.id
+    WHERE m.organization_id = $1 AND u.account_type = 'service'
+      AND NOT EXISTS (
+          SELECT 1 FROM organization_members other
+          WHERE other.user_id = u.id AND other.organization_id <> $1
+      )
+)
+RETURNING id, user_id, match_type, match_value, scopes, created_by, created_at, last_used_at, organization_id
+`
+
+func (q *Queries) DeleteOrganizationServiceAccountCertificates(ctx context.Context, id uuid.UUID) ([]ClientCertificateMapping, error) {
+	rows, err := q.db.QueryContext(ctx, deleteOrganizationServiceAccountCertificates, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClientCertificateMapping
+	for rows.Next() {
+		var i ClientCertificateMapping
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.MatchType,
+			&i.MatchValue,
+			pq.Array(&i.Scopes),
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+			&i.OrganizationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteOrganizationServiceAccountTasks = `-- name: DeleteOrganizationServiceAccountTasks :execrows
+DELETE FROM tasks
+WHERE user_id IN (
+    SELECT u.id FROM users u
+    JOIN organization_members m ON m.user_id = u.id
+    WHERE m.organization_id = $1 AND u.account_type = 'service'
+      AND NOT EXISTS (
+          SELECT 1 FROM organization_members other
+          WHERE other.user_id = u.id AND other.organization_id <> $1
+      )
+)
+`
+
+func (q *Queries) DeleteOrganizationServiceAccountTasks(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOrganizationServiceAccountTasks, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteOrganizationServiceAccounts = `-- name: DeleteOrganizationServiceAccounts :many
+DELETE FROM users
+WHERE account_type = 'service'
+  AND id IN (
+      SELECT m.user_id FROM organization_members m
+      WHERE m.organization_id = $1
+        AND NOT EXISTS (
+            SELECT 1 FROM organization_members other
+            WHERE other.user_id = m.user_id AND other.organization_id <> $1
+        )
+  )
+RETURNING id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin
+`
+
+func (q *Queries) DeleteOrganizationServiceAccounts(ctx context.Context, id uuid.UUID) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, deleteOrganizationServiceAccounts, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PasswordHash,
+			&i.Age,
+			&i.Gender,
+			&i.OrganizationID,
+			&i.AccountType,
+			&i.DisabledAt,
+			&i.CreatedBy,
+			&i.IsSystemAdmin,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isSystemAdmin = `-- name: IsSystemAdmin :one
+
+SELECT is_system_admin FROM users
+WHERE id = $1 AND disabled_at IS NULL
+`
+
+// Queries for system administrators; they see every account and organization, including deleted ones
+func (q *Queries) IsSystemAdmin(ctx context.Context, id uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isSystemAdmin, id)
+	var is_system_admin bool
+	err := row.Scan(&is_system_admin)
+	return is_system_admin, err
+}
+
+const revokeOrganizationServiceAccountKeys = `-- name: RevokeOrganizationServiceAccountKeys :many
+UPDATE api_keys
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE revoked_at IS NULL
+  AND user_id IN (
+      SELECT u.id FROM users u
+      JOIN organization_members m ON m.user_id = u.id
+      WHERE m.organization_id = $1 AND u.account_type = 'service'
+        AND NOT EXISTS (
+            SELECT 1 FROM organization_members other
+            WHERE other.user_id = u.id AND other.organization_id <> $1
+        )
+  )
+RETURNING id, user_id, name, scopes, expires_at, last_used_at, revoked_at, created_at, updated_at, key_prefix, key_hash, rotate_by
+`
+
+func (q *Queries) RevokeOrganizationServiceAccountKeys(ctx context.Context, id uuid.UUID) ([]ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, revokeOrganizationServiceAccountKeys, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			pq.Array(&i.Scopes),
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.KeyPrefix,
+			&i.KeyHash,
+			&i.RotateBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
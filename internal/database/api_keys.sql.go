@@ -0,0 +1,289 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: api_keys.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, user_id, name, key_prefix, key_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, name, scopes, expires_at, last_used_at, revoked_at, created_at, updated_at, key_prefix, key_hash, rotate_by
+`
+
+type CreateAPIKeyParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	KeyPrefix string
+	KeyHash   string
+	Scopes    []string
+	ExpiresAt sql.NullTime
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, createAPIKey,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		arg.KeyPrefix,
+		arg.KeyHash,
+		pq.Array(arg.Scopes),
+		arg.ExpiresAt,
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.KeyPrefix,
+		&i.KeyHash,
+		&i.RotateBy,
+	)
+	return i, err
+}
+
+const getAPIKeyByID = `-- name: GetAPIKeyByID :one
+SELECT id, user_id, name, scopes, expires_at, last_used_at, revoked_at, created_at, updated_at, key_prefix, key_hash, rotate_by FROM api_keys
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+`
+
+type GetAPIKeyByIDParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetAPIKeyByID(ctx context.Context, arg GetAPIKeyByIDParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, getAPIKeyByID, arg.ID, arg.UserID)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.KeyPrefix,
+		&i.KeyHash,
+		&i.RotateBy,
+	)
+	return i, err
+}
+
+const getAPIKeysByUser = `-- name: GetAPIKeysByUser :many
+SELECT id, user_id, name, scopes, expires_at, last_used_at, revoked_at, created_at, updated_at, key_prefix, key_hash, rotate_by FROM api_keys
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetAPIKeysByUser(ctx context.Context, userID uuid.UUID) ([]ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, getAPIKeysByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			pq.Array(&i.Scopes),
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.KeyPrefix,
+			&i.KeyHash,
+			&i.RotateBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActiveAPIKey = `-- name: GetActiveAPIKey :one
+SELECT api_keys.id, api_keys.user_id, api_keys.name, api_keys.scopes, api_keys.expires_at, api_keys.last_used_at, api_keys.revoked_at, api_keys.created_at, api_keys.updated_at, api_keys.key_prefix, api_keys.key_hash, api_keys.rotate_by, users.username, users.organization_id, users.account_type
+FROM api_keys
+JOIN users ON users.id = api_keys.user_id
+WHERE api_keys.key_hash = $1
+  AND api_keys.revoked_at IS NULL
+  AND (api_keys.expires_at IS NULL OR api_keys.expires_at > NOW())
+  AND (api_keys.rotate_by IS NULL OR api_keys.rotate_by > NOW())
+  AND users.disabled_at IS NULL
+`
+
+type GetActiveAPIKeyRow struct {
+	ApiKey         ApiKey
+	Username       string
+	OrganizationID uuid.NullUUID
+	AccountType    string
+}
+
+func (q *Queries) GetActiveAPIKey(ctx context.Context, keyHash string) (GetActiveAPIKeyRow, error) {
+	row := q.db.QueryRowContext(ctx, getActiveAPIKey, keyHash)
+	var i GetActiveAPIKeyRow
+	err := row.Scan(
+		&i.ApiKey.ID,
+		&i.ApiKey.UserID,
+		&i.ApiKey.Name,
+		pq.Array(&i.ApiKey.Scopes),
+		&i.ApiKey.ExpiresAt,
+		&i.ApiKey.LastUsedAt,
+		&i.ApiKey.RevokedAt,
+		&i.ApiKey.CreatedAt,
+		&i.ApiKey.UpdatedAt,
+		&i.ApiKey.KeyPrefix,
+		&i.ApiKey.KeyHash,
+		&i.ApiKey.RotateBy,
+		&i.Username,
+		&i.OrganizationID,
+		&i.AccountType,
+	)
+	return i, err
+}
+
+const requireUserAPIKeyRotation = `-- name: RequireUserAPIKeyRotation :execrows
+UPDATE api_keys
+SET rotate_by = LEAST(COALESCE(rotate_by, $1), $1), updated_at = NOW()
+WHERE user_id = $2 AND revoked_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+`
+
+type RequireUserAPIKeyRotationParams struct {
+	RotateBy sql.NullTime
+	UserID   uuid.UUID
+}
+
+func (q *Queries) RequireUserAPIKeyRotation(ctx context.Context, arg RequireUserAPIKeyRotationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, requireUserAPIKeyRotation, arg.RotateBy, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING id, user_id, name, scopes, expires_at, last_used_at, revoked_at, created_at, updated_at, key_prefix, key_hash, rotate_by
+`
+
+type RevokeAPIKeyParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, revokeAPIKey, arg.ID, arg.UserID)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.KeyPrefix,
+		&i.KeyHash,
+		&i.RotateBy,
+	)
+	return i, err
+}
+
+const revokeOtherUserAPIKeys = `-- name: RevokeOtherUserAPIKeys :exec
+UPDATE api_keys
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL
+`
+
+type RevokeOtherUserAPIKeysParams struct {
+	UserID uuid.UUID
+	ID     uuid.UUID
+}
+
+func (q *Queries) RevokeOtherUserAPIKeys(ctx context.Context, arg RevokeOtherUserAPIKeysParams) error {
+	_, err := q.db.ExecContext(ctx, revokeOtherUserAPIKeys, arg.UserID, arg.ID)
+	return err
+}
+
+const rotateAPIKey = `-- name: RotateAPIKey :one
+UPDATE api_keys
+SET key_prefix = $3, key_hash = $4, rotate_by = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING id, user_id, name, scopes, expires_at, last_used_at, revoked_at, created_at, updated_at, key_prefix, key_hash, rotate_by
+`
+
+type RotateAPIKeyParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	KeyPrefix string
+	KeyHash   string
+}
+
+func (q *Queries) RotateAPIKey(ctx context.Context, arg RotateAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, rotateAPIKey,
+		arg.ID,
+		arg.UserID,
+		arg.KeyPrefix,
+		arg.KeyHash,
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.KeyPrefix,
+		&i.KeyHash,
+		&i.RotateBy,
+	)
+	return i, err
+}
+
+const touchAPIKeyLastUsed = `-- name: TouchAPIKeyLastUsed :exec
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TouchAPIKeyLastUsed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, touchAPIKeyLastUsed, id)
+	return err
+}
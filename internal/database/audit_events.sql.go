@@ -0,0 +1,237 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: audit_events.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+const countAuditEvents = `-- name: CountAuditEvents :one
+SELECT COUNT(*) FROM audit_events
+WHERE ($1::uuid IS NULL OR organization_id = $1::uuid)
+  AND ($2::uuid IS NULL OR actor_id = $2::uuid)
+  AND ($3::text IS NULL OR action = $3::text OR action LIKE $3::text || '.%')
+  AND ($4::text IS NULL OR target_type = $4::text)
+  AND ($5::uuid IS NULL OR target_id = $5::uuid)
+  AND ($6::timestamp IS NULL OR created_at >= $6::timestamp)
+  AND ($7::timestamp IS NULL OR created_at < $7::timestamp)
+`
+
+type CountAuditEventsParams struct {
+	OrganizationID uuid.NullUUID
+	ActorID        uuid.NullUUID
+	Action         sql.NullString
+	TargetType     sql.NullString
+	TargetID       uuid.NullUUID
+	Since          sql.NullTime
+	Until          sql.NullTime
+}
+
+func (q *Queries) CountAuditEvents(ctx context.Context, arg CountAuditEventsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAuditEvents,
+		arg.OrganizationID,
+		arg.ActorID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Since,
+		arg.Until,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAuditEvent = `-- name: CreateAuditEvent :exec
+
+INSERT INTO audit_events (
+    id, organization_id, actor_id, actor_username, auth_method, action, target_type, target_id, changes, ip_address, request_id
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+`
+
+type CreateAuditEventParams struct {
+	ID             uuid.UUID
+	OrganizationID uuid.NullUUID
+	ActorID        uuid.NullUUID
+	ActorUsername  sql.NullString
+	AuthMethod     sql.NullString
+	Action         string
+	TargetType     string
+	TargetID       uuid.NullUUID
+	Changes        pqtype.NullRawMessage
+	IpAddress      sql.NullString
+	RequestID      sql.NullString
+}
+
+// Audit events are only ever inserted; filters left NULL match every event
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error {
+	_, err := q.db.ExecContext(ctx, createAuditEvent,
+		arg.ID,
+		arg.OrganizationID,
+		arg.ActorID,
+		arg.ActorUsername,
+		arg.AuthMethod,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Changes,
+		arg.IpAddress,
+		arg.RequestID,
+	)
+	return err
+}
+
+const exportAuditEvents = `-- name: ExportAuditEvents :many
+SELECT id, organization_id, actor_id, actor_username, auth_method, action, target_type, target_id, changes, ip_address, request_id, created_at FROM audit_events
+WHERE (created_at, id) > ($1::timestamp, $2::uuid)
+  AND ($3::uuid IS NULL OR organization_id = $3::uuid)
+  AND ($4::uuid IS NULL OR actor_id = $4::uuid)
+  AND ($5::text IS NULL OR action = $5::text OR action LIKE $5::text || '.%')
+  AND ($6::text IS NULL OR target_type = $6::text)
+  AND ($7::uuid IS NULL OR target_id = $7::uuid)
+  AND ($8::timestamp IS NULL OR created_at >= $8::timestamp)
+  AND ($9::timestamp IS NULL OR created_at < $9::timestamp)
+ORDER BY created_at, id
+LIMIT $10
+`
+
+type ExportAuditEventsParams struct {
+	AfterCreatedAt time.Time
+	AfterID        uuid.UUID
+	OrganizationID uuid.NullUUID
+	ActorID        uuid.NullUUID
+	Action         sql.NullString
+	TargetType     sql.NullString
+	TargetID       uuid.NullUUID
+	Since          sql.NullTime
+	Until          sql.NullTime
+	PageLimit      int32
+}
+
+// Oldest first, continuing after the last event of the previous batch
+func (q *Queries) ExportAuditEvents(ctx context.Context, arg ExportAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, exportAuditEvents,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.OrganizationID,
+		arg.ActorID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Since,
+		arg.Until,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.ActorID,
+			&i.ActorUsername,
+			&i.AuthMethod,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Changes,
+			&i.IpAddress,
+			&i.RequestID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, organization_id, actor_id, actor_username, auth_method, action, target_type, target_id, changes, ip_address, request_id, created_at FROM audit_events
+WHERE ($1::uuid IS NULL OR organization_id = $1::uuid)
+  AND ($2::uuid IS NULL OR actor_id = $2::uuid)
+  AND ($3::text IS NULL OR action = $3::text OR action LIKE $3::text || '.%')
+  AND ($4::text IS NULL OR target_type = $4::text)
+  AND ($5::uuid IS NULL OR target_id = $5::uuid)
+  AND ($6::timestamp IS NULL OR created_at >= $6::timestamp)
+  AND ($7::timestamp IS NULL OR created_at < $7::timestamp)
+ORDER BY created_at DESC, id DESC
+LIMIT $9 OFFSET $8
+`
+
+type ListAuditEventsParams struct {
+	OrganizationID uuid.NullUUID
+	ActorID        uuid.NullUUID
+	Action         sql.NullString
+	TargetType     sql.NullString
+	TargetID       uuid.NullUUID
+	Since          sql.NullTime
+	Until          sql.NullTime
+	PageOffset     int32
+	PageLimit      int32
+}
+
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEvents,
+		arg.OrganizationID,
+		arg.ActorID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Since,
+		arg.Until,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.ActorID,
+			&i.ActorUsername,
+			&i.AuthMethod,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Changes,
+			&i.IpAddress,
+			&i.RequestID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
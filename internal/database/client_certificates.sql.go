@@ -0,0 +1,176 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: client_certificates.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createClientCertificateMapping = `-- name: CreateClientCertificateMapping :one
+INSERT INTO client_certificate_mappings (id, user_id, organization_id, match_type, match_value, scopes, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, match_type, match_value, scopes, created_by, created_at, last_used_at, organization_id
+`
+
+type CreateClientCertificateMappingParams struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	OrganizationID uuid.UUID
+	MatchType      string
+	MatchValue     string
+	Scopes         []string
+	CreatedBy      uuid.NullUUID
+}
+
+func (q *Queries) CreateClientCertificateMapping(ctx context.Context, arg CreateClientCertificateMappingParams) (ClientCertificateMapping, error) {
+	row := q.db.QueryRowContext(ctx, createClientCertificateMapping,
+		arg.ID,
+		arg.UserID,
+		arg.OrganizationID,
+		arg.MatchType,
+		arg.MatchValue,
+		pq.Array(arg.Scopes),
+		arg.CreatedBy,
+	)
+	var i ClientCertificateMapping
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.MatchType,
+		&i.MatchValue,
+		pq.Array(&i.Scopes),
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.OrganizationID,
+	)
+	return i, err
+}
+
+const deleteClientCertificateMapping = `-- name: DeleteClientCertificateMapping :execrows
+DELETE FROM client_certificate_mappings
+WHERE id = $1 AND organization_id = $2
+`
+
+type DeleteClientCertificateMappingParams struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+}
+
+func (q *Queries) DeleteClientCertificateMapping(ctx context.Context, arg DeleteClientCertificateMappingParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteClientCertificateMapping, arg.ID, arg.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getActiveClientCertificateMapping = `-- name: GetActiveClientCertificateMapping :one
+SELECT client_certificate_mappings.id, client_certificate_mappings.user_id, client_certificate_mappings.match_type, client_certificate_mappings.match_value, client_certificate_mappings.scopes, client_certificate_mappings.created_by, client_certificate_mappings.created_at, client_certificate_mappings.last_used_at, client_certificate_mappings.organization_id, users.username, users.organization_id, users.account_type
+FROM client_certificate_mappings
+JOIN users ON users.id = client_certificate_mappings.user_id
+WHERE client_certificate_mappings.match_type = $1
+  AND client_certificate_mappings.match_value = $2
+  AND users.disabled_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM organization_members
+      WHERE organization_members.user_id = client_certificate_mappings.user_id
+        AND organization_members.organization_id = client_certificate_mappings.organization_id
+  )
+`
+
+type GetActiveClientCertificateMappingParams struct {
+	MatchType  string
+	MatchValue string
+}
+
+type GetActiveClientCertificateMappingRow struct {
+	ClientCertificateMapping ClientCertificateMapping
+	Username                 string
+	OrganizationID           uuid.NullUUID
+	AccountType              string
+}
+
+func (q *Queries) GetActiveClientCertificateMapping(ctx context.Context, arg GetActiveClientCertificateMappingParams) (GetActiveClientCertificateMappingRow, error) {
+	row := q.db.QueryRowContext(ctx, getActiveClientCertificateMapping, arg.MatchType, arg.MatchValue)
+	var i GetActiveClientCertificateMappingRow
+	err := row.Scan(
+		&i.ClientCertificateMapping.ID,
+		&i.ClientCertificateMapping.UserID,
+		&i.ClientCertificateMapping.MatchType,
+		&i.ClientCertificateMapping.MatchValue,
+		pq.Array(&i.ClientCertificateMapping.Scopes),
+		&i.ClientCertificateMapping.CreatedBy,
+		&i.ClientCertificateMapping.CreatedAt,
+		&i.ClientCertificateMapping.LastUsedAt,
+		&i.ClientCertificateMapping.OrganizationID,
+		&i.Username,
+		&i.OrganizationID,
+		&i.AccountType,
+	)
+	return i, err
+}
+
+const listOrganizationClientCertificateMappings = `-- name: ListOrganizationClientCertificateMappings :many
+SELECT client_certificate_mappings.id, client_certificate_mappings.user_id, client_certificate_mappings.match_type, client_certificate_mappings.match_value, client_certificate_mappings.scopes, client_certificate_mappings.created_by, client_certificate_mappings.created_at, client_certificate_mappings.last_used_at, client_certificate_mappings.organization_id, users.username
+FROM client_certificate_mappings
+JOIN users ON users.id = client_certificate_mappings.user_id
+WHERE client_certificate_mappings.organization_id = $1
+ORDER BY users.username, client_certificate_mappings.created_at
+`
+
+type ListOrganizationClientCertificateMappingsRow struct {
+	ClientCertificateMapping ClientCertificateMapping
+	Username                 string
+}
+
+func (q *Queries) ListOrganizationClientCertificateMappings(ctx context.Context, organizationID uuid.UUID) ([]ListOrganizationClientCertificateMappingsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrganizationClientCertificateMappings, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrganizationClientCertificateMappingsRow
+	for rows.Next() {
+		var i ListOrganizationClientCertificateMappingsRow
+		if err := rows.Scan(
+			&i.ClientCertificateMapping.ID,
+			&i.ClientCertificateMapping.UserID,
+			&i.ClientCertificateMapping.MatchType,
+			&i.ClientCertificateMapping.MatchValue,
+			pq.Array(&i.ClientCertificateMapping.Scopes),
+			&i.ClientCertificateMapping.CreatedBy,
+			&i.ClientCertificateMapping.CreatedAt,
+			&i.ClientCertificateMapping.LastUsedAt,
+			&i.ClientCertificateMapping.OrganizationID,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchClientCertificateMapping = `-- name: TouchClientCertificateMapping :exec
+UPDATE client_certificate_mappings
+SET last_used_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TouchClientCertificateMapping(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, touchClientCertificateMapping, id)
+	return err
+}
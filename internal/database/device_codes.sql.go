@@ -0,0 +1,228 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: device_codes.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const approveDeviceCode = `-- name: ApproveDeviceCode :one
+UPDATE device_codes
+SET status = 'approved', user_id = $2
+WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+RETURNING id, device_code_hash, user_code, client_id, status, user_id, poll_interval, last_polled_at, expires_at, created_at
+`
+
+type ApproveDeviceCodeParams struct {
+	ID     uuid.UUID
+	UserID uuid.NullUUID
+}
+
+func (q *Queries) ApproveDeviceCode(ctx context.Context, arg ApproveDeviceCodeParams) (DeviceCode, error) {
+	row := q.db.QueryRowContext(ctx, approveDeviceCode, arg.ID, arg.UserID)
+	var i DeviceCode
+	err := row.Scan(
+		&i.ID,
+		&i.DeviceCodeHash,
+		&i.UserCode,
+		&i.ClientID,
+		&i.Status,
+		&i.UserID,
+		&i.PollInterval,
+		&i.LastPolledAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const consumeDeviceCode = `-- name: ConsumeDeviceCode :one
+UPDATE device_codes
+SET status = 'consumed'
+WHERE id = $1 AND status = 'approved'
+RETURNING id, device_code_hash, user_code, client_id, status, user_id, poll_interval, last_polled_at, expires_at, created_at
+`
+
+func (q *Queries) ConsumeDeviceCode(ctx context.Context, id uuid.UUID) (DeviceCode, error) {
+	row := q.db.QueryRowContext(ctx, consumeDeviceCode, id)
+	var i DeviceCode
+	err := row.Scan(
+		&i.ID,
+		&i.DeviceCodeHash,
+		&i.UserCode,
+		&i.ClientID,
+		&i.Status,
+		&i.UserID,
+		&i.PollInterval,
+		&i.LastPolledAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createDeviceCode = `-- name: CreateDeviceCode :one
+INSERT INTO device_codes (id, device_code_hash, user_code, client_id, poll_interval, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, device_code_hash, user_code, client_id, status, user_id, poll_interval, last_polled_at, expires_at, created_at
+`
+
+type CreateDeviceCodeParams struct {
+	ID             uuid.UUID
+	DeviceCodeHash string
+	UserCode       string
+	ClientID       string
+	PollInterval   int32
+	ExpiresAt      time.Time
+}
+
+func (q *Queries) CreateDeviceCode(ctx context.Context, arg CreateDeviceCodeParams) (DeviceCode, error) {
+	row := q.db.QueryRowContext(ctx, createDeviceCode,
+		arg.ID,
+		arg.DeviceCodeHash,
+		arg.UserCode,
+		arg.ClientID,
+		arg.PollInterval,
+		arg.ExpiresAt,
+	)
+	var i DeviceCode
+	err := row.Scan(
+		&i.ID,
+		&i.DeviceCodeHash,
+		&i.UserCode,
+		&i.ClientID,
+		&i.Status,
+		&i.UserID,
+		&i.PollInterval,
+		&i.LastPolledAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteExpiredDeviceCodes = `-- name: DeleteExpiredDeviceCodes :exec
+DELETE FROM device_codes WHERE expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredDeviceCodes(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredDeviceCodes)
+	return err
+}
+
+const denyDeviceCode = `-- name: DenyDeviceCode :one
+UPDATE device_codes
+SET status = 'denied'
+WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+RETURNING id, device_code_hash, user_code, client_id, status, user_id, poll_interval, last_polled_at, expires_at, created_at
+`
+
+func (q *Queries) DenyDeviceCode(ctx context.Context, id uuid.UUID) (DeviceCode, error) {
+	row := q.db.QueryRowContext(ctx, denyDeviceCode, id)
+	var i DeviceCode
+	err := row.Scan(
+		&i.ID,
+		&i.DeviceCodeHash,
+		&i.UserCode,
+		&i.ClientID,
+		&i.Status,
+		&i.UserID,
+		&i.PollInterval,
+		&i.LastPolledAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getDeviceCodeByHash = `-- name: GetDeviceCodeByHash :one
+SELECT id, device_code_hash, user_code, client_id, status, user_id, poll_interval, last_polled_at, expires_at, created_at FROM device_codes WHERE device_code_hash = $1
+`
+
+func (q *Queries) GetDeviceCodeByHash(ctx context.Context, deviceCodeHash string) (DeviceCode, error) {
+	row := q.db.QueryRowContext(ctx, getDeviceCodeByHash, deviceCodeHash)
+	var i DeviceCode
+	err := row.Scan(
+		&i.ID,
+		&i.DeviceCodeHash,
+		&i.UserCode,
+		&i.ClientID,
+		&i.Status,
+		&i.UserID,
+		&i.PollInterval,
+		&i.LastPolledAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPendingDeviceCodeByID = `-- name: GetPendingDeviceCodeByID :one
+SELECT id, device_code_hash, user_code, client_id, status, user_id, poll_interval, last_polled_at, expires_at, created_at FROM device_codes
+WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+`
+
+func (q *Queries) GetPendingDeviceCodeByID(ctx context.Context, id uuid.UUID) (DeviceCode, error) {
+	row := q.db.QueryRowContext(ctx, getPendingDeviceCodeByID, id)
+	var i DeviceCode
+	err := row.Scan(
+		&i.ID,
+		&i.DeviceCodeHash,
+		&i.UserCode,
+		&i.ClientID,
+		&i.Status,
+		&i.UserID,
+		&i.PollInterval,
+		&i.LastPolledAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPendingDeviceCodeByUserCode = `-- name: GetPendingDeviceCodeByUserCode :one
+SELECT id, device_code_hash, user_code, client_id, status, user_id, poll_interval, last_polled_at, expires_at, created_at FROM device_codes
+WHERE user_code = $1 AND status = 'pending' AND expires_at > NOW()
+`
+
+func (q *Queries) GetPendingDeviceCodeByUserCode(ctx context.Context, userCode string) (DeviceCode, error) {
+	row := q.db.QueryRowContext(ctx, getPendingDeviceCodeByUserCode, userCode)
+	var i DeviceCode
+	err := row.Scan(
+		&i.ID,
+		&i.DeviceCodeHash,
+		&i.UserCode,
+		&i.ClientID,
+		&i.Status,
+		&i.UserID,
+		&i.PollInterval,
+		&i.LastPolledAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const recordDeviceCodePoll = `-- name: RecordDeviceCodePoll :exec
+UPDATE device_codes
+SET last_polled_at = $2, poll_interval = $3
+WHERE id = $1
+`
+
+type RecordDeviceCodePollParams struct {
+	ID           uuid.UUID
+	LastPolledAt sql.NullTime
+	PollInterval int32
+}
+
+func (q *Queries) RecordDeviceCodePoll(ctx context.Context, arg RecordDeviceCodePollParams) error {
+	_, err := q.db.ExecContext(ctx, recordDeviceCodePoll, arg.ID, arg.LastPolledAt, arg.PollInterval)
+	return err
+}
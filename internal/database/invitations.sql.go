@@ -0,0 +1,246 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: invitations.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const acceptInvitation = `-- name: AcceptInvitation :one
+UPDATE organization_invitations
+SET accepted_at = NOW(), responded_by = $2
+WHERE id = $1
+  AND accepted_at IS NULL AND declined_at IS NULL AND revoked_at IS NULL
+  AND expires_at > NOW()
+RETURNING id, organization_id, token_hash, role, username, email, invited_by, expires_at, accepted_at, declined_at, revoked_at, responded_by, created_at
+`
+
+type AcceptInvitationParams struct {
+	ID          uuid.UUID
+	RespondedBy uuid.NullUUID
+}
+
+func (q *Queries) AcceptInvitation(ctx context.Context, arg AcceptInvitationParams) (OrganizationInvitation, error) {
+	row := q.db.QueryRowContext(ctx, acceptInvitation, arg.ID, arg.RespondedBy)
+	var i OrganizationInvitation
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.TokenHash,
+		&i.Role,
+		&i.Username,
+		&i.Email,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.DeclinedAt,
+		&i.RevokedAt,
+		&i.RespondedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createOrganizationInvitation = `-- name: CreateOrganizationInvitation :one
+INSERT INTO organization_invitations (id, organization_id, token_hash, role, username, email, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, organization_id, token_hash, role, username, email, invited_by, expires_at, accepted_at, declined_at, revoked_at, responded_by, created_at
+`
+
+type CreateOrganizationInvitationParams struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	TokenHash      string
+	Role           string
+	Username       sql.NullString
+	Email          sql.NullString
+	InvitedBy      uuid.NullUUID
+	ExpiresAt      time.Time
+}
+
+func (q *Queries) CreateOrganizationInvitation(ctx context.Context, arg CreateOrganizationInvitationParams) (OrganizationInvitation, error) {
+	row := q.db.QueryRowContext(ctx, createOrganizationInvitation,
+		arg.ID,
+		arg.OrganizationID,
+		arg.TokenHash,
+		arg.Role,
+		arg.Username,
+		arg.Email,
+		arg.InvitedBy,
+		arg.ExpiresAt,
+	)
+	var i OrganizationInvitation
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.TokenHash,
+		&i.Role,
+		&i.Username,
+		&i.Email,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.DeclinedAt,
+		&i.RevokedAt,
+		&i.RespondedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const declineInvitation = `-- name: DeclineInvitation :one
+UPDATE organization_invitations
+SET declined_at = NOW(), responded_by = $2
+WHERE id = $1
+  AND accepted_at IS NULL AND declined_at IS NULL AND revoked_at IS NULL
+  AND expires_at > NOW()
+RETURNING id, organization_id, token_hash, role, username, email, invited_by, expires_at, accepted_at, declined_at, revoked_at, responded_by, created_at
+`
+
+type DeclineInvitationParams struct {
+	ID          uuid.UUID
+	RespondedBy uuid.NullUUID
+}
+
+func (q *Queries) DeclineInvitation(ctx context.Context, arg DeclineInvitationParams) (OrganizationInvitation, error) {
+	row := q.db.QueryRowContext(ctx, declineInvitation, arg.ID, arg.RespondedBy)
+	var i OrganizationInvitation
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.TokenHash,
+		&i.Role,
+		&i.Username,
+		&i.Email,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.DeclinedAt,
+		&i.RevokedAt,
+		&i.RespondedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPendingInvitationByToken = `-- name: GetPendingInvitationByToken :one
+SELECT id, organization_id, token_hash, role, username, email, invited_by, expires_at, accepted_at, declined_at, revoked_at, responded_by, created_at FROM organization_invitations
+WHERE token_hash = $1 AND organization_id = $2
+  AND accepted_at IS NULL AND declined_at IS NULL AND revoked_at IS NULL
+  AND expires_at > NOW()
+`
+
+type GetPendingInvitationByTokenParams struct {
+	TokenHash      string
+	OrganizationID uuid.UUID
+}
+
+func (q *Queries) GetPendingInvitationByToken(ctx context.Context, arg GetPendingInvitationByTokenParams) (OrganizationInvitation, error) {
+	row := q.db.QueryRowContext(ctx, getPendingInvitationByToken, arg.TokenHash, arg.OrganizationID)
+	var i OrganizationInvitation
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.TokenHash,
+		&i.Role,
+		&i.Username,
+		&i.Email,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.DeclinedAt,
+		&i.RevokedAt,
+		&i.RespondedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listOrganizationInvitations = `-- name: ListOrganizationInvitations :many
+SELECT id, organization_id, token_hash, role, username, email, invited_by, expires_at, accepted_at, declined_at, revoked_at, responded_by, created_at FROM organization_invitations
+WHERE organization_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListOrganizationInvitations(ctx context.Context, organizationID uuid.UUID) ([]OrganizationInvitation, error) {
+	rows, err := q.db.QueryContext(ctx, listOrganizationInvitations, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrganizationInvitation
+	for rows.Next() {
+		var i OrganizationInvitation
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.TokenHash,
+			&i.Role,
+			&i.Username,
+			&i.Email,
+			&i.InvitedBy,
+			&i.ExpiresAt,
+			&i.AcceptedAt,
+			&i.DeclinedAt,
+			&i.RevokedAt,
+			&i.RespondedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeInvitation = `-- name: RevokeInvitation :execrows
+UPDATE organization_invitations
+SET revoked_at = NOW()
+WHERE id = $1 AND organization_id = $2
+  AND accepted_at IS NULL AND declined_at IS NULL AND revoked_at IS NULL
+`
+
+type RevokeInvitationParams struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+}
+
+func (q *Queries) RevokeInvitation(ctx context.Context, arg RevokeInvitationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeInvitation, arg.ID, arg.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const userHasIdentityEmail = `-- name: UserHasIdentityEmail :one
+SELECT EXISTS (
+    SELECT 1 FROM user_identities
+    WHERE user_id = $1 AND LOWER(email) = LOWER($2::text)
+)
+`
+
+type UserHasIdentityEmailParams struct {
+	UserID uuid.UUID
+	Email  string
+}
+
+func (q *Queries) UserHasIdentityEmail(ctx context.Context, arg UserHasIdentityEmailParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, userHasIdentityEmail, arg.UserID, arg.Email)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,128 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: login_attempts.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const clearLoginAttempts = `-- name: ClearLoginAttempts :exec
+DELETE FROM login_attempts WHERE scope = $1 AND key = $2
+`
+
+type ClearLoginAttemptsParams struct {
+	Scope string
+	Key   string
+}
+
+func (q *Queries) ClearLoginAttempts(ctx context.Context, arg ClearLoginAttemptsParams) error {
+	_, err := q.db.ExecContext(ctx, clearLoginAttempts, arg.Scope, arg.Key)
+	return err
+}
+
+const createAuthEvent = `-- name: CreateAuthEvent :exec
+INSERT INTO auth_events (id, user_id, username, event_type, ip_address, detail)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateAuthEventParams struct {
+	ID        uuid.UUID
+	UserID    uuid.NullUUID
+	Username  sql.NullString
+	EventType string
+	IpAddress sql.NullString
+	Detail    sql.NullString
+}
+
+func (q *Queries) CreateAuthEvent(ctx context.Context, arg CreateAuthEventParams) error {
+	_, err := q.db.ExecContext(ctx, createAuthEvent,
+		arg.ID,
+		arg.UserID,
+		arg.Username,
+		arg.EventType,
+		arg.IpAddress,
+		arg.Detail,
+	)
+	return err
+}
+
+const getLoginAttempt = `-- name: GetLoginAttempt :one
+SELECT scope, key, failed_count, last_failed_at, locked_until FROM login_attempts WHERE scope = $1 AND key = $2
+`
+
+type GetLoginAttemptParams struct {
+	Scope string
+	Key   string
+}
+
+func (q *Queries) GetLoginAttempt(ctx context.Context, arg GetLoginAttemptParams) (LoginAttempt, error) {
+	row := q.db.QueryRowContext(ctx, getLoginAttempt, arg.Scope, arg.Key)
+	var i LoginAttempt
+	err := row.Scan(
+		&i.Scope,
+		&i.Key,
+		&i.FailedCount,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const lockLogin = `-- name: LockLogin :exec
+UPDATE login_attempts SET locked_until = $3 WHERE scope = $1 AND key = $2
+`
+
+type LockLoginParams struct {
+	Scope       string
+	Key         string
+	LockedUntil sql.NullTime
+}
+
+func (q *Queries) LockLogin(ctx context.Context, arg LockLoginParams) error {
+	_, err := q.db.ExecContext(ctx, lockLogin, arg.Scope, arg.Key, arg.LockedUntil)
+	return err
+}
+
+const recordFailedLogin = `-- name: RecordFailedLogin :one
+INSERT INTO login_attempts (scope, key, failed_count, last_failed_at)
+VALUES ($1, $2, 1, $3)
+ON CONFLICT (scope, key) DO UPDATE
+SET failed_count = CASE
+        WHEN login_attempts.last_failed_at < $4 THEN 1
+        ELSE login_attempts.failed_count + 1
+    END,
+    last_failed_at = EXCLUDED.last_failed_at
+RETURNING scope, key, failed_count, last_failed_at, locked_until
+`
+
+type RecordFailedLoginParams struct {
+	Scope       string
+	Key         string
+	FailedAt    time.Time
+	WindowStart time.Time
+}
+
+func (q *Queries) RecordFailedLogin(ctx context.Context, arg RecordFailedLoginParams) (LoginAttempt, error) {
+	row := q.db.QueryRowContext(ctx, recordFailedLogin,
+		arg.Scope,
+		arg.Key,
+		arg.FailedAt,
+		arg.WindowStart,
+	)
+	var i LoginAttempt
+	err := row.Scan(
+		&i.Scope,
+		&i.Key,
+		&i.FailedCount,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
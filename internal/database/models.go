@@ -12,6 +12,97 @@ import (
 	"github.com/sqlc-dev/pqtype"
 )
 
+type AccountDeletion struct {
+	UserID       uuid.UUID
+	TaskAction   string
+	TransferTo   uuid.NullUUID
+	ScheduledFor time.Time
+	RequestedAt  time.Time
+}
+
+type ApiKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Scopes     []string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	KeyPrefix  string
+	KeyHash    string
+	RotateBy   sql.NullTime
+}
+
+type AuditEvent struct {
+	ID             uuid.UUID
+	OrganizationID uuid.NullUUID
+	ActorID        uuid.NullUUID
+	ActorUsername  sql.NullString
+	AuthMethod     sql.NullString
+	Action         string
+	TargetType     string
+	TargetID       uuid.NullUUID
+	Changes        pqtype.NullRawMessage
+	IpAddress      sql.NullString
+	RequestID      sql.NullString
+	CreatedAt      time.Time
+}
+
+type AuthEvent struct {
+	ID        uuid.UUID
+	UserID    uuid.NullUUID
+	Username  sql.NullString
+	EventType string
+	IpAddress sql.NullString
+	Detail    sql.NullString
+	CreatedAt time.Time
+}
+
+type ClientCertificateMapping struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	MatchType      string
+	MatchValue     string
+	Scopes         []string
+	CreatedBy      uuid.NullUUID
+	CreatedAt      time.Time
+	LastUsedAt     sql.NullTime
+	OrganizationID uuid.UUID
+}
+
+type DeviceCode struct {
+	ID             uuid.UUID
+	DeviceCodeHash string
+	UserCode       string
+	ClientID       string
+	Status         string
+	UserID         uuid.NullUUID
+	PollInterval   int32
+	LastPolledAt   sql.NullTime
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+}
+
+type LoginAttempt struct {
+	Scope        string
+	Key          string
+	FailedCount  int32
+	LastFailedAt time.Time
+	LockedUntil  sql.NullTime
+}
+
+type OidcLoginState struct {
+	StateHash      string
+	OrganizationID uuid.NullUUID
+	Nonce          string
+	CodeVerifier   string
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+	DeviceCodeID   uuid.NullUUID
+}
+
 type Organization struct {
 	ID          uuid.UUID
 	Name        string
@@ -22,26 +113,144 @@ type Organization struct {
 	DeletedAt   sql.NullTime
 }
 
+type OrganizationInvitation struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	TokenHash      string
+	Role           string
+	Username       sql.NullString
+	Email          sql.NullString
+	InvitedBy      uuid.NullUUID
+	ExpiresAt      time.Time
+	AcceptedAt     sql.NullTime
+	DeclinedAt     sql.NullTime
+	RevokedAt      sql.NullTime
+	RespondedBy    uuid.NullUUID
+	CreatedAt      time.Time
+}
+
+type OrganizationMember struct {
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	Role           string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type OrganizationOwnershipTransfer struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	FromUserID     uuid.UUID
+	ToUserID       uuid.UUID
+	ExpiresAt      time.Time
+	AcceptedAt     sql.NullTime
+	CancelledAt    sql.NullTime
+	CreatedAt      time.Time
+}
+
+type OrganizationRole struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Name           string
+	Description    sql.NullString
+	Permissions    []string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type PasswordResetToken struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	TokenHash   string
+	RequestedBy uuid.NullUUID
+	ExpiresAt   time.Time
+	UsedAt      sql.NullTime
+	CreatedAt   time.Time
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
+	IdleUntil time.Time
+}
+
+type RecoveryCode struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type RetiredRefreshToken struct {
+	TokenHash string
+	SessionID uuid.UUID
+	RetiredAt time.Time
+}
+
+type Session struct {
+	ID               uuid.UUID
+	UserID           uuid.UUID
+	RefreshTokenHash string
+	ExpiresAt        time.Time
+	RevokedAt        sql.NullTime
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Client           sql.NullString
+	IpAddress        sql.NullString
+	UserAgent        sql.NullString
+	LastSeenAt       time.Time
+}
+
 type Task struct {
 	ID          uuid.UUID
 	Title       string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	DeletedAt   sql.NullTime
-	UserID      uuid.UUID
+	UserID      uuid.NullUUID
 	Description string
 	IsCompleted bool
 }
 
+type UsedMfaToken struct {
+	Jti       uuid.UUID
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    time.Time
+}
+
 type User struct {
 	ID             uuid.UUID
 	Username       string
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
-	ApiKey         string
 	PasswordHash   string
 	Age            sql.NullInt32
 	Gender         sql.NullString
-	Role           string
 	OrganizationID uuid.NullUUID
+	AccountType    string
+	DisabledAt     sql.NullTime
+	CreatedBy      uuid.NullUUID
+	IsSystemAdmin  bool
+}
+
+type UserIdentity struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Issuer      string
+	Subject     string
+	Email       sql.NullString
+	CreatedAt   time.Time
+	LastLoginAt time.Time
+}
+
+type UserTotp struct {
+	UserID       uuid.UUID
+	Secret       string
+	EnabledAt    sql.NullTime
+	LastUsedStep int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
@@ -0,0 +1,194 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: oidc.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const consumeOIDCLoginState = `-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = $1 AND expires_at > NOW()
+RETURNING state_hash, organization_id, nonce, code_verifier, expires_at, created_at, device_code_id
+`
+
+func (q *Queries) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (OidcLoginState, error) {
+	row := q.db.QueryRowContext(ctx, consumeOIDCLoginState, stateHash)
+	var i OidcLoginState
+	err := row.Scan(
+		&i.StateHash,
+		&i.OrganizationID,
+		&i.Nonce,
+		&i.CodeVerifier,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.DeviceCodeID,
+	)
+	return i, err
+}
+
+const createOIDCLoginState = `-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (state_hash, organization_id, nonce, code_verifier, expires_at, device_code_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateOIDCLoginStateParams struct {
+	StateHash      string
+	OrganizationID uuid.NullUUID
+	Nonce          string
+	CodeVerifier   string
+	ExpiresAt      time.Time
+	DeviceCodeID   uuid.NullUUID
+}
+
+func (q *Queries) CreateOIDCLoginState(ctx context.Context, arg CreateOIDCLoginStateParams) error {
+	_, err := q.db.ExecContext(ctx, createOIDCLoginState,
+		arg.StateHash,
+		arg.OrganizationID,
+		arg.Nonce,
+		arg.CodeVerifier,
+		arg.ExpiresAt,
+		arg.DeviceCodeID,
+	)
+	return err
+}
+
+const createSSOUser = `-- name: CreateSSOUser :one
+INSERT INTO users (id, username, password_hash, organization_id)
+VALUES ($1, $2, $3, $4)
+RETURNING id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin
+`
+
+type CreateSSOUserParams struct {
+	ID             uuid.UUID
+	Username       string
+	PasswordHash   string
+	OrganizationID uuid.NullUUID
+}
+
+func (q *Queries) CreateSSOUser(ctx context.Context, arg CreateSSOUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createSSOUser,
+		arg.ID,
+		arg.Username,
+		arg.PasswordHash,
+		arg.OrganizationID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Age,
+		&i.Gender,
+		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
+	)
+	return i, err
+}
+
+const createUserIdentity = `-- name: CreateUserIdentity :one
+INSERT INTO user_identities (id, user_id, issuer, subject, email)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, issuer, subject, email, created_at, last_login_at
+`
+
+type CreateUserIdentityParams struct {
+	ID      uuid.UUID
+	UserID  uuid.UUID
+	Issuer  string
+	Subject string
+	Email   sql.NullString
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error) {
+	row := q.db.QueryRowContext(ctx, createUserIdentity,
+		arg.ID,
+		arg.UserID,
+		arg.Issuer,
+		arg.Subject,
+		arg.Email,
+	)
+	var i UserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Issuer,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt,
+		&i.LastLoginAt,
+	)
+	return i, err
+}
+
+const deleteExpiredOIDCLoginStates = `-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states WHERE expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredOIDCLoginStates(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredOIDCLoginStates)
+	return err
+}
+
+const getUserIdentity = `-- name: GetUserIdentity :one
+SELECT id, user_id, issuer, subject, email, created_at, last_login_at FROM user_identities WHERE issuer = $1 AND subject = $2
+`
+
+type GetUserIdentityParams struct {
+	Issuer  string
+	Subject string
+}
+
+func (q *Queries) GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error) {
+	row := q.db.QueryRowContext(ctx, getUserIdentity, arg.Issuer, arg.Subject)
+	var i UserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Issuer,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt,
+		&i.LastLoginAt,
+	)
+	return i, err
+}
+
+const touchUserIdentity = `-- name: TouchUserIdentity :exec
+UPDATE user_identities
+SET email = $2, last_login_at = NOW()
+WHERE id = $1
+`
+
+type TouchUserIdentityParams struct {
+	ID    uuid.UUID
+	Email sql.NullString
+}
+
+func (q *Queries) TouchUserIdentity(ctx context.Context, arg TouchUserIdentityParams) error {
+	_, err := q.db.ExecContext(ctx, touchUserIdentity, arg.ID, arg.Email)
+	return err
+}
+
+const usernameExists = `-- name: UsernameExists :one
+SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)
+`
+
+func (q *Queries) UsernameExists(ctx context.Context, username string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, usernameExists, username)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,257 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: organization_members.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const addOrganizationMember = `-- name: AddOrganizationMember :one
+INSERT INTO organization_members (organization_id, user_id, role)
+VALUES ($1, $2, $3)
+RETURNING organization_id, user_id, role, created_at, updated_at
+`
+
+type AddOrganizationMemberParams struct {
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	Role           string
+}
+
+func (q *Queries) AddOrganizationMember(ctx context.Context, arg AddOrganizationMemberParams) (OrganizationMember, error) {
+	row := q.db.QueryRowContext(ctx, addOrganizationMember, arg.OrganizationID, arg.UserID, arg.Role)
+	var i OrganizationMember
+	err := row.Scan(
+		&i.OrganizationID,
+		&i.UserID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const clearUserDefaultOrganization = `-- name: ClearUserDefaultOrganization :exec
+UPDATE users
+SET organization_id = NULL, updated_at = NOW()
+WHERE id = $1 AND organization_id = $2
+`
+
+type ClearUserDefaultOrganizationParams struct {
+	ID             uuid.UUID
+	OrganizationID uuid.NullUUID
+}
+
+func (q *Queries) ClearUserDefaultOrganization(ctx context.Context, arg ClearUserDefaultOrganizationParams) error {
+	_, err := q.db.ExecContext(ctx, clearUserDefaultOrganization, arg.ID, arg.OrganizationID)
+	return err
+}
+
+const countOrganizationRoleMembers = `-- name: CountOrganizationRoleMembers :one
+SELECT COUNT(*) FROM organization_members
+WHERE organization_id = $1 AND role = $2
+`
+
+type CountOrganizationRoleMembersParams struct {
+	OrganizationID uuid.UUID
+	Role           string
+}
+
+func (q *Queries) CountOrganizationRoleMembers(ctx context.Context, arg CountOrganizationRoleMembersParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOrganizationRoleMembers, arg.OrganizationID, arg.Role)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getOrganizationMembership = `-- name: GetOrganizationMembership :one
+SELECT organization_id, user_id, role, created_at, updated_at FROM organization_members
+WHERE organization_id = $1 AND user_id = $2
+`
+
+type GetOrganizationMembershipParams struct {
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+}
+
+func (q *Queries) GetOrganizationMembership(ctx context.Context, arg GetOrganizationMembershipParams) (OrganizationMember, error) {
+	row := q.db.QueryRowContext(ctx, getOrganizationMembership, arg.OrganizationID, arg.UserID)
+	var i OrganizationMember
+	err := row.Scan(
+		&i.OrganizationID,
+		&i.UserID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listOrganizationMembers = `-- name: ListOrganizationMembers :many
+SELECT organization_members.organization_id, organization_members.user_id, organization_members.role, organization_members.created_at, organization_members.updated_at, users.username, users.account_type, users.disabled_at
+FROM organization_members
+JOIN users ON users.id = organization_members.user_id
+WHERE organization_members.organization_id = $1
+ORDER BY users.username
+`
+
+type ListOrganizationMembersRow struct {
+	OrganizationMember OrganizationMember
+	Username           string
+	AccountType        string
+	DisabledAt         sql.NullTime
+}
+
+func (q *Queries) ListOrganizationMembers(ctx context.Context, organizationID uuid.UUID) ([]ListOrganizationMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrganizationMembers, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrganizationMembersRow
+	for rows.Next() {
+		var i ListOrganizationMembersRow
+		if err := rows.Scan(
+			&i.OrganizationMember.OrganizationID,
+			&i.OrganizationMember.UserID,
+			&i.OrganizationMember.Role,
+			&i.OrganizationMember.CreatedAt,
+			&i.OrganizationMember.UpdatedAt,
+			&i.Username,
+			&i.AccountType,
+			&i.DisabledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserMemberships = `-- name: ListUserMemberships :many
+SELECT organization_members.organization_id, organization_members.user_id, organization_members.role, organization_members.created_at, organization_members.updated_at, organizations.name AS organization_name
+FROM organization_members
+JOIN organizations ON organizations.id = organization_members.organization_id
+WHERE organization_members.user_id = $1 AND organizations.deleted_at IS NULL
+ORDER BY organizations.name
+`
+
+type ListUserMembershipsRow struct {
+	OrganizationMember OrganizationMember
+	OrganizationName   string
+}
+
+func (q *Queries) ListUserMemberships(ctx context.Context, userID uuid.UUID) ([]ListUserMembershipsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserMemberships, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserMembershipsRow
+	for rows.Next() {
+		var i ListUserMembershipsRow
+		if err := rows.Scan(
+			&i.OrganizationMember.OrganizationID,
+			&i.OrganizationMember.UserID,
+			&i.OrganizationMember.Role,
+			&i.OrganizationMember.CreatedAt,
+			&i.OrganizationMember.UpdatedAt,
+			&i.OrganizationName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockOrganizationOwners = `-- name: LockOrganizationOwners :many
+SELECT user_id FROM organization_members
+WHERE organization_id = $1 AND role = 'owner'
+FOR UPDATE
+`
+
+func (q *Queries) LockOrganizationOwners(ctx context.Context, organizationID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, lockOrganizationOwners, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var user_id uuid.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeOrganizationMember = `-- name: RemoveOrganizationMember :execrows
+DELETE FROM organization_members
+WHERE organization_id = $1 AND user_id = $2
+`
+
+type RemoveOrganizationMemberParams struct {
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+}
+
+func (q *Queries) RemoveOrganizationMember(ctx context.Context, arg RemoveOrganizationMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeOrganizationMember, arg.OrganizationID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateOrganizationMemberRole = `-- name: UpdateOrganizationMemberRole :one
+UPDATE organization_members
+SET role = $3, updated_at = NOW()
+WHERE organization_id = $1 AND user_id = $2
+RETURNING organization_id, user_id, role, created_at, updated_at
+`
+
+type UpdateOrganizationMemberRoleParams struct {
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	Role           string
+}
+
+func (q *Queries) UpdateOrganizationMemberRole(ctx context.Context, arg UpdateOrganizationMemberRoleParams) (OrganizationMember, error) {
+	row := q.db.QueryRowContext(ctx, updateOrganizationMemberRole, arg.OrganizationID, arg.UserID, arg.Role)
+	var i OrganizationMember
+	err := row.Scan(
+		&i.OrganizationID,
+		&i.UserID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
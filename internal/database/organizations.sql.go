@@ -15,7 +15,7 @@ import (
 
 const createOrganization = `-- name: CreateOrganization :one
 INSERT INTO organizations (id, name, description, settings) 
-VALUES ($1, $2, $3, COALESCE($4, '{}'))
+VALUES ($1, $2, $3, COALESCE($4::jsonb, '{}'))
 RETURNING id, name, description, settings, created_at, updated_at, deleted_at
 `
 
@@ -23,7 +23,7 @@ type CreateOrganizationParams struct {
 	ID          uuid.UUID
 	Name        string
 	Description sql.NullString
-	Column4     interface{}
+	Settings    pqtype.NullRawMessage
 }
 
 func (q *Queries) CreateOrganization(ctx context.Context, arg CreateOrganizationParams) (Organization, error) {
@@ -31,7 +31,7 @@ func (q *Queries) CreateOrganization(ctx context.Context, arg CreateOrganization
 		arg.ID,
 		arg.Name,
 		arg.Description,
-		arg.Column4,
+		arg.Settings,
 	)
 	var i Organization
 	err := row.Scan(
@@ -46,41 +46,6 @@ func (q *Queries) CreateOrganization(ctx context.Context, arg CreateOrganization
 	return i, err
 }
 
-const getAllOrganizations = `-- name: GetAllOrganizations :many
-SELECT id, name, description, settings, created_at, updated_at, deleted_at FROM organizations WHERE deleted_at IS NULL
-`
-
-func (q *Queries) GetAllOrganizations(ctx context.Context) ([]Organization, error) {
-	rows, err := q.db.QueryContext(ctx, getAllOrganizations)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Organization
-	for rows.Next() {
-		var i Organization
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Description,
-			&i.Settings,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.DeletedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
 const getOrganizationByID = `-- name: GetOrganizationByID :one
 SELECT id, name, description, settings, created_at, updated_at, deleted_at FROM organizations WHERE id = $1 AND deleted_at IS NULL
 `
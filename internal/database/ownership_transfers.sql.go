@@ -0,0 +1,114 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: ownership_transfers.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const acceptOwnershipTransfer = `-- name: AcceptOwnershipTransfer :one
+UPDATE organization_ownership_transfers
+SET accepted_at = NOW()
+WHERE id = $1
+  AND accepted_at IS NULL AND cancelled_at IS NULL
+  AND expires_at > NOW()
+RETURNING id, organization_id, from_user_id, to_user_id, expires_at, accepted_at, cancelled_at, created_at
+`
+
+func (q *Queries) AcceptOwnershipTransfer(ctx context.Context, id uuid.UUID) (OrganizationOwnershipTransfer, error) {
+	row := q.db.QueryRowContext(ctx, acceptOwnershipTransfer, id)
+	var i OrganizationOwnershipTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.FromUserID,
+		&i.ToUserID,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CancelledAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const cancelOwnershipTransfers = `-- name: CancelOwnershipTransfers :execrows
+UPDATE organization_ownership_transfers
+SET cancelled_at = NOW()
+WHERE organization_id = $1
+  AND accepted_at IS NULL AND cancelled_at IS NULL
+`
+
+func (q *Queries) CancelOwnershipTransfers(ctx context.Context, organizationID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cancelOwnershipTransfers, organizationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createOwnershipTransfer = `-- name: CreateOwnershipTransfer :one
+INSERT INTO organization_ownership_transfers (id, organization_id, from_user_id, to_user_id, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, organization_id, from_user_id, to_user_id, expires_at, accepted_at, cancelled_at, created_at
+`
+
+type CreateOwnershipTransferParams struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	FromUserID     uuid.UUID
+	ToUserID       uuid.UUID
+	ExpiresAt      time.Time
+}
+
+func (q *Queries) CreateOwnershipTransfer(ctx context.Context, arg CreateOwnershipTransferParams) (OrganizationOwnershipTransfer, error) {
+	row := q.db.QueryRowContext(ctx, createOwnershipTransfer,
+		arg.ID,
+		arg.OrganizationID,
+		arg.FromUserID,
+		arg.ToUserID,
+		arg.ExpiresAt,
+	)
+	var i OrganizationOwnershipTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.FromUserID,
+		&i.ToUserID,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CancelledAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPendingOwnershipTransfer = `-- name: GetPendingOwnershipTransfer :one
+SELECT id, organization_id, from_user_id, to_user_id, expires_at, accepted_at, cancelled_at, created_at FROM organization_ownership_transfers
+WHERE organization_id = $1
+  AND accepted_at IS NULL AND cancelled_at IS NULL
+  AND expires_at > NOW()
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetPendingOwnershipTransfer(ctx context.Context, organizationID uuid.UUID) (OrganizationOwnershipTransfer, error) {
+	row := q.db.QueryRowContext(ctx, getPendingOwnershipTransfer, organizationID)
+	var i OrganizationOwnershipTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.FromUserID,
+		&i.ToUserID,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CancelledAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
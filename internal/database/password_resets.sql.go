@@ -0,0 +1,101 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: password_resets.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createPasswordResetToken = `-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (id, user_id, token_hash, requested_by, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, token_hash, requested_by, expires_at, used_at, created_at
+`
+
+type CreatePasswordResetTokenParams struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	TokenHash   string
+	RequestedBy uuid.NullUUID
+	ExpiresAt   time.Time
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, createPasswordResetToken,
+		arg.ID,
+		arg.UserID,
+		arg.TokenHash,
+		arg.RequestedBy,
+		arg.ExpiresAt,
+	)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.RequestedBy,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getValidPasswordResetToken = `-- name: GetValidPasswordResetToken :one
+SELECT id, user_id, token_hash, requested_by, expires_at, used_at, created_at FROM password_reset_tokens
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+`
+
+func (q *Queries) GetValidPasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, getValidPasswordResetToken, tokenHash)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.RequestedBy,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const invalidateUserPasswordResetTokens = `-- name: InvalidateUserPasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) InvalidateUserPasswordResetTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, invalidateUserPasswordResetTokens, userID)
+	return err
+}
+
+const usePasswordResetToken = `-- name: UsePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING id, user_id, token_hash, requested_by, expires_at, used_at, created_at
+`
+
+func (q *Queries) UsePasswordResetToken(ctx context.Context, id uuid.UUID) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, usePasswordResetToken, id)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.RequestedBy,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,71 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: rate_limits.sql
+
+package database
+
+import (
+	"context"
+)
+
+const deleteIdleRateLimitBuckets = `-- name: DeleteIdleRateLimitBuckets :exec
+DELETE FROM rate_limit_buckets WHERE idle_until < NOW()
+`
+
+// A bucket left alone for a whole period is full again and behaves like a missing one
+func (q *Queries) DeleteIdleRateLimitBuckets(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteIdleRateLimitBuckets)
+	return err
+}
+
+const getRateLimitTokens = `-- name: GetRateLimitTokens :one
+SELECT LEAST($1::float8, tokens + EXTRACT(EPOCH FROM (NOW() - updated_at))::float8 * $2::float8)::float8 AS tokens
+FROM rate_limit_buckets
+WHERE key = $3
+`
+
+type GetRateLimitTokensParams struct {
+	Capacity float64
+	Rate     float64
+	Key      string
+}
+
+func (q *Queries) GetRateLimitTokens(ctx context.Context, arg GetRateLimitTokensParams) (float64, error) {
+	row := q.db.QueryRowContext(ctx, getRateLimitTokens, arg.Capacity, arg.Rate, arg.Key)
+	var tokens float64
+	err := row.Scan(&tokens)
+	return tokens, err
+}
+
+const takeRateLimitToken = `-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at, idle_until)
+VALUES ($1, CAST($2 AS DOUBLE PRECISION) - 1, NOW(), NOW() + make_interval(secs => $3::float8))
+ON CONFLICT (key) DO UPDATE
+SET tokens = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at))::float8 * $4::float8) - 1,
+    updated_at = NOW(),
+    idle_until = NOW() + make_interval(secs => $3::float8)
+WHERE LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at))::float8 * $4::float8) >= 1
+RETURNING tokens
+`
+
+type TakeRateLimitTokenParams struct {
+	Key           string
+	Capacity      float64
+	PeriodSeconds float64
+	Rate          float64
+}
+
+// Buckets refill at @rate tokens per second up to @capacity, measured with the database clock so every
+// instance agrees. Returns no row when the bucket holds less than one token, leaving it untouched.
+func (q *Queries) TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (float64, error) {
+	row := q.db.QueryRowContext(ctx, takeRateLimitToken,
+		arg.Key,
+		arg.Capacity,
+		arg.PeriodSeconds,
+		arg.Rate,
+	)
+	var tokens float64
+	err := row.Scan(&tokens)
+	return tokens, err
+}
@@ -0,0 +1,190 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: roles.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createOrganizationRole = `-- name: CreateOrganizationRole :one
+INSERT INTO organization_roles (id, organization_id, name, description, permissions)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, organization_id, name, description, permissions, created_at, updated_at
+`
+
+type CreateOrganizationRoleParams struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Name           string
+	Description    sql.NullString
+	Permissions    []string
+}
+
+func (q *Queries) CreateOrganizationRole(ctx context.Context, arg CreateOrganizationRoleParams) (OrganizationRole, error) {
+	row := q.db.QueryRowContext(ctx, createOrganizationRole,
+		arg.ID,
+		arg.OrganizationID,
+		arg.Name,
+		arg.Description,
+		pq.Array(arg.Permissions),
+	)
+	var i OrganizationRole
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Description,
+		pq.Array(&i.Permissions),
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteOrganizationRole = `-- name: DeleteOrganizationRole :execrows
+DELETE FROM organization_roles
+WHERE id = $1 AND organization_id = $2
+`
+
+type DeleteOrganizationRoleParams struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+}
+
+func (q *Queries) DeleteOrganizationRole(ctx context.Context, arg DeleteOrganizationRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOrganizationRole, arg.ID, arg.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getOrganizationRole = `-- name: GetOrganizationRole :one
+SELECT id, organization_id, name, description, permissions, created_at, updated_at FROM organization_roles
+WHERE id = $1 AND organization_id = $2
+`
+
+type GetOrganizationRoleParams struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+}
+
+func (q *Queries) GetOrganizationRole(ctx context.Context, arg GetOrganizationRoleParams) (OrganizationRole, error) {
+	row := q.db.QueryRowContext(ctx, getOrganizationRole, arg.ID, arg.OrganizationID)
+	var i OrganizationRole
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Description,
+		pq.Array(&i.Permissions),
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getOrganizationRoleByName = `-- name: GetOrganizationRoleByName :one
+SELECT id, organization_id, name, description, permissions, created_at, updated_at FROM organization_roles
+WHERE organization_id = $1 AND name = $2
+`
+
+type GetOrganizationRoleByNameParams struct {
+	OrganizationID uuid.UUID
+	Name           string
+}
+
+func (q *Queries) GetOrganizationRoleByName(ctx context.Context, arg GetOrganizationRoleByNameParams) (OrganizationRole, error) {
+	row := q.db.QueryRowContext(ctx, getOrganizationRoleByName, arg.OrganizationID, arg.Name)
+	var i OrganizationRole
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Description,
+		pq.Array(&i.Permissions),
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listOrganizationRoles = `-- name: ListOrganizationRoles :many
+SELECT id, organization_id, name, description, permissions, created_at, updated_at FROM organization_roles
+WHERE organization_id = $1
+ORDER BY name
+`
+
+func (q *Queries) ListOrganizationRoles(ctx context.Context, organizationID uuid.UUID) ([]OrganizationRole, error) {
+	rows, err := q.db.QueryContext(ctx, listOrganizationRoles, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrganizationRole
+	for rows.Next() {
+		var i OrganizationRole
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.Name,
+			&i.Description,
+			pq.Array(&i.Permissions),
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateOrganizationRole = `-- name: UpdateOrganizationRole :one
+UPDATE organization_roles
+SET description = $1,
+    permissions = $2,
+    updated_at = NOW()
+WHERE id = $3 AND organization_id = $4
+RETURNING id, organization_id, name, description, permissions, created_at, updated_at
+`
+
+type UpdateOrganizationRoleParams struct {
+	Description    sql.NullString
+	Permissions    []string
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+}
+
+func (q *Queries) UpdateOrganizationRole(ctx context.Context, arg UpdateOrganizationRoleParams) (OrganizationRole, error) {
+	row := q.db.QueryRowContext(ctx, updateOrganizationRole,
+		arg.Description,
+		pq.Array(arg.Permissions),
+		arg.ID,
+		arg.OrganizationID,
+	)
+	var i OrganizationRole
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Description,
+		pq.Array(&i.Permissions),
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
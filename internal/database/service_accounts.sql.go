@@ -0,0 +1,155 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: service_accounts.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const createServiceAccount = `-- name: CreateServiceAccount :one
+INSERT INTO users (id, username, password_hash, organization_id, account_type, created_by)
+VALUES ($1, $2, '', $3, 'service', $4)
+RETURNING id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin
+`
+
+type CreateServiceAccountParams struct {
+	ID             uuid.UUID
+	Username       string
+	OrganizationID uuid.NullUUID
+	CreatedBy      uuid.NullUUID
+}
+
+func (q *Queries) CreateServiceAccount(ctx context.Context, arg CreateServiceAccountParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createServiceAccount,
+		arg.ID,
+		arg.Username,
+		arg.OrganizationID,
+		arg.CreatedBy,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Age,
+		&i.Gender,
+		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
+	)
+	return i, err
+}
+
+const getServiceAccount = `-- name: GetServiceAccount :one
+SELECT id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin FROM users
+WHERE id = $1 AND organization_id = $2 AND account_type = 'service'
+`
+
+type GetServiceAccountParams struct {
+	ID             uuid.UUID
+	OrganizationID uuid.NullUUID
+}
+
+func (q *Queries) GetServiceAccount(ctx context.Context, arg GetServiceAccountParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getServiceAccount, arg.ID, arg.OrganizationID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Age,
+		&i.Gender,
+		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
+	)
+	return i, err
+}
+
+const listServiceAccounts = `-- name: ListServiceAccounts :many
+SELECT id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin FROM users
+WHERE organization_id = $1 AND account_type = 'service'
+ORDER BY username
+`
+
+func (q *Queries) ListServiceAccounts(ctx context.Context, organizationID uuid.NullUUID) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listServiceAccounts, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PasswordHash,
+			&i.Age,
+			&i.Gender,
+			&i.OrganizationID,
+			&i.AccountType,
+			&i.DisabledAt,
+			&i.CreatedBy,
+			&i.IsSystemAdmin,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserDisabledAt = `-- name: SetUserDisabledAt :one
+UPDATE users
+SET disabled_at = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin
+`
+
+type SetUserDisabledAtParams struct {
+	ID         uuid.UUID
+	DisabledAt sql.NullTime
+}
+
+func (q *Queries) SetUserDisabledAt(ctx context.Context, arg SetUserDisabledAtParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserDisabledAt, arg.ID, arg.DisabledAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Age,
+		&i.Gender,
+		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
+	)
+	return i, err
+}
@@ -0,0 +1,309 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: sessions.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO sessions (id, user_id, refresh_token_hash, expires_at, client, ip_address, user_agent)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, refresh_token_hash, expires_at, revoked_at, created_at, updated_at, client, ip_address, user_agent, last_seen_at
+`
+
+type CreateSessionParams struct {
+	ID               uuid.UUID
+	UserID           uuid.UUID
+	RefreshTokenHash string
+	ExpiresAt        time.Time
+	Client           sql.NullString
+	IpAddress        sql.NullString
+	UserAgent        sql.NullString
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, createSession,
+		arg.ID,
+		arg.UserID,
+		arg.RefreshTokenHash,
+		arg.ExpiresAt,
+		arg.Client,
+		arg.IpAddress,
+		arg.UserAgent,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshTokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Client,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.LastSeenAt,
+	)
+	return i, err
+}
+
+const getActiveSessionByID = `-- name: GetActiveSessionByID :one
+SELECT sessions.id, sessions.user_id, sessions.refresh_token_hash, sessions.expires_at, sessions.revoked_at, sessions.created_at, sessions.updated_at, sessions.client, sessions.ip_address, sessions.user_agent, sessions.last_seen_at, users.username, users.organization_id, users.account_type
+FROM sessions
+JOIN users ON users.id = sessions.user_id
+WHERE sessions.id = $1
+  AND sessions.revoked_at IS NULL
+  AND sessions.expires_at > NOW()
+  AND users.disabled_at IS NULL
+`
+
+type GetActiveSessionByIDRow struct {
+	Session        Session
+	Username       string
+	OrganizationID uuid.NullUUID
+	AccountType    string
+}
+
+func (q *Queries) GetActiveSessionByID(ctx context.Context, id uuid.UUID) (GetActiveSessionByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getActiveSessionByID, id)
+	var i GetActiveSessionByIDRow
+	err := row.Scan(
+		&i.Session.ID,
+		&i.Session.UserID,
+		&i.Session.RefreshTokenHash,
+		&i.Session.ExpiresAt,
+		&i.Session.RevokedAt,
+		&i.Session.CreatedAt,
+		&i.Session.UpdatedAt,
+		&i.Session.Client,
+		&i.Session.IpAddress,
+		&i.Session.UserAgent,
+		&i.Session.LastSeenAt,
+		&i.Username,
+		&i.OrganizationID,
+		&i.AccountType,
+	)
+	return i, err
+}
+
+const getSessionByRefreshTokenHash = `-- name: GetSessionByRefreshTokenHash :one
+SELECT id, user_id, refresh_token_hash, expires_at, revoked_at, created_at, updated_at, client, ip_address, user_agent, last_seen_at FROM sessions
+WHERE refresh_token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+`
+
+func (q *Queries) GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSessionByRefreshTokenHash, refreshTokenHash)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshTokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Client,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.LastSeenAt,
+	)
+	return i, err
+}
+
+const getSessionByRetiredRefreshTokenHash = `-- name: GetSessionByRetiredRefreshTokenHash :one
+SELECT sessions.id, sessions.user_id, sessions.refresh_token_hash, sessions.expires_at, sessions.revoked_at, sessions.created_at, sessions.updated_at, sessions.client, sessions.ip_address, sessions.user_agent, sessions.last_seen_at FROM sessions
+JOIN retired_refresh_tokens ON retired_refresh_tokens.session_id = sessions.id
+WHERE retired_refresh_tokens.token_hash = $1
+`
+
+func (q *Queries) GetSessionByRetiredRefreshTokenHash(ctx context.Context, tokenHash string) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSessionByRetiredRefreshTokenHash, tokenHash)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshTokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Client,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.LastSeenAt,
+	)
+	return i, err
+}
+
+const listActiveUserSessions = `-- name: ListActiveUserSessions :many
+SELECT id, user_id, refresh_token_hash, expires_at, revoked_at, created_at, updated_at, client, ip_address, user_agent, last_seen_at FROM sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY last_seen_at DESC
+`
+
+func (q *Queries) ListActiveUserSessions(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.RefreshTokenHash,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Client,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retireRefreshToken = `-- name: RetireRefreshToken :exec
+INSERT INTO retired_refresh_tokens (token_hash, session_id)
+VALUES ($1, $2)
+ON CONFLICT (token_hash) DO NOTHING
+`
+
+type RetireRefreshTokenParams struct {
+	TokenHash string
+	SessionID uuid.UUID
+}
+
+func (q *Queries) RetireRefreshToken(ctx context.Context, arg RetireRefreshTokenParams) error {
+	_, err := q.db.ExecContext(ctx, retireRefreshToken, arg.TokenHash, arg.SessionID)
+	return err
+}
+
+const revokeOtherUserSessions = `-- name: RevokeOtherUserSessions :exec
+UPDATE sessions
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL
+`
+
+type RevokeOtherUserSessionsParams struct {
+	UserID uuid.UUID
+	ID     uuid.UUID
+}
+
+func (q *Queries) RevokeOtherUserSessions(ctx context.Context, arg RevokeOtherUserSessionsParams) error {
+	_, err := q.db.ExecContext(ctx, revokeOtherUserSessions, arg.UserID, arg.ID)
+	return err
+}
+
+const revokeSession = `-- name: RevokeSession :one
+UPDATE sessions
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING id, user_id, refresh_token_hash, expires_at, revoked_at, created_at, updated_at, client, ip_address, user_agent, last_seen_at
+`
+
+type RevokeSessionParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RevokeSession(ctx context.Context, arg RevokeSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, revokeSession, arg.ID, arg.UserID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshTokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Client,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.LastSeenAt,
+	)
+	return i, err
+}
+
+const revokeUserSessions = `-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeUserSessions, userID)
+	return err
+}
+
+const rotateSessionRefreshToken = `-- name: RotateSessionRefreshToken :one
+UPDATE sessions
+SET refresh_token_hash = $1, expires_at = $2, last_seen_at = NOW(), updated_at = NOW()
+WHERE id = $3 AND refresh_token_hash = $4 AND revoked_at IS NULL
+RETURNING id, user_id, refresh_token_hash, expires_at, revoked_at, created_at, updated_at, client, ip_address, user_agent, last_seen_at
+`
+
+type RotateSessionRefreshTokenParams struct {
+	NewHash   string
+	ExpiresAt time.Time
+	ID        uuid.UUID
+	OldHash   string
+}
+
+func (q *Queries) RotateSessionRefreshToken(ctx context.Context, arg RotateSessionRefreshTokenParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, rotateSessionRefreshToken,
+		arg.NewHash,
+		arg.ExpiresAt,
+		arg.ID,
+		arg.OldHash,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshTokenHash,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Client,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.LastSeenAt,
+	)
+	return i, err
+}
+
+const touchSessionLastSeen = `-- name: TouchSessionLastSeen :exec
+UPDATE sessions
+SET last_seen_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TouchSessionLastSeen(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, touchSessionLastSeen, id)
+	return err
+}
@@ -12,6 +12,20 @@ import (
 	"github.com/google/uuid"
 )
 
+const anonymizeUserTasks = `-- name: AnonymizeUserTasks :execrows
+UPDATE tasks
+SET user_id = NULL, updated_at = NOW()
+WHERE user_id = $1
+`
+
+func (q *Queries) AnonymizeUserTasks(ctx context.Context, userID uuid.NullUUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, anonymizeUserTasks, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const completeTask = `-- name: CompleteTask :one
 UPDATE tasks
 SET is_completed = TRUE, updated_at = NOW()
@@ -45,7 +59,7 @@ type CreateTaskParams struct {
 	ID          uuid.UUID
 	Title       string
 	Description string
-	UserID      uuid.UUID
+	UserID      uuid.NullUUID
 }
 
 func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error) {
@@ -69,6 +83,18 @@ func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, e
 	return i, err
 }
 
+const deleteUserTasks = `-- name: DeleteUserTasks :execrows
+DELETE FROM tasks WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserTasks(ctx context.Context, userID uuid.NullUUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserTasks, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getAllTasks = `-- name: GetAllTasks :many
 SELECT id, title, created_at, updated_at, deleted_at, user_id, description, is_completed FROM tasks WHERE deleted_at IS NULL ORDER BY created_at DESC
 `
@@ -105,11 +131,47 @@ func (q *Queries) GetAllTasks(ctx context.Context) ([]Task, error) {
 	return items, nil
 }
 
+const getAllTasksByUserId = `-- name: GetAllTasksByUserId :many
+SELECT id, title, created_at, updated_at, deleted_at, user_id, description, is_completed FROM tasks WHERE user_id = $1 ORDER BY created_at
+`
+
+func (q *Queries) GetAllTasksByUserId(ctx context.Context, userID uuid.NullUUID) ([]Task, error) {
+	rows, err := q.db.QueryContext(ctx, getAllTasksByUserId, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Task
+	for rows.Next() {
+		var i Task
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.UserID,
+			&i.Description,
+			&i.IsCompleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getDeletedTasksByUserId = `-- name: GetDeletedTasksByUserId :many
 SELECT id, title, created_at, updated_at, deleted_at, user_id, description, is_completed FROM tasks WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY updated_at DESC
 `
 
-func (q *Queries) GetDeletedTasksByUserId(ctx context.Context, userID uuid.UUID) ([]Task, error) {
+func (q *Queries) GetDeletedTasksByUserId(ctx context.Context, userID uuid.NullUUID) ([]Task, error) {
 	rows, err := q.db.QueryContext(ctx, getDeletedTasksByUserId, userID)
 	if err != nil {
 		return nil, err
@@ -165,7 +227,7 @@ const getTasksByUserId = `-- name: GetTasksByUserId :many
 SELECT id, title, created_at, updated_at, deleted_at, user_id, description, is_completed FROM tasks WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC
 `
 
-func (q *Queries) GetTasksByUserId(ctx context.Context, userID uuid.UUID) ([]Task, error) {
+func (q *Queries) GetTasksByUserId(ctx context.Context, userID uuid.NullUUID) ([]Task, error) {
 	rows, err := q.db.QueryContext(ctx, getTasksByUserId, userID)
 	if err != nil {
 		return nil, err
@@ -319,6 +381,25 @@ func (q *Queries) SoftDeleteTask(ctx context.Context, id uuid.UUID) (Task, error
 	return i, err
 }
 
+const transferUserTasks = `-- name: TransferUserTasks :execrows
+UPDATE tasks
+SET user_id = $1, updated_at = NOW()
+WHERE user_id = $2
+`
+
+type TransferUserTasksParams struct {
+	ToUserID   uuid.NullUUID
+	FromUserID uuid.NullUUID
+}
+
+func (q *Queries) TransferUserTasks(ctx context.Context, arg TransferUserTasksParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, transferUserTasks, arg.ToUserID, arg.FromUserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const undoCompleteTask = `-- name: UndoCompleteTask :one
 UPDATE tasks
 SET is_completed = FALSE, updated_at = NOW()
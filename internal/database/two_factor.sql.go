@@ -0,0 +1,215 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: two_factor.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const countUnusedRecoveryCodes = `-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnusedRecoveryCodes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (id, user_id, code_hash)
+VALUES ($1, $2, $3)
+`
+
+type CreateRecoveryCodeParams struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	CodeHash string
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.ExecContext(ctx, createRecoveryCode, arg.ID, arg.UserID, arg.CodeHash)
+	return err
+}
+
+const deleteExpiredMFATokens = `-- name: DeleteExpiredMFATokens :exec
+DELETE FROM used_mfa_tokens WHERE expires_at < NOW()
+`
+
+func (q *Queries) DeleteExpiredMFATokens(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredMFATokens)
+	return err
+}
+
+const deleteUserRecoveryCodes = `-- name: DeleteUserRecoveryCodes :exec
+DELETE FROM recovery_codes WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUserRecoveryCodes, userID)
+	return err
+}
+
+const deleteUserTOTP = `-- name: DeleteUserTOTP :exec
+DELETE FROM user_totp WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserTOTP(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUserTOTP, userID)
+	return err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :one
+UPDATE user_totp
+SET enabled_at = NOW(), last_used_step = $2, updated_at = NOW()
+WHERE user_id = $1 AND enabled_at IS NULL
+RETURNING user_id, secret, enabled_at, last_used_step, created_at, updated_at
+`
+
+type EnableUserTOTPParams struct {
+	UserID       uuid.UUID
+	LastUsedStep int64
+}
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (UserTotp, error) {
+	row := q.db.QueryRowContext(ctx, enableUserTOTP, arg.UserID, arg.LastUsedStep)
+	var i UserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.EnabledAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getUserTOTP = `-- name: GetUserTOTP :one
+SELECT user_id, secret, enabled_at, last_used_step, created_at, updated_at FROM user_totp WHERE user_id = $1
+`
+
+func (q *Queries) GetUserTOTP(ctx context.Context, userID uuid.UUID) (UserTotp, error) {
+	row := q.db.QueryRowContext(ctx, getUserTOTP, userID)
+	var i UserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.EnabledAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertPendingUserTOTP = `-- name: UpsertPendingUserTOTP :one
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, last_used_step = 0, updated_at = NOW()
+WHERE user_totp.enabled_at IS NULL
+RETURNING user_id, secret, enabled_at, last_used_step, created_at, updated_at
+`
+
+type UpsertPendingUserTOTPParams struct {
+	UserID uuid.UUID
+	Secret string
+}
+
+func (q *Queries) UpsertPendingUserTOTP(ctx context.Context, arg UpsertPendingUserTOTPParams) (UserTotp, error) {
+	row := q.db.QueryRowContext(ctx, upsertPendingUserTOTP, arg.UserID, arg.Secret)
+	var i UserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.EnabledAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const useMFAToken = `-- name: UseMFAToken :one
+INSERT INTO used_mfa_tokens (jti, user_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (jti) DO NOTHING
+RETURNING jti, user_id, expires_at, used_at
+`
+
+type UseMFATokenParams struct {
+	Jti       uuid.UUID
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+}
+
+func (q *Queries) UseMFAToken(ctx context.Context, arg UseMFATokenParams) (UsedMfaToken, error) {
+	row := q.db.QueryRowContext(ctx, useMFAToken, arg.Jti, arg.UserID, arg.ExpiresAt)
+	var i UsedMfaToken
+	err := row.Scan(
+		&i.Jti,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.UsedAt,
+	)
+	return i, err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+RETURNING id, user_id, code_hash, used_at, created_at
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   uuid.UUID
+	CodeHash string
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error) {
+	row := q.db.QueryRowContext(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	var i RecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CodeHash,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :one
+UPDATE user_totp
+SET last_used_step = $2, updated_at = NOW()
+WHERE user_id = $1 AND last_used_step < $2
+RETURNING user_id, secret, enabled_at, last_used_step, created_at, updated_at
+`
+
+type UseTOTPStepParams struct {
+	UserID       uuid.UUID
+	LastUsedStep int64
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (UserTotp, error) {
+	row := q.db.QueryRowContext(ctx, useTOTPStep, arg.UserID, arg.LastUsedStep)
+	var i UserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.EnabledAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -14,21 +14,18 @@ import (
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, username, password_hash, age, gender, role, organization_id, api_key) 
-VALUES ($1, $2, $3, $4, $5, COALESCE($6, 'user'), COALESCE($7, '00000000-0000-0000-0000-000000000000'),
-    encode(sha256(random()::text::bytea), 'hex')
-)
-RETURNING id, username, created_at, updated_at, api_key, password_hash, age, gender, role, organization_id
+INSERT INTO users (id, username, password_hash, age, gender, organization_id) 
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin
 `
 
 type CreateUserParams struct {
-	ID           uuid.UUID
-	Username     string
-	PasswordHash string
-	Age          sql.NullInt32
-	Gender       sql.NullString
-	Column6      interface{}
-	Column7      interface{}
+	ID             uuid.UUID
+	Username       string
+	PasswordHash   string
+	Age            sql.NullInt32
+	Gender         sql.NullString
+	OrganizationID uuid.NullUUID
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -38,8 +35,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.PasswordHash,
 		arg.Age,
 		arg.Gender,
-		arg.Column6,
-		arg.Column7,
+		arg.OrganizationID,
 	)
 	var i User
 	err := row.Scan(
@@ -47,32 +43,31 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Username,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
 		&i.PasswordHash,
 		&i.Age,
 		&i.Gender,
-		&i.Role,
 		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
 	)
 	return i, err
 }
 
 const createUserWithPassword = `-- name: CreateUserWithPassword :one
-INSERT INTO users (id, username, password_hash, age, gender, role, organization_id, api_key) 
-VALUES ($1, $2, $3, $4, $5, COALESCE($6, 'user'), COALESCE($7, '00000000-0000-0000-0000-000000000000'),
-    encode(sha256(random()::text::bytea), 'hex')
-)
-RETURNING id, username, created_at, updated_at, api_key, password_hash, age, gender, role, organization_id
+INSERT INTO users (id, username, password_hash, age, gender, organization_id) 
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin
 `
 
 type CreateUserWithPasswordParams struct {
-	ID           uuid.UUID
-	Username     string
-	PasswordHash string
-	Age          sql.NullInt32
-	Gender       sql.NullString
-	Column6      interface{}
-	Column7      interface{}
+	ID             uuid.UUID
+	Username       string
+	PasswordHash   string
+	Age            sql.NullInt32
+	Gender         sql.NullString
+	OrganizationID uuid.NullUUID
 }
 
 func (q *Queries) CreateUserWithPassword(ctx context.Context, arg CreateUserWithPasswordParams) (User, error) {
@@ -82,8 +77,7 @@ func (q *Queries) CreateUserWithPassword(ctx context.Context, arg CreateUserWith
 		arg.PasswordHash,
 		arg.Age,
 		arg.Gender,
-		arg.Column6,
-		arg.Column7,
+		arg.OrganizationID,
 	)
 	var i User
 	err := row.Scan(
@@ -91,19 +85,21 @@ func (q *Queries) CreateUserWithPassword(ctx context.Context, arg CreateUserWith
 		&i.Username,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
 		&i.PasswordHash,
 		&i.Age,
 		&i.Gender,
-		&i.Role,
 		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
 	)
 	return i, err
 }
 
 const deleteUser = `-- name: DeleteUser :one
 DELETE FROM users WHERE id = $1
-RETURNING id, username, created_at, updated_at, api_key, password_hash, age, gender, role, organization_id
+RETURNING id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin
 `
 
 func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (User, error) {
@@ -114,115 +110,23 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Username,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
 		&i.PasswordHash,
 		&i.Age,
 		&i.Gender,
-		&i.Role,
 		&i.OrganizationID,
-	)
-	return i, err
-}
-
-const getAllUsers = `-- name: GetAllUsers :many
-SELECT u.id, u.username, u.created_at, u.updated_at, u.api_key, u.password_hash, u.age, u.gender, u.role, u.organization_id, o.name as organization_name 
-FROM users u 
-LEFT JOIN organizations o ON u.organization_id = o.id
-`
-
-type GetAllUsersRow struct {
-	ID               uuid.UUID
-	Username         string
-	CreatedAt        time.Time
-	UpdatedAt        time.Time
-	ApiKey           string
-	PasswordHash     string
-	Age              sql.NullInt32
-	Gender           sql.NullString
-	Role             string
-	OrganizationID   uuid.NullUUID
-	OrganizationName sql.NullString
-}
-
-func (q *Queries) GetAllUsers(ctx context.Context) ([]GetAllUsersRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAllUsers)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetAllUsersRow
-	for rows.Next() {
-		var i GetAllUsersRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Username,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.ApiKey,
-			&i.PasswordHash,
-			&i.Age,
-			&i.Gender,
-			&i.Role,
-			&i.OrganizationID,
-			&i.OrganizationName,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const getUserByAPIKey = `-- name: GetUserByAPIKey :one
-SELECT u.id, u.username, u.created_at, u.updated_at, u.api_key, u.password_hash, u.age, u.gender, u.role, u.organization_id, o.name as organization_name 
-FROM users u 
-LEFT JOIN organizations o ON u.organization_id = o.id 
-WHERE u.api_key = $1
-`
-
-type GetUserByAPIKeyRow struct {
-	ID               uuid.UUID
-	Username         string
-	CreatedAt        time.Time
-	UpdatedAt        time.Time
-	ApiKey           string
-	PasswordHash     string
-	Age              sql.NullInt32
-	Gender           sql.NullString
-	Role             string
-	OrganizationID   uuid.NullUUID
-	OrganizationName sql.NullString
-}
-
-func (q *Queries) GetUserByAPIKey(ctx context.Context, apiKey string) (GetUserByAPIKeyRow, error) {
-	row := q.db.QueryRowContext(ctx, getUserByAPIKey, apiKey)
-	var i GetUserByAPIKeyRow
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.ApiKey,
-		&i.PasswordHash,
-		&i.Age,
-		&i.Gender,
-		&i.Role,
-		&i.OrganizationID,
-		&i.OrganizationName,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT u.id, u.username, u.created_at, u.updated_at, u.api_key, u.password_hash, u.age, u.gender, u.role, u.organization_id, o.name as organization_name 
+SELECT u.id, u.username, u.created_at, u.updated_at, u.password_hash, u.age, u.gender, u.organization_id, u.account_type, u.disabled_at, u.created_by, u.is_system_admin, o.name as organization_name, m.role 
 FROM users u 
 LEFT JOIN organizations o ON u.organization_id = o.id 
+LEFT JOIN organization_members m ON m.organization_id = u.organization_id AND m.user_id = u.id
 WHERE u.id = $1
 `
 
@@ -231,13 +135,16 @@ type GetUserByIDRow struct {
 	Username         string
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
-	ApiKey           string
 	PasswordHash     string
 	Age              sql.NullInt32
 	Gender           sql.NullString
-	Role             string
 	OrganizationID   uuid.NullUUID
+	AccountType      string
+	DisabledAt       sql.NullTime
+	CreatedBy        uuid.NullUUID
+	IsSystemAdmin    bool
 	OrganizationName sql.NullString
+	Role             sql.NullString
 }
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (GetUserByIDRow, error) {
@@ -248,21 +155,26 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (GetUserByIDRow
 		&i.Username,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
 		&i.PasswordHash,
 		&i.Age,
 		&i.Gender,
-		&i.Role,
 		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
 		&i.OrganizationName,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT u.id, u.username, u.created_at, u.updated_at, u.api_key, u.password_hash, u.age, u.gender, u.role, u.organization_id, o.name as organization_name 
+
+SELECT u.id, u.username, u.created_at, u.updated_at, u.password_hash, u.age, u.gender, u.organization_id, u.account_type, u.disabled_at, u.created_by, u.is_system_admin, o.name as organization_name, m.role 
 FROM users u 
 LEFT JOIN organizations o ON u.organization_id = o.id 
+LEFT JOIN organization_members m ON m.organization_id = u.organization_id AND m.user_id = u.id
 WHERE u.username = $1
 `
 
@@ -271,15 +183,19 @@ type GetUserByUsernameRow struct {
 	Username         string
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
-	ApiKey           string
 	PasswordHash     string
 	Age              sql.NullInt32
 	Gender           sql.NullString
-	Role             string
 	OrganizationID   uuid.NullUUID
+	AccountType      string
+	DisabledAt       sql.NullTime
+	CreatedBy        uuid.NullUUID
+	IsSystemAdmin    bool
 	OrganizationName sql.NullString
+	Role             sql.NullString
 }
 
+// The role returned with a user is their role in their default organization
 func (q *Queries) GetUserByUsername(ctx context.Context, username string) (GetUserByUsernameRow, error) {
 	row := q.db.QueryRowContext(ctx, getUserByUsername, username)
 	var i GetUserByUsernameRow
@@ -288,21 +204,25 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (GetUs
 		&i.Username,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
 		&i.PasswordHash,
 		&i.Age,
 		&i.Gender,
-		&i.Role,
 		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
 		&i.OrganizationName,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUserByUsernameAndPassword = `-- name: GetUserByUsernameAndPassword :one
-SELECT u.id, u.username, u.created_at, u.updated_at, u.api_key, u.password_hash, u.age, u.gender, u.role, u.organization_id, o.name as organization_name 
+SELECT u.id, u.username, u.created_at, u.updated_at, u.password_hash, u.age, u.gender, u.organization_id, u.account_type, u.disabled_at, u.created_by, u.is_system_admin, o.name as organization_name, m.role 
 FROM users u 
 LEFT JOIN organizations o ON u.organization_id = o.id 
+LEFT JOIN organization_members m ON m.organization_id = u.organization_id AND m.user_id = u.id
 WHERE u.username = $1 AND u.password_hash = $2
 `
 
@@ -316,13 +236,16 @@ type GetUserByUsernameAndPasswordRow struct {
 	Username         string
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
-	ApiKey           string
 	PasswordHash     string
 	Age              sql.NullInt32
 	Gender           sql.NullString
-	Role             string
 	OrganizationID   uuid.NullUUID
+	AccountType      string
+	DisabledAt       sql.NullTime
+	CreatedBy        uuid.NullUUID
+	IsSystemAdmin    bool
 	OrganizationName sql.NullString
+	Role             sql.NullString
 }
 
 func (q *Queries) GetUserByUsernameAndPassword(ctx context.Context, arg GetUserByUsernameAndPasswordParams) (GetUserByUsernameAndPasswordRow, error) {
@@ -333,60 +256,43 @@ func (q *Queries) GetUserByUsernameAndPassword(ctx context.Context, arg GetUserB
 		&i.Username,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
 		&i.PasswordHash,
 		&i.Age,
 		&i.Gender,
-		&i.Role,
 		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
 		&i.OrganizationName,
+		&i.Role,
 	)
 	return i, err
 }
 
-const getUsersByOrganization = `-- name: GetUsersByOrganization :many
-SELECT u.id, u.username, u.created_at, u.updated_at, u.api_key, u.password_hash, u.age, u.gender, u.role, u.organization_id, o.name as organization_name 
-FROM users u 
-LEFT JOIN organizations o ON u.organization_id = o.id 
-WHERE u.organization_id = $1
+const listUserPasswordHashes = `-- name: ListUserPasswordHashes :many
+SELECT id, username, password_hash FROM users
+WHERE account_type = 'human'
+ORDER BY username
 `
 
-type GetUsersByOrganizationRow struct {
-	ID               uuid.UUID
-	Username         string
-	CreatedAt        time.Time
-	UpdatedAt        time.Time
-	ApiKey           string
-	PasswordHash     string
-	Age              sql.NullInt32
-	Gender           sql.NullString
-	Role             string
-	OrganizationID   uuid.NullUUID
-	OrganizationName sql.NullString
+type ListUserPasswordHashesRow struct {
+	ID           uuid.UUID
+	Username     string
+	PasswordHash string
 }
 
-func (q *Queries) GetUsersByOrganization(ctx context.Context, organizationID uuid.NullUUID) ([]GetUsersByOrganizationRow, error) {
-	rows, err := q.db.QueryContext(ctx, getUsersByOrganization, organizationID)
+// Service accounts have no password, so only human accounts are listed
+func (q *Queries) ListUserPasswordHashes(ctx context.Context) ([]ListUserPasswordHashesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserPasswordHashes)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetUsersByOrganizationRow
+	var items []ListUserPasswordHashesRow
 	for rows.Next() {
-		var i GetUsersByOrganizationRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Username,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.ApiKey,
-			&i.PasswordHash,
-			&i.Age,
-			&i.Gender,
-			&i.Role,
-			&i.OrganizationID,
-			&i.OrganizationName,
-		); err != nil {
+		var i ListUserPasswordHashesRow
+		if err := rows.Scan(&i.ID, &i.Username, &i.PasswordHash); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -400,6 +306,23 @@ func (q *Queries) GetUsersByOrganization(ctx context.Context, organizationID uui
 	return items, nil
 }
 
+const rehashUserPassword = `-- name: RehashUserPassword :exec
+UPDATE users
+SET password_hash = $1
+WHERE id = $2 AND password_hash = $3
+`
+
+type RehashUserPasswordParams struct {
+	NewHash string
+	ID      uuid.UUID
+	OldHash string
+}
+
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, rehashUserPassword, arg.NewHash, arg.ID, arg.OldHash)
+	return err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET username = COALESCE($2, username), 
@@ -407,7 +330,7 @@ SET username = COALESCE($2, username),
     gender = COALESCE($4, gender),
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, username, created_at, updated_at, api_key, password_hash, age, gender, role, organization_id
+RETURNING id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin
 `
 
 type UpdateUserParams struct {
@@ -430,12 +353,14 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Username,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
 		&i.PasswordHash,
 		&i.Age,
 		&i.Gender,
-		&i.Role,
 		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
 	)
 	return i, err
 }
@@ -444,7 +369,7 @@ const updateUserOrganization = `-- name: UpdateUserOrganization :one
 UPDATE users
 SET organization_id = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING id, username, created_at, updated_at, api_key, password_hash, age, gender, role, organization_id
+RETURNING id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin
 `
 
 type UpdateUserOrganizationParams struct {
@@ -460,12 +385,14 @@ func (q *Queries) UpdateUserOrganization(ctx context.Context, arg UpdateUserOrga
 		&i.Username,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
 		&i.PasswordHash,
 		&i.Age,
 		&i.Gender,
-		&i.Role,
 		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
 	)
 	return i, err
 }
@@ -474,7 +401,7 @@ const updateUserPassword = `-- name: UpdateUserPassword :one
 UPDATE users
 SET password_hash = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING id, username, created_at, updated_at, api_key, password_hash, age, gender, role, organization_id
+RETURNING id, username, created_at, updated_at, password_hash, age, gender, organization_id, account_type, disabled_at, created_by, is_system_admin
 `
 
 type UpdateUserPasswordParams struct {
@@ -490,42 +417,14 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 		&i.Username,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
-		&i.PasswordHash,
-		&i.Age,
-		&i.Gender,
-		&i.Role,
-		&i.OrganizationID,
-	)
-	return i, err
-}
-
-const updateUserRole = `-- name: UpdateUserRole :one
-UPDATE users
-SET role = $2, updated_at = NOW()
-WHERE id = $1
-RETURNING id, username, created_at, updated_at, api_key, password_hash, age, gender, role, organization_id
-`
-
-type UpdateUserRoleParams struct {
-	ID   uuid.UUID
-	Role string
-}
-
-func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUserRole, arg.ID, arg.Role)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.ApiKey,
 		&i.PasswordHash,
 		&i.Age,
 		&i.Gender,
-		&i.Role,
 		&i.OrganizationID,
+		&i.AccountType,
+		&i.DisabledAt,
+		&i.CreatedBy,
+		&i.IsSystemAdmin,
 	)
 	return i, err
 }
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestNewRejectsInvalidConfig checks that unknown formats and levels are reported
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "xml", "info"); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+	if _, err := New(&bytes.Buffer{}, "json", "loud"); err == nil {
+		t.Error("expected an unknown level to be rejected")
+	}
+}
+
+// TestLoggerAddsRequestFields checks that records logged with a request context carry its ID, user and organization
+func TestLoggerAddsRequestFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID, orgID := uuid.New(), uuid.New()
+	ctx := WithRequestID(context.Background(), "req-1")
+	SetUser(ctx, userID, "human")
+	SetOrganization(ctx, uuid.NullUUID{UUID: orgID, Valid: true})
+
+	logger.InfoContext(ctx, "Request completed", "status", 200)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["request_id"] != "req-1" || record["user_id"] != userID.String() || record["org_id"] != orgID.String() {
+		t.Errorf("expected request fields, got %s", buf.String())
+	}
+	if RequestID(ctx) != "req-1" || RequestID(context.Background()) != "" {
+		t.Error("expected the request ID to be read back from the context only")
+	}
+}
+
+// TestLoggerRedactsSensitiveFields checks that secrets are replaced, including inside groups
+func TestLoggerRedactsSensitiveFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("Login", "username", "alice", "Password", "hunter2", slog.Group("credentials", "api_key", "hw_live_abc"))
+
+	var record struct {
+		Username    string
+		Password    string
+		Credentials struct {
+			APIKey string `json:"api_key"`
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Username != "alice" || record.Password != redacted || record.Credentials.APIKey != redacted {
+		t.Errorf("expected secrets to be redacted, got %s", buf.String())
+	}
+}
+
+// TestRedactQuery checks that sensitive query parameters are hidden and others kept
+func TestRedactQuery(t *testing.T) {
+	query := RedactQuery(url.Values{"code": {"abc"}, "state": {"xyz"}})
+	if query != "code=%5Bredacted%5D&state=xyz" {
+		t.Errorf("unexpected query %q", query)
+	}
+	if RedactQuery(nil) != "" {
+		t.Error("expected an empty query to stay empty")
+	}
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID from clients and proxies, and back in responses
+const RequestIDHeader = "X-Request-ID"
+
+// requestFieldsKey is the context key of a request's requestFields
+type requestFieldsKey struct{}
+
+// requestFields are attached to every log line of a request. Authentication runs inside the handlers that
+// log, so the fields are shared by pointer and filled in as the request is processed.
+type requestFields struct {
+	mu             sync.Mutex
+	requestID      string
+	userID         uuid.UUID
+	accountType    string
+	organizationID uuid.NullUUID
+}
+
+// WithRequestID starts the log fields of a request with its ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestFieldsKey{}, &requestFields{requestID: requestID})
+}
+
+// RequestID returns the ID of the request in the context, or an empty string outside a request
+func RequestID(ctx context.Context) string {
+	fields := requestFieldsFrom(ctx)
+	if fields == nil {
+		return ""
+	}
+	return fields.requestID
+}
+
+// SetUser records the authenticated account of the request in the context
+func SetUser(ctx context.Context, userID uuid.UUID, accountType string) {
+	if fields := requestFieldsFrom(ctx); fields != nil {
+		fields.mu.Lock()
+		defer fields.mu.Unlock()
+		fields.userID = userID
+		fields.accountType = accountType
+	}
+}
+
+// SetOrganization records the organization the request acts in
+func SetOrganization(ctx context.Context, organizationID uuid.NullUUID) {
+	if fields := requestFieldsFrom(ctx); fields != nil {
+		fields.mu.Lock()
+		defer fields.mu.Unlock()
+		fields.organizationID = organizationID
+	}
+}
+
+func requestFieldsFrom(ctx context.Context) *requestFields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(requestFieldsKey{}).(*requestFields)
+	return fields
+}
+
+func (f *requestFields) attrs() []slog.Attr {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	attrs := []slog.Attr{slog.String("request_id", f.requestID)}
+	if f.userID != uuid.Nil {
+		attrs = append(attrs, slog.String("user_id", f.userID.String()), slog.String("account_type", f.accountType))
+	}
+	if f.organizationID.Valid {
+		attrs = append(attrs, slog.String("org_id", f.organizationID.UUID.String()))
+	}
+	return attrs
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import "database/sql"
+
+// Metrics are the metrics exported by the server
+type Metrics struct {
+	*Registry
+
+	// HTTP requests by method, chi route pattern and status code
+	HTTPRequests         *Counter
+	HTTPRequestDuration  *Histogram
+	HTTPRequestsInFlight *Gauge
+
+	// Logins by result, success or failure
+	Logins *Counter
+
+	TasksCreated   *Counter
+	TasksCompleted *Counter
+}
+
+// New creates the server's metrics in a new registry
+func New() *Metrics {
+	r := NewRegistry()
+	return &Metrics{
+		Registry:             r,
+		HTTPRequests:         r.NewCounter("http_requests_total", "HTTP requests handled, by method, route and status.", "method", "route", "status"),
+		HTTPRequestDuration:  r.NewHistogram("http_request_duration_seconds", "HTTP request latency in seconds, by method and route.", DefaultBuckets, "method", "route"),
+		HTTPRequestsInFlight: r.NewGauge("http_requests_in_flight", "HTTP requests currently being handled."),
+		Logins:               r.NewCounter("auth_logins_total", "Interactive logins, by result.", "result"),
+		TasksCreated:         r.NewCounter("tasks_created_total", "Tasks created."),
+		TasksCompleted:       r.NewCounter("tasks_completed_total", "Tasks marked as completed."),
+	}
+}
+
+// ObserveDB exports the connection pool statistics of db, read on every scrape
+func (m *Metrics) ObserveDB(db *sql.DB) {
+	stats := func(fn func(sql.DBStats) float64) func() float64 {
+		return func() float64 { return fn(db.Stats()) }
+	}
+
+	m.NewGaugeFunc("db_connections_max_open", "Maximum number of open database connections.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	m.NewGaugeFunc("db_connections_open", "Open database connections, in use or idle.",
+		stats(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	m.NewGaugeFunc("db_connections_in_use", "Database connections currently in use.",
+		stats(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	m.NewGaugeFunc("db_connections_idle", "Idle database connections.",
+		stats(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	m.NewCounterFunc("db_connection_waits_total", "Times a query waited for a free database connection.",
+		stats(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	m.NewCounterFunc("db_connection_wait_seconds_total", "Total time spent waiting for a free database connection.",
+		stats(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+	m.NewCounterFunc("db_connections_closed_max_idle_total", "Connections closed because of the idle connection limit.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+	m.NewCounterFunc("db_connections_closed_max_idle_time_total", "Connections closed because they were idle too long.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }))
+	m.NewCounterFunc("db_connections_closed_max_lifetime_total", "Connections closed because they reached their maximum lifetime.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+}
@@ -0,0 +1,348 @@
+// Package metrics collects counters, gauges and histograms and exposes them in the Prometheus text format
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram bucket upper bounds in seconds suited to HTTP request latencies
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds metrics and writes them in the Prometheus text exposition format
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+// metric is a named metric that can write its samples
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register adds a metric, panicking on duplicate names since that is a programming error
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.metrics[name]; ok {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.metrics[name] = m
+}
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{newFamily(name, help, "counter", labelNames)}
+	r.register(name, c)
+	return c
+}
+
+// NewGauge registers a gauge with the given label names
+func (r *Registry) NewGauge(name, help string, labelNames ...string) *Gauge {
+	g := &Gauge{newFamily(name, help, "gauge", labelNames)}
+	r.register(name, g)
+	return g
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds and label names
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	h := &Histogram{family: newFamily(name, help, "histogram", labelNames), buckets: buckets}
+	r.register(name, h)
+	return h
+}
+
+// NewGaugeFunc registers a gauge whose value is read from fn on every scrape
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(name, funcMetric{name: name, help: help, kind: "gauge", fn: fn})
+}
+
+// NewCounterFunc registers a counter whose value is read from fn on every scrape
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(name, funcMetric{name: name, help: help, kind: "counter", fn: fn})
+}
+
+// WriteTo writes every metric, sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	metrics := make([]metric, len(names))
+	for i, name := range names {
+		metrics[i] = r.metrics[name]
+	}
+	r.mu.Unlock()
+
+	counter := &countingWriter{w: w}
+	buf := bufio.NewWriter(counter)
+	for _, m := range metrics {
+		m.write(buf)
+	}
+	err := buf.Flush()
+	return counter.n, err
+}
+
+// ServeHTTP serves the metrics for Prometheus to scrape
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// family holds the series of a metric, one per combination of label values
+type family struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// Histograms only: observations per bucket, not cumulative
+	bucketCounts []uint64
+	count        uint64
+}
+
+func newFamily(name, help, kind string, labelNames []string) family {
+	return family{name: name, help: help, kind: kind, labelNames: labelNames, series: make(map[string]*series)}
+}
+
+// get returns the series for the label values, creating it if needed; callers hold f.mu
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labelNames), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: slices.Clone(labelValues)}
+		f.series[key] = s
+	}
+	return s
+}
+
+// sortedSeries returns copies of the series ordered by label values; callers hold f.mu
+func (f *family) sortedSeries() []series {
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	sorted := make([]series, len(keys))
+	for i, key := range keys {
+		s := *f.series[key]
+		s.bucketCounts = slices.Clone(s.bucketCounts)
+		sorted[i] = s
+	}
+	return sorted
+}
+
+func (f *family) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind)
+}
+
+// writeValues writes a sample of every series for counters and gauges
+func (f *family) writeValues(w *bufio.Writer) {
+	f.mu.Lock()
+	sorted := f.sortedSeries()
+	f.mu.Unlock()
+
+	f.writeHeader(w)
+	for _, s := range sorted {
+		writeSample(w, f.name, f.labelNames, s.labelValues, "", "", s.value)
+	}
+}
+
+// Counter is a value that only goes up, such as a number of requests
+type Counter struct {
+	family
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds a non-negative amount to the series with the given label values
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(labelValues).value += delta
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.writeValues(w)
+}
+
+// Gauge is a value that goes up and down, such as a number of requests in flight
+type Gauge struct {
+	family
+}
+
+// Set sets the series with the given label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.get(labelValues).value = value
+}
+
+// Add adds an amount, which may be negative, to the series with the given label values
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.get(labelValues).value += delta
+}
+
+// Inc adds one to the series with the given label values
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec subtracts one from the series with the given label values
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.writeValues(w)
+}
+
+// Histogram counts observations, such as request durations, in buckets
+type Histogram struct {
+	family
+	buckets []float64
+}
+
+// Observe records a value in the series with the given label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.get(labelValues)
+	if s.bucketCounts == nil {
+		s.bucketCounts = make([]uint64, len(h.buckets))
+	}
+	if i, _ := slices.BinarySearch(h.buckets, value); i < len(h.buckets) {
+		s.bucketCounts[i]++
+	}
+	s.count++
+	s.value += value
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	sorted := h.sortedSeries()
+	h.mu.Unlock()
+
+	h.writeHeader(w)
+	for _, s := range sorted {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.bucketCounts[i]
+			writeSample(w, h.name+"_bucket", h.labelNames, s.labelValues, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labelNames, s.labelValues, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labelNames, s.labelValues, "", "", s.value)
+		writeSample(w, h.name+"_count", h.labelNames, s.labelValues, "", "", float64(s.count))
+	}
+}
+
+// funcMetric is an unlabeled metric read from a function, such as connection pool statistics
+type funcMetric struct {
+	name string
+	help string
+	kind string
+	fn   func() float64
+}
+
+func (m funcMetric) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, escapeHelp(m.help), m.name, m.kind)
+	writeSample(w, m.name, nil, nil, "", "", m.fn())
+}
+
+// writeSample writes one sample line, with an optional extra label such as a histogram's le
+func writeSample(w *bufio.Writer, name string, labelNames, labelValues []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labelNames) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, labelName := range labelNames {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", labelName, escapeLabelValue(labelValues[i]))
+		}
+		if extraName != "" {
+			if len(labelNames) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+// countingWriter counts the bytes written for WriteTo
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRegistryWritesTextFormat checks the exposition of counters, gauges and function metrics
+func TestRegistryWritesTextFormat(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("requests_total", "Requests handled.", "method", "route")
+	inFlight := r.NewGauge("in_flight", "Requests in flight.")
+	r.NewGaugeFunc("pool_open", "Open connections.", func() float64 { return 3 })
+
+	requests.Inc("GET", "/v1/tasks/{taskId}")
+	requests.Add(2, "GET", "/v1/tasks/{taskId}")
+	requests.Inc("POST", `/v1/"quoted"`)
+	inFlight.Inc()
+	inFlight.Inc()
+	inFlight.Dec()
+
+	var out strings.Builder
+	if _, err := r.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP in_flight Requests in flight.
+# TYPE in_flight gauge
+in_flight 1
+# HELP pool_open Open connections.
+# TYPE pool_open gauge
+pool_open 3
+# HELP requests_total Requests handled.
+# TYPE requests_total counter
+requests_total{method="GET",route="/v1/tasks/{taskId}"} 3
+requests_total{method="POST",route="/v1/\"quoted\""} 1
+`
+	if out.String() != expected {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+// TestHistogramBuckets checks that buckets are cumulative and bounds are inclusive
+func TestHistogramBuckets(t *testing.T) {
+	r := NewRegistry()
+	latency := r.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1}, "route")
+
+	for _, value := range []float64{0.05, 0.1, 0.5, 3} {
+		latency.Observe(value, "/v1/tasks")
+	}
+
+	var out strings.Builder
+	r.WriteTo(&out)
+
+	for _, line := range []string{
+		`latency_seconds_bucket{route="/v1/tasks",le="0.1"} 2`,
+		`latency_seconds_bucket{route="/v1/tasks",le="1"} 3`,
+		`latency_seconds_bucket{route="/v1/tasks",le="+Inf"} 4`,
+		`latency_seconds_sum{route="/v1/tasks"} 3.65`,
+		`latency_seconds_count{route="/v1/tasks"} 4`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("expected %q in:\n%s", line, out.String())
+		}
+	}
+}
+
+// TestRegistryServeHTTP checks the content type Prometheus expects
+func TestRegistryServeHTTP(t *testing.T) {
+	m := New()
+	m.TasksCreated.Inc()
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "\ntasks_created_total 1\n") {
+		t.Errorf("expected the task counter in:\n%s", rec.Body.String())
+	}
+}
+
+// TestLabelCountMismatchPanics checks that using the wrong number of label values is caught
+func TestLabelCountMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	NewRegistry().NewCounter("logins_total", "Logins.", "result").Inc()
+}
@@ -1,37 +1,44 @@
 package middleware
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/omed0/go-hello-world/handlers"
 	"github.com/omed0/go-hello-world/internal/auth"
 )
 
-// AuthMiddleware creates an authentication middleware that validates API keys
-// and sets user context for protected routes
-func AuthMiddleware(apiCfg *handlers.ApiConfig) func(http.Handler) http.Handler {
+// Authenticate creates an authentication middleware that identifies the caller with the
+// given authenticator and stores the resulting principal in the context for protected routes
+func Authenticate(authenticator auth.Authenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Extract API key from Authorization header
-			apiKey, err := auth.GetAPIKey(r.Header)
+			principal, err := authenticator.Authenticate(r)
 			if err != nil {
-				handlers.RespondWithError(w, http.StatusUnauthorized, err.Error())
-				return
-			}
+				if auth.IsAuthenticationError(err) {
+					handlers.RespondWithError(w, http.StatusUnauthorized, err.Error())
+					return
+				}
 
-			// Validate API key and get user
-			user, err := apiCfg.Queries.GetUserByAPIKey(r.Context(), apiKey)
-			if err != nil {
-				handlers.RespondWithError(w, http.StatusUnauthorized, "Invalid API key")
+				slog.ErrorContext(r.Context(), "Authentication failed", "error", err)
+				handlers.RespondWithError(w, http.StatusInternalServerError, "Authentication failed")
 				return
 			}
 
-			// Set user ID in context for downstream handlers
-			ctx := auth.SetUserIDInContext(r.Context(), user.ID)
-			r = r.WithContext(ctx)
-
-			// Call next handler
-			next.ServeHTTP(w, r)
+			setRequestActor(r, principal)
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
 		})
 	}
 }
+
+// RequireHumanAccount rejects service accounts on endpoints meant for people,
+// such as password, two-factor and API key management
+func RequireHumanAccount(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal, err := auth.PrincipalFromContext(r.Context()); err == nil && principal.IsServiceAccount() {
+			handlers.RespondWithError(w, http.StatusForbidden, "Service accounts cannot use this endpoint")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,39 +1,80 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"regexp"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/handlers"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/logging"
 )
 
+// validRequestID limits the request IDs accepted from clients to what is safe to log and echo
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID is a middleware that identifies every request by the X-Request-ID header, generating one when the
+// client or proxy did not send a usable value. The ID is echoed in the response and attached to every log line.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(logging.RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(logging.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
 // RequestLogger is a middleware that logs HTTP requests
 func RequestLogger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		// Create a wrapped ResponseWriter to capture status code
+		// Create a wrapped ResponseWriter to capture status code and error message
 		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
 		// Call the next handler
 		next.ServeHTTP(wrapped, r)
 
-		// Log the request
-		duration := time.Since(start)
-		log.Printf(
-			"[%s] %s %s - %d - %v",
-			r.Method,
-			r.RequestURI,
-			r.RemoteAddr,
-			wrapped.statusCode,
-			duration,
-		)
+		// Log the request; the request ID, user and organization come from the context
+		level := slog.LevelInfo
+		if wrapped.statusCode >= 500 {
+			level = slog.LevelError
+		} else if wrapped.statusCode >= 400 {
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", wrapped.statusCode),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", r.RemoteAddr),
+		}
+		if query := logging.RedactQuery(r.URL.Query()); query != "" {
+			attrs = append(attrs, slog.String("query", query))
+		}
+		if wrapped.errorMessage != "" {
+			attrs = append(attrs, slog.String("error", wrapped.errorMessage))
+		}
+		slog.LogAttrs(r.Context(), level, "Request completed", attrs...)
 	})
 }
 
-// responseWriter wraps http.ResponseWriter to capture status code
+// setRequestActor records the authenticated account for the request log
+func setRequestActor(r *http.Request, principal *auth.Principal) {
+	logging.SetUser(r.Context(), principal.UserID, principal.AccountType)
+}
+
+// responseWriter wraps http.ResponseWriter to capture status code and error message
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode   int
+	errorMessage string
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -41,13 +82,27 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// RecordError keeps the message of an error response for the request log, passing it on to
+// an outer wrapper so it is not lost when several middleware wrap the response
+func (rw *responseWriter) RecordError(msg string) {
+	rw.errorMessage = msg
+	if recorder, ok := rw.ResponseWriter.(interface{ RecordError(string) }); ok {
+		recorder.RecordError(msg)
+	}
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // Recovery middleware recovers from panics and returns a proper error response
 func Recovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("Panic recovered: %v", err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				slog.ErrorContext(r.Context(), "Panic recovered", "panic", err)
+				handlers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error")
 			}
 		}()
 		next.ServeHTTP(w, r)
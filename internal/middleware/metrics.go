@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/omed0/go-hello-world/internal/metrics"
+)
+
+// unmatchedRoute labels requests that matched no route, so arbitrary paths cannot create new series
+const unmatchedRoute = "unmatched"
+
+// knownMethods are reported as themselves; any other method is counted as OTHER for the same reason
+var knownMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// Metrics creates middleware that counts requests and their latency by chi route pattern, such as
+// /v1/tasks/{taskId}, rather than by raw path
+func Metrics(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			m.HTTPRequestsInFlight.Inc()
+			defer m.HTTPRequestsInFlight.Dec()
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+
+			// The pattern is only complete once the request has been routed
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+
+			method := r.Method
+			if !slices.Contains(knownMethods, method) {
+				method = "OTHER"
+			}
+
+			m.HTTPRequests.Inc(method, route, strconv.Itoa(wrapped.statusCode))
+			m.HTTPRequestDuration.Observe(time.Since(start).Seconds(), method, route)
+		})
+	}
+}
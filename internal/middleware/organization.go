@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/handlers"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/authz"
+	"github.com/omed0/go-hello-world/internal/logging"
+)
+
+// OrganizationHeader selects the organization a request acts in on routes without an organization in the path
+const OrganizationHeader = "X-Organization-ID"
+
+// ActiveOrganization creates middleware that decides which organization a request acts in and gives the
+// principal their role there. The {orgId} path parameter wins, then the X-Organization-ID header, then the
+// caller's default organization. Selecting an organization the caller is not a member of is rejected.
+func ActiveOrganization(engine *authz.Engine) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := auth.PrincipalFromContext(r.Context())
+			if err != nil {
+				handlers.RespondWithError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			orgID, selected, errMsg := requestedOrganization(r)
+			if errMsg != "" {
+				handlers.RespondWithError(w, http.StatusBadRequest, errMsg)
+				return
+			}
+			if !selected && principal.DefaultOrganizationID.Valid {
+				orgID = principal.DefaultOrganizationID.UUID
+			}
+
+			err = engine.Activate(r.Context(), principal, orgID)
+			if errors.Is(err, authz.ErrNotMember) && !selected {
+				// A default organization the caller has since left just means acting outside any organization
+				err = engine.Activate(r.Context(), principal, uuid.Nil)
+			}
+			if errors.Is(err, authz.ErrNotMember) {
+				handlers.RespondWithError(w, http.StatusForbidden, "Access denied to this organization")
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Failed to resolve organization membership", "error", err)
+				handlers.RespondWithError(w, http.StatusInternalServerError, "Failed to resolve organization")
+				return
+			}
+
+			logging.SetOrganization(r.Context(), principal.OrganizationID)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestedOrganization returns the organization the request names in its path or header, if any
+func requestedOrganization(r *http.Request) (uuid.UUID, bool, string) {
+	pathValue := chi.URLParam(r, "orgId")
+	headerValue := r.Header.Get(OrganizationHeader)
+
+	var pathID, headerID uuid.UUID
+	var err error
+	if pathValue != "" {
+		if pathID, err = uuid.Parse(pathValue); err != nil {
+			return uuid.Nil, false, "Invalid organization ID"
+		}
+	}
+	if headerValue != "" {
+		if headerID, err = uuid.Parse(headerValue); err != nil {
+			return uuid.Nil, false, "Invalid " + OrganizationHeader + " header"
+		}
+	}
+
+	switch {
+	case pathValue != "" && headerValue != "" && pathID != headerID:
+		return uuid.Nil, false, OrganizationHeader + " does not match the organization in the path"
+	case pathValue != "":
+		return pathID, true, ""
+	case headerValue != "":
+		return headerID, true, ""
+	default:
+		return uuid.Nil, false, ""
+	}
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/handlers"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/ratelimit"
+)
+
+// RateLimitPolicy is one limit applied by RateLimit. Requests whose key is empty, or whose method is not listed
+// when Methods is set, are not counted against it.
+type RateLimitPolicy struct {
+	Name    string
+	Limit   ratelimit.Limit
+	Key     func(r *http.Request) string
+	Methods []string
+}
+
+// RateLimit creates middleware that takes a token from the caller's bucket for every policy. The tightest policy
+// is reported in RateLimit-* headers, and requests over any limit get 429 with Retry-After. Store errors let the
+// request through so an outage of the limiter does not take the API down with it.
+func RateLimit(store ratelimit.Store, policies ...RateLimitPolicy) func(http.Handler) http.Handler {
+	policies = slices.DeleteFunc(slices.Clone(policies), func(p RateLimitPolicy) bool { return !p.Limit.Enabled() })
+
+	return func(next http.Handler) http.Handler {
+		if len(policies) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var tightest *ratelimit.Result
+			for _, policy := range policies {
+				if len(policy.Methods) > 0 && !slices.Contains(policy.Methods, r.Method) {
+					continue
+				}
+				key := policy.Key(r)
+				if key == "" {
+					continue
+				}
+
+				result, err := store.Take(r.Context(), policy.Name+":"+key, policy.Limit)
+				if err != nil {
+					slog.ErrorContext(r.Context(), "Rate limit check failed", "policy", policy.Name, "error", err)
+					continue
+				}
+				if tightest == nil || tighter(result, *tightest) {
+					tightest = &result
+				}
+			}
+
+			if tightest == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(tightest.Limit.Requests))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(tightest.Reset)))
+			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", tightest.Limit.Requests, ceilSeconds(tightest.Limit.Period)))
+
+			if !tightest.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(tightest.RetryAfter))))
+				handlers.RespondWithError(w, http.StatusTooManyRequests, "Rate limit exceeded, try again later")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// tighter reports whether a is the result the caller should be told about rather than b
+func tighter(a, b ratelimit.Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// RateLimitByIP keys requests by client address, as resolved by clientIP
+func RateLimitByIP(clientIP func(r *http.Request) string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return "ip:" + clientIP(r)
+	}
+}
+
+// RateLimitByCaller keys authenticated requests by API key, or by user for other credentials,
+// so each key of an account gets its own budget
+func RateLimitByCaller(r *http.Request) string {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil {
+		return ""
+	}
+	if principal.APIKeyID != uuid.Nil {
+		return "key:" + principal.APIKeyID.String()
+	}
+	return "user:" + principal.UserID.String()
+}
+
+// RateLimitByOrganization keys authenticated requests by the active organization, shared by all its members
+func RateLimitByOrganization(r *http.Request) string {
+	principal, err := auth.PrincipalFromContext(r.Context())
+	if err != nil || !principal.OrganizationID.Valid {
+		return ""
+	}
+	return "org:" + principal.OrganizationID.UUID.String()
+}
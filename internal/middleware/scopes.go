@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/omed0/go-hello-world/handlers"
+	"github.com/omed0/go-hello-world/internal/auth"
+)
+
+// RequireScope creates middleware that rejects scoped API keys lacking the required scope.
+// Requests authenticated with a session, a password or an unscoped credential are always allowed.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := auth.PrincipalFromContext(r.Context())
+			if err != nil {
+				handlers.RespondWithError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			if !principal.HasScope(scope) {
+				handlers.RespondWithError(w, http.StatusForbidden, "API key is missing required scope: "+scope)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
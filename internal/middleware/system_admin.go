@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/handlers"
+	"github.com/omed0/go-hello-world/internal/auth"
+)
+
+// SystemAdminStore reports whether an account is an enabled system administrator; *database.Queries implements it
+type SystemAdminStore interface {
+	IsSystemAdmin(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// RequireSystemAdmin creates middleware that only lets system administrators through. Organization roles do not
+// count, and the flag is read on every request so revoking it takes effect immediately. Scoped API keys and
+// service accounts are always rejected.
+func RequireSystemAdmin(store SystemAdminStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := auth.PrincipalFromContext(r.Context())
+			if err != nil {
+				handlers.RespondWithError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			if principal.IsServiceAccount() || !principal.HasScope(auth.ScopeAll) {
+				handlers.RespondWithError(w, http.StatusForbidden, "System administrator access required")
+				return
+			}
+
+			admin, err := store.IsSystemAdmin(r.Context(), principal.UserID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				handlers.RespondWithError(w, http.StatusInternalServerError, "Failed to check permissions")
+				return
+			}
+			if !admin {
+				handlers.RespondWithError(w, http.StatusForbidden, "System administrator access required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/omed0/go-hello-world/internal/logging"
+	"github.com/omed0/go-hello-world/internal/tracing"
+)
+
+// traceparentHeader carries W3C trace context from the caller
+const traceparentHeader = "traceparent"
+
+// Trace creates middleware that starts a server span for every request, continuing the caller's trace when the
+// request has a valid traceparent header. The span is named after the chi route pattern once routing is done.
+func Trace(tracer *tracing.Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if tracer == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if remote, ok := tracing.ParseTraceparent(r.Header.Get(traceparentHeader)); ok {
+				ctx = tracing.ContextWithRemoteSpanContext(ctx, remote)
+			}
+
+			ctx, span := tracer.Start(ctx, r.Method, tracing.SpanKindServer,
+				tracing.String("http.request.method", r.Method),
+				tracing.String("url.path", r.URL.Path),
+				tracing.String("client.address", r.RemoteAddr),
+				tracing.String("request.id", logging.RequestID(ctx)),
+			)
+			defer span.End()
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				span.SetName(r.Method + " " + rctx.RoutePattern())
+				span.SetAttributes(tracing.String("http.route", rctx.RoutePattern()))
+			}
+			span.SetAttributes(tracing.Int("http.response.status_code", wrapped.statusCode))
+			if wrapped.statusCode >= 500 {
+				span.SetError(wrapped.errorMessage)
+			}
+		})
+	}
+}
+
+// TraceStage wraps a middleware in its own span, covering the middleware's work until it calls the next
+// handler or responds. Later stages and the handler are traced as siblings rather than inside it.
+func TraceStage(tracer *tracing.Tracer, name string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	if tracer == nil {
+		return mw
+	}
+
+	return func(next http.Handler) http.Handler {
+		// Reached when the stage passes the request on: its span ends and the request span becomes current again
+		handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			stage := tracing.SpanFromContext(r.Context())
+			stage.End()
+			next.ServeHTTP(w, r.WithContext(tracing.ContextWithSpan(r.Context(), stage.Parent())))
+		}))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := tracer.Start(r.Context(), "middleware "+name, tracing.SpanKindInternal)
+			defer span.End()
+			handler.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
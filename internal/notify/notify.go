@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message is a notification addressed to a user, such as a password reset token
+type Message struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Recipient string    `json:"recipient"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Sink delivers notifications to users. Implementations can send email, SMS or chat messages.
+type Sink interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FileSink appends every message as a JSON line to a file. It is the default sink
+// for development and for deployments that forward the file to another system.
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSink creates a sink that writes to the given file, creating it if needed
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Send appends the message to the sink's file
+func (s *FileSink) Send(ctx context.Context, msg Message) error {
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,392 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// clockSkew is the leeway allowed when checking token times
+const clockSkew = time.Minute
+
+// keySetRefreshInterval limits how often an unknown key ID can trigger a JWKS refetch
+const keySetRefreshInterval = 10 * time.Second
+
+// ID token errors
+var (
+	ErrMalformedToken   = errors.New("oidc: malformed ID token")
+	ErrUnsupportedAlg   = errors.New("oidc: unsupported signing algorithm")
+	ErrUnknownKey       = errors.New("oidc: ID token signed with an unknown key")
+	ErrInvalidSignature = errors.New("oidc: invalid ID token signature")
+	ErrWrongIssuer      = errors.New("oidc: ID token issuer does not match")
+	ErrWrongAudience    = errors.New("oidc: ID token was not issued for this client")
+	ErrTokenExpired     = errors.New("oidc: ID token has expired")
+	ErrTokenNotYetValid = errors.New("oidc: ID token issued in the future")
+	ErrNonceMismatch    = errors.New("oidc: ID token nonce does not match")
+	ErrMissingSubject   = errors.New("oidc: ID token has no subject")
+	ErrWrongAuthParty   = errors.New("oidc: ID token authorized party does not match")
+)
+
+// IDToken holds the validated claims of an ID token
+type IDToken struct {
+	Issuer            string
+	Subject           string
+	Audience          []string
+	Expiry            time.Time
+	IssuedAt          time.Time
+	Nonce             string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+
+	// Claims holds every claim, for role and organization mapping
+	Claims map[string]interface{}
+}
+
+// VerifyIDToken validates the signature, issuer, audience, times and nonce of an ID token
+func (c *Client) VerifyIDToken(ctx context.Context, provider ProviderConfig, rawToken, nonce string) (*IDToken, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	metadata, err := c.Discover(ctx, provider.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := c.signingKey(ctx, metadata.JWKSURI, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	token := &IDToken{
+		Issuer:            stringClaim(claims, "iss"),
+		Subject:           stringClaim(claims, "sub"),
+		Audience:          StringsClaim(claims, "aud"),
+		Expiry:            timeClaim(claims, "exp"),
+		IssuedAt:          timeClaim(claims, "iat"),
+		Nonce:             stringClaim(claims, "nonce"),
+		Email:             stringClaim(claims, "email"),
+		PreferredUsername: stringClaim(claims, "preferred_username"),
+		Name:              stringClaim(claims, "name"),
+		Claims:            claims,
+	}
+	token.EmailVerified, _ = claims["email_verified"].(bool)
+
+	if strings.TrimRight(token.Issuer, "/") != strings.TrimRight(metadata.Issuer, "/") {
+		return nil, ErrWrongIssuer
+	}
+
+	if !contains(token.Audience, provider.ClientID) {
+		return nil, ErrWrongAudience
+	}
+	if azp := stringClaim(claims, "azp"); len(token.Audience) > 1 && azp != "" && azp != provider.ClientID {
+		return nil, ErrWrongAuthParty
+	}
+
+	now := time.Now()
+	if token.Expiry.IsZero() || now.After(token.Expiry.Add(clockSkew)) {
+		return nil, ErrTokenExpired
+	}
+	if token.IssuedAt.After(now.Add(clockSkew)) {
+		return nil, ErrTokenNotYetValid
+	}
+
+	if token.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	if token.Subject == "" {
+		return nil, ErrMissingSubject
+	}
+
+	return token, nil
+}
+
+// keySet is a cached JWKS document
+type keySet struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// signingKey returns the key with the given ID, refetching the JWKS once if it is unknown
+// so that key rotation at the issuer is picked up
+func (c *Client) signingKey(ctx context.Context, jwksURI, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	set := c.keySets[jwksURI]
+	c.mu.Unlock()
+
+	if set != nil {
+		if key, ok := set.lookup(kid); ok {
+			return key, nil
+		}
+		if time.Since(set.fetchedAt) < keySetRefreshInterval {
+			return nil, ErrUnknownKey
+		}
+	}
+
+	set, err := c.fetchKeySet(ctx, jwksURI)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.keySets[jwksURI] = set
+	c.mu.Unlock()
+
+	if key, ok := set.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// lookup finds a key by ID; tokens without a key ID are accepted only if the set has a single key
+func (s *keySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// jsonWebKey is a single entry of a JWKS document
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (c *Client) fetchKeySet(ctx context.Context, jwksURI string) (*keySet, error) {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := c.getJSON(ctx, jwksURI, &doc); err != nil {
+		return nil, fmt.Errorf("oidc: fetching JWKS failed: %w", err)
+	}
+
+	set := &keySet{keys: make(map[string]crypto.PublicKey), fetchedAt: time.Now()}
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Skip key types we do not support rather than failing the whole set
+			continue
+		}
+		set.keys[jwk.Kid] = key
+	}
+
+	return set, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, ErrUnsupportedAlg
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+
+	return nil, ErrUnsupportedAlg
+}
+
+// verifySignature checks a JWS signature; only asymmetric algorithms are accepted
+func verifySignature(alg string, key crypto.PublicKey, signingInput string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		return ErrUnsupportedAlg
+	}
+
+	digest := digestOf(hash, signingInput)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return ErrUnsupportedAlg
+		}
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return ErrUnsupportedAlg
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+
+	return ErrUnsupportedAlg
+}
+
+func digestOf(hash crypto.Hash, input string) []byte {
+	switch hash {
+	case crypto.SHA384:
+		sum := sha512.Sum384([]byte(input))
+		return sum[:]
+	case crypto.SHA512:
+		sum := sha512.Sum512([]byte(input))
+		return sum[:]
+	default:
+		sum := sha256.Sum256([]byte(input))
+		return sum[:]
+	}
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+func timeClaim(claims map[string]interface{}, name string) time.Time {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(int64(value), 0)
+}
+
+// StringsClaim returns a claim that may be a single string or a list of strings
+func StringsClaim(claims map[string]interface{}, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// MapRole returns the highest role the token's role claim maps to, or the provider's default role
+func MapRole(provider ProviderConfig, token *IDToken) string {
+	best := -1
+	if provider.RoleClaim != "" {
+		for _, value := range StringsClaim(token.Claims, provider.RoleClaim) {
+			role, ok := provider.RoleMapping[value]
+			if !ok {
+				continue
+			}
+			for i, r := range mappableRoles {
+				if r == role && i > best {
+					best = i
+				}
+			}
+		}
+	}
+
+	if best >= 0 {
+		return mappableRoles[best]
+	}
+	if provider.DefaultRole != "" {
+		return provider.DefaultRole
+	}
+	return mappableRoles[0]
+}
+
+func contains(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+// Package oidc implements the relying-party side of OpenID Connect: discovery,
+// the authorization code flow with PKCE and ID token validation against the issuer's JWKS.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Roles that claims can be mapped to, lowest to highest
+var mappableRoles = []string{"user", "moderator", "admin"}
+
+// metadataTTL is how long discovery documents are cached
+const metadataTTL = time.Hour
+
+// OIDC errors
+var (
+	ErrMissingIssuer   = errors.New("oidc: issuer is required")
+	ErrMissingClientID = errors.New("oidc: client_id is required")
+	ErrInvalidRole     = errors.New("oidc: role mapping targets an unknown role")
+	ErrIssuerMismatch  = errors.New("oidc: discovery document issuer does not match")
+	ErrNoIDToken       = errors.New("oidc: token response has no id_token")
+)
+
+// ProviderConfig configures one OpenID Connect provider
+type ProviderConfig struct {
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	RedirectURL  string   `json:"redirect_url,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+
+	// RoleClaim names the claim (string or list of strings) whose values are looked up in RoleMapping
+	RoleClaim   string            `json:"role_claim,omitempty"`
+	RoleMapping map[string]string `json:"role_mapping,omitempty"`
+	DefaultRole string            `json:"default_role,omitempty"`
+
+	// OrgClaim names a claim holding an organization ID or name; only used by the global provider
+	OrgClaim string `json:"org_claim,omitempty"`
+}
+
+// Validate checks that the provider is usable
+func (p ProviderConfig) Validate() error {
+	if p.Issuer == "" {
+		return ErrMissingIssuer
+	}
+	if p.ClientID == "" {
+		return ErrMissingClientID
+	}
+	if p.DefaultRole != "" && !isMappableRole(p.DefaultRole) {
+		return ErrInvalidRole
+	}
+	for _, role := range p.RoleMapping {
+		if !isMappableRole(role) {
+			return ErrInvalidRole
+		}
+	}
+	return nil
+}
+
+// scopes returns the requested scopes, always including openid
+func (p ProviderConfig) scopes() []string {
+	scopes := []string{"openid"}
+	if len(p.Scopes) == 0 {
+		return append(scopes, "profile", "email")
+	}
+	for _, scope := range p.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// Metadata is the subset of the discovery document this package uses
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint,omitempty"`
+}
+
+// TokenResponse is the token endpoint response of the authorization code grant
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Client talks to OpenID providers and caches their discovery documents and signing keys
+type Client struct {
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	metadata map[string]cachedMetadata
+	keySets  map[string]*keySet
+}
+
+type cachedMetadata struct {
+	metadata  *Metadata
+	fetchedAt time.Time
+}
+
+// NewClient creates a client; a nil httpClient uses a default with a timeout
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Client{
+		httpClient: httpClient,
+		metadata:   make(map[string]cachedMetadata),
+		keySets:    make(map[string]*keySet),
+	}
+}
+
+// Discover fetches and caches the issuer's discovery document
+func (c *Client) Discover(ctx context.Context, issuer string) (*Metadata, error) {
+	issuer = strings.TrimRight(issuer, "/")
+
+	c.mu.Lock()
+	cached, ok := c.metadata[issuer]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < metadataTTL {
+		return cached.metadata, nil
+	}
+
+	var metadata Metadata
+	if err := c.getJSON(ctx, issuer+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, fmt.Errorf("oidc: discovery failed: %w", err)
+	}
+
+	if strings.TrimRight(metadata.Issuer, "/") != issuer {
+		return nil, ErrIssuerMismatch
+	}
+
+	c.mu.Lock()
+	c.metadata[issuer] = cachedMetadata{metadata: &metadata, fetchedAt: time.Now()}
+	c.mu.Unlock()
+
+	return &metadata, nil
+}
+
+// AuthCodeURL builds the authorization request URL for the code flow with PKCE (S256)
+func (c *Client) AuthCodeURL(ctx context.Context, provider ProviderConfig, state, nonce, codeChallenge string) (string, error) {
+	metadata, err := c.Discover(ctx, provider.Issuer)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	params := authURL.Query()
+	params.Set("response_type", "code")
+	params.Set("client_id", provider.ClientID)
+	params.Set("redirect_uri", provider.RedirectURL)
+	params.Set("scope", strings.Join(provider.scopes(), " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+	authURL.RawQuery = params.Encode()
+
+	return authURL.String(), nil
+}
+
+// Exchange redeems an authorization code at the token endpoint
+func (c *Client) Exchange(ctx context.Context, provider ProviderConfig, code, codeVerifier string) (*TokenResponse, error) {
+	metadata, err := c.Discover(ctx, provider.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", provider.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if provider.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(provider.ClientID), url.QueryEscape(provider.ClientSecret))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tokens TokenResponse
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, err
+	}
+	if tokens.IDToken == "" {
+		return nil, ErrNoIDToken
+	}
+
+	return &tokens, nil
+}
+
+// getJSON fetches a URL and decodes the JSON body
+func (c *Client) getJSON(ctx context.Context, target string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", target, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// NewPKCEVerifier returns a random code verifier (RFC 7636)
+func NewPKCEVerifier() (string, error) {
+	return randomString(32)
+}
+
+// PKCEChallenge returns the S256 code challenge for a verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// NewNonce returns a random value to bind an ID token to the login that requested it
+func NewNonce() (string, error) {
+	return randomString(16)
+}
+
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func isMappableRole(role string) bool {
+	for _, r := range mappableRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/omed0/go-hello-world/internal/oidc"
+	"github.com/omed0/go-hello-world/internal/oidc/oidctest"
+)
+
+const redirectURL = "http://localhost:8080/v1/auth/oidc/callback"
+
+func newTestProvider(t *testing.T) (*oidctest.Issuer, oidc.ProviderConfig) {
+	t.Helper()
+
+	issuer, err := oidctest.NewIssuer("test-client", "test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(issuer.Close)
+
+	return issuer, oidc.ProviderConfig{
+		Issuer:       issuer.URL,
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		RedirectURL:  redirectURL,
+		RoleClaim:    "groups",
+		RoleMapping:  map[string]string{"engineering": "user", "platform-admins": "admin"},
+	}
+}
+
+// authorize follows the authorization URL like a browser would and returns the code from the redirect
+func authorize(t *testing.T, authURL, wantState string) string {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned %d", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := location.Query().Get("state"); got != wantState {
+		t.Fatalf("state = %q, want %q", got, wantState)
+	}
+	return location.Query().Get("code")
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	issuer, provider := newTestProvider(t)
+	issuer.Claims = map[string]interface{}{
+		"sub":                "user-123",
+		"email":              "jane@example.com",
+		"preferred_username": "jane",
+		"groups":             []string{"engineering", "platform-admins"},
+	}
+
+	client := oidc.NewClient(nil)
+	ctx := context.Background()
+
+	verifier, _ := oidc.NewPKCEVerifier()
+	nonce, _ := oidc.NewNonce()
+
+	authURL, err := client.AuthCodeURL(ctx, provider, "state-1", nonce, oidc.PKCEChallenge(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := authorize(t, authURL, "state-1")
+
+	tokens, err := client.Exchange(ctx, provider, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idToken, err := client.VerifyIDToken(ctx, provider, tokens.IDToken, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if idToken.Subject != "user-123" || idToken.PreferredUsername != "jane" || idToken.Email != "jane@example.com" {
+		t.Errorf("unexpected token claims: %+v", idToken)
+	}
+	if role := oidc.MapRole(provider, idToken); role != "admin" {
+		t.Errorf("MapRole = %q, want admin", role)
+	}
+
+	// Codes are single use
+	if _, err := client.Exchange(ctx, provider, code, verifier); err == nil {
+		t.Error("expected a redeemed code to be rejected")
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	_, provider := newTestProvider(t)
+	client := oidc.NewClient(nil)
+	ctx := context.Background()
+
+	verifier, _ := oidc.NewPKCEVerifier()
+	authURL, err := client.AuthCodeURL(ctx, provider, "state", "nonce", oidc.PKCEChallenge(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := authorize(t, authURL, "state")
+
+	other, _ := oidc.NewPKCEVerifier()
+	if _, err := client.Exchange(ctx, provider, code, other); err == nil {
+		t.Error("expected exchange with the wrong code verifier to fail")
+	}
+}
+
+func TestVerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	issuer, provider := newTestProvider(t)
+	client := oidc.NewClient(nil)
+	ctx := context.Background()
+
+	sign := func(claims map[string]interface{}) string {
+		token, err := issuer.SignIDToken(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	base := map[string]interface{}{"sub": "user-123"}
+
+	valid := sign(issuer.IDTokenClaims("n", base))
+	if _, err := client.VerifyIDToken(ctx, provider, valid, "n"); err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		nonce string
+		want  error
+	}{
+		{"wrong nonce", valid, "other", oidc.ErrNonceMismatch},
+		{"tampered signature", valid[:len(valid)-4] + "AAAA", "n", oidc.ErrInvalidSignature},
+		{"wrong audience", sign(merge(issuer.IDTokenClaims("n", base), map[string]interface{}{"aud": "someone-else"})), "n", oidc.ErrWrongAudience},
+		{"wrong issuer", sign(merge(issuer.IDTokenClaims("n", base), map[string]interface{}{"iss": "https://evil.example"})), "n", oidc.ErrWrongIssuer},
+		{"expired", sign(merge(issuer.IDTokenClaims("n", base), map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})), "n", oidc.ErrTokenExpired},
+		{"missing subject", sign(issuer.IDTokenClaims("n", nil)), "n", oidc.ErrMissingSubject},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.VerifyIDToken(ctx, provider, tt.token, tt.nonce); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenPicksUpRotatedKeys(t *testing.T) {
+	issuer, provider := newTestProvider(t)
+	client := oidc.NewClient(nil)
+	ctx := context.Background()
+
+	first, _ := issuer.SignIDToken(issuer.IDTokenClaims("n", map[string]interface{}{"sub": "a"}))
+	if _, err := client.VerifyIDToken(ctx, provider, first, "n"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := issuer.RotateKey(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The new key ID is unknown to the cached JWKS, but the cache is too fresh to refetch yet
+	rotated, _ := issuer.SignIDToken(issuer.IDTokenClaims("n", map[string]interface{}{"sub": "a"}))
+	if _, err := client.VerifyIDToken(ctx, provider, rotated, "n"); !errors.Is(err, oidc.ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey right after rotation, got %v", err)
+	}
+
+	// A fresh client fetches the current key set
+	if _, err := oidc.NewClient(nil).VerifyIDToken(ctx, provider, rotated, "n"); err != nil {
+		t.Errorf("expected rotated key to verify with a fresh key set, got %v", err)
+	}
+}
+
+func TestMapRoleDefaults(t *testing.T) {
+	provider := oidc.ProviderConfig{RoleClaim: "groups", RoleMapping: map[string]string{"mods": "moderator"}}
+
+	token := &oidc.IDToken{Claims: map[string]interface{}{"groups": "mods"}}
+	if role := oidc.MapRole(provider, token); role != "moderator" {
+		t.Errorf("MapRole with string claim = %q, want moderator", role)
+	}
+
+	token = &oidc.IDToken{Claims: map[string]interface{}{"groups": []interface{}{"unmapped"}}}
+	if role := oidc.MapRole(provider, token); role != "user" {
+		t.Errorf("MapRole without a match = %q, want user", role)
+	}
+
+	provider.DefaultRole = "moderator"
+	if role := oidc.MapRole(provider, token); role != "moderator" {
+		t.Errorf("MapRole with default = %q, want moderator", role)
+	}
+}
+
+func TestProviderConfigValidate(t *testing.T) {
+	if err := (oidc.ProviderConfig{ClientID: "c"}).Validate(); err != oidc.ErrMissingIssuer {
+		t.Errorf("expected ErrMissingIssuer, got %v", err)
+	}
+	if err := (oidc.ProviderConfig{Issuer: "https://idp", ClientID: "c", RoleMapping: map[string]string{"g": "owner"}}).Validate(); err != oidc.ErrInvalidRole {
+		t.Errorf("expected ErrInvalidRole, got %v", err)
+	}
+}
+
+func merge(claims, overrides map[string]interface{}) map[string]interface{} {
+	for k, v := range overrides {
+		claims[k] = v
+	}
+	return claims
+}
+
+func TestValidatePublicIssuer(t *testing.T) {
+	tests := []struct {
+		issuer string
+		want   error
+	}{
+		{"https://accounts.example.com", nil},
+		{"https://idp.example.com/realms/acme", nil},
+		{"http://accounts.example.com", oidc.ErrInsecureIssuer},
+		{"https://", oidc.ErrInsecureIssuer},
+		{"https://localhost:8443", oidc.ErrNonPublicIssuer},
+		{"https://127.0.0.1", oidc.ErrNonPublicIssuer},
+		{"https://10.0.0.5", oidc.ErrNonPublicIssuer},
+		{"https://169.254.169.254", oidc.ErrNonPublicIssuer},
+		{"https://[::1]", oidc.ErrNonPublicIssuer},
+		{"https://[::ffff:192.168.1.1]", oidc.ErrNonPublicIssuer},
+	}
+
+	for _, tt := range tests {
+		if err := oidc.ValidatePublicIssuer(tt.issuer); err != tt.want {
+			t.Errorf("ValidatePublicIssuer(%q) = %v, want %v", tt.issuer, err, tt.want)
+		}
+	}
+}
+
+func TestPublicClientRefusesLocalAddresses(t *testing.T) {
+	_, provider := newTestProvider(t)
+
+	_, err := oidc.NewPublicClient().Discover(context.Background(), provider.Issuer)
+	if !errors.Is(err, oidc.ErrNonPublicAddress) {
+		t.Fatalf("expected ErrNonPublicAddress, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Issuer URL errors
+var (
+	ErrInsecureIssuer   = errors.New("oidc: issuer must be an https URL")
+	ErrNonPublicIssuer  = errors.New("oidc: issuer must not be a loopback, private or link-local address")
+	ErrNonPublicAddress = errors.New("oidc: refusing to connect to a non-public address")
+)
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which net/netip does not treat as private
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// ValidatePublicIssuer checks that an issuer configured by a tenant is an https URL that does not name
+// the server's own network. Host names are checked again when connecting, since they may resolve anywhere.
+func ValidatePublicIssuer(issuer string) error {
+	u, err := url.Parse(issuer)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return ErrInsecureIssuer
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrNonPublicIssuer
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !IsPublicAddr(addr) {
+		return ErrNonPublicIssuer
+	}
+	return nil
+}
+
+// IsPublicAddr reports whether an address is reachable on the public internet rather than
+// a loopback, private, link-local, multicast or unspecified one
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// NewPublicClient creates a client for providers configured by tenants. It only connects to public
+// addresses, checked after DNS resolution, and ignores proxy settings so the check cannot be bypassed.
+func NewPublicClient() *Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrNonPublicAddress, address)
+			}
+			if !IsPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrNonPublicAddress, address)
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, address)
+		},
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	return NewClient(&http.Client{Timeout: 10 * time.Second, Transport: transport})
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets are dropped from a MemoryStore
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory. Each instance of the server limits on its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updated   time.Time
+	idleUntil time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Take removes a token from the bucket for key if one is available
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests), updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = limit.refill(b.tokens, now.Sub(b.updated))
+	b.updated = now
+	if b.tokens < 1 {
+		return limit.result(b.tokens, false), nil
+	}
+
+	b.tokens--
+	b.idleUntil = now.Add(limit.Period)
+	return limit.result(b.tokens, true), nil
+}
+
+// sweep drops buckets that have been idle long enough to be full again, at most once per sweepInterval
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.After(b.idleUntil) {
+			delete(s.buckets, key)
+		}
+	}
+}
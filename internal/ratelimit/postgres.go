@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// PostgresQueries is the subset of database queries used by PostgresStore
+type PostgresQueries interface {
+	TakeRateLimitToken(ctx context.Context, arg database.TakeRateLimitTokenParams) (float64, error)
+	GetRateLimitTokens(ctx context.Context, arg database.GetRateLimitTokensParams) (float64, error)
+	DeleteIdleRateLimitBuckets(ctx context.Context) error
+}
+
+// PostgresStore keeps buckets in the database so that every instance of the server shares the same limits
+type PostgresStore struct {
+	queries PostgresQueries
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewPostgresStore creates a store backed by the rate_limit_buckets table
+func NewPostgresStore(queries PostgresQueries) *PostgresStore {
+	return &PostgresStore{queries: queries}
+}
+
+// Take removes a token from the bucket for key in a single statement, so concurrent requests cannot overspend it
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.sweep(ctx)
+
+	tokens, err := s.queries.TakeRateLimitToken(ctx, database.TakeRateLimitTokenParams{
+		Key:           key,
+		Capacity:      float64(limit.Requests),
+		PeriodSeconds: limit.Period.Seconds(),
+		Rate:          limit.rate(),
+	})
+	if err == nil {
+		return limit.result(tokens, true), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Result{}, err
+	}
+
+	// The bucket is empty; read it back to tell the caller how long to wait
+	tokens, err = s.queries.GetRateLimitTokens(ctx, database.GetRateLimitTokensParams{
+		Key:      key,
+		Capacity: float64(limit.Requests),
+		Rate:     limit.rate(),
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return limit.result(tokens, false), nil
+}
+
+// sweep deletes idle buckets at most once per sweepInterval
+func (s *PostgresStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	now := time.Now()
+	due := now.Sub(s.lastSweep) >= sweepInterval
+	if due {
+		s.lastSweep = now
+	}
+	s.mu.Unlock()
+
+	if due {
+		if err := s.queries.DeleteIdleRateLimitBuckets(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to delete idle rate limit buckets", "error", err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Package ratelimit implements token bucket rate limiting with in-memory and Postgres-backed state
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows bursts of up to Requests requests, refilled evenly over Period. The zero Limit disables limiting.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit parses limits such as "20/1m" or "1000/1h"; an empty value, "0" or "off" disables the limit
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" || strings.EqualFold(value, "off") {
+		return Limit{}, nil
+	}
+
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected requests/period such as 20/1m", value)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("invalid request count in rate limit %q", value)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid period in rate limit %q", value)
+	}
+
+	if n == 0 {
+		return Limit{}, nil
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// Enabled reports whether the limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// String formats the limit the way ParseLimit accepts it
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// rate is the number of tokens added back per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// refill returns the tokens in a bucket after elapsed time, capped at the burst size
+func (l Limit) refill(tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(l.Requests), tokens+elapsed.Seconds()*l.rate())
+}
+
+// result describes a bucket holding the given tokens after a request was allowed or denied
+func (l Limit) result(tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     l,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     l.wait(float64(l.Requests) - tokens),
+	}
+	if !allowed {
+		result.RetryAfter = l.wait(1 - tokens)
+	}
+	return result
+}
+
+// wait returns how long it takes to refill the given number of tokens
+func (l Limit) wait(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(tokens / l.rate() * float64(time.Second)))
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	Limit   Limit
+	// Remaining is the number of requests that can still be made right away
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long to wait before the next request is allowed, zero when this one was
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets. Take removes one token from the bucket for key, creating a full bucket if needed.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestParseLimit checks accepted formats and that malformed limits are rejected
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("20/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit != (Limit{Requests: 20, Period: time.Minute}) {
+		t.Errorf("unexpected limit %+v", limit)
+	}
+
+	for _, value := range []string{"", "0", "off", "0/1m"} {
+		limit, err := ParseLimit(value)
+		if err != nil || limit.Enabled() {
+			t.Errorf("expected %q to disable the limit, got %+v, %v", value, limit, err)
+		}
+	}
+
+	for _, value := range []string{"20", "x/1m", "-1/1m", "20/soon", "20/0s"} {
+		if _, err := ParseLimit(value); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
+
+// TestMemoryStoreBurstAndRefill checks that a bucket allows a burst, then refills at the limit's rate
+func TestMemoryStoreBurstAndRefill(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(ctx, "ip:192.0.2.1", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed || result.Remaining != i {
+			t.Fatalf("expected request to be allowed with %d remaining, got %+v", i, result)
+		}
+	}
+
+	result, _ := store.Take(ctx, "ip:192.0.2.1", limit)
+	if result.Allowed || result.RetryAfter != time.Second || result.Reset != 3*time.Second {
+		t.Fatalf("expected denial with a one second wait, got %+v", result)
+	}
+
+	if other, _ := store.Take(ctx, "ip:192.0.2.2", limit); !other.Allowed {
+		t.Error("expected buckets to be independent per key")
+	}
+
+	now = now.Add(time.Second)
+	if result, _ := store.Take(ctx, "ip:192.0.2.1", limit); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("expected one token after a second, got %+v", result)
+	}
+
+	now = now.Add(time.Hour)
+	if result, _ := store.Take(ctx, "ip:192.0.2.1", limit); result.Remaining != 2 {
+		t.Errorf("expected refill to stop at the burst size, got %+v", result)
+	}
+}
+
+// TestMemoryStoreSweepsIdleBuckets checks that buckets idle for a whole period are dropped
+func TestMemoryStoreSweepsIdleBuckets(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	store.Take(ctx, "user:a", Limit{Requests: 10, Period: time.Minute})
+	store.Take(ctx, "user:b", Limit{Requests: 10, Period: time.Hour})
+
+	now = now.Add(2 * time.Minute)
+	store.Take(ctx, "user:c", Limit{Requests: 10, Period: time.Minute})
+
+	if _, ok := store.buckets["user:a"]; ok {
+		t.Error("expected the idle bucket to be dropped")
+	}
+	if len(store.buckets) != 2 {
+		t.Errorf("expected 2 buckets, got %d", len(store.buckets))
+	}
+}
@@ -0,0 +1,199 @@
+// Package tlsreload serves TLS certificates that can be replaced on disk without restarting the server.
+// Certificates are reloaded when the files change or when Reload is called, for example on SIGHUP.
+package tlsreload
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Client certificate modes
+const (
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional"
+	ClientAuthRequire  = "require"
+)
+
+// ErrNoClientCAs is returned when a client CA file contains no certificates
+var ErrNoClientCAs = errors.New("client CA file contains no PEM certificates")
+
+// ErrUnknownClientAuth is returned for a client certificate mode other than none, optional or require
+var ErrUnknownClientAuth = errors.New("unknown client certificate mode, expected none, optional or require")
+
+// Reloader holds the current server certificate and client CA pool
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	clientAuth   tls.ClientAuthType
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+}
+
+// New loads the certificate, key and optional client CA bundle; clientAuth is one of the ClientAuth constants,
+// or empty to verify client certificates only when a client CA file is given
+func New(certFile, keyFile, clientCAFile, clientAuth string) (*Reloader, error) {
+	authType, err := parseClientAuth(clientAuth, clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		clientAuth:   authType,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func parseClientAuth(mode, clientCAFile string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "":
+		// Without an explicit mode, client certificates are verified when a client CA is configured
+		if clientCAFile != "" {
+			return tls.VerifyClientCertIfGiven, nil
+		}
+		return tls.NoClientCert, nil
+	case ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthOptional, ClientAuthRequire:
+		if clientCAFile == "" {
+			return tls.NoClientCert, fmt.Errorf("client certificate mode %q needs a client CA file", mode)
+		}
+		if mode == ClientAuthOptional {
+			return tls.VerifyClientCertIfGiven, nil
+		}
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, ErrUnknownClientAuth
+	}
+}
+
+// Reload reads the files again; on error the previously loaded certificates stay in use
+func (r *Reloader) Reload() error {
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("reading client CA file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return ErrNoClientCAs
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	r.mu.Unlock()
+	return nil
+}
+
+// Changed reports whether any of the files was modified since the last successful load
+func (r *Reloader) Changed() bool {
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for name, modTime := range modTimes {
+		if !modTime.Equal(r.modTimes[name]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) statFiles() (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time, 3)
+	for _, name := range []string{r.certFile, r.keyFile, r.clientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[name] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// Watch checks the files every interval and reloads them when they change, until ctx is done
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.Changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				slog.Error("Failed to reload TLS certificates", "error", err)
+				continue
+			}
+			slog.Info("Reloaded TLS certificates")
+		}
+	}
+}
+
+// TLSConfig returns a server configuration that always uses the most recently loaded certificates
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetCertificate:     r.getCertificate,
+		GetConfigForClient: r.getConfigForClient,
+	}
+}
+
+func (r *Reloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *Reloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// The client CA pool can change too, so each handshake gets a config built from the current files
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+		Certificates: []tls.Certificate{*r.cert},
+		ClientAuth:   r.clientAuth,
+		ClientCAs:    r.clientCAs,
+	}, nil
+}
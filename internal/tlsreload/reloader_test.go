@@ -0,0 +1,159 @@
+package tlsreload
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a fresh self-signed certificate and key for localhost with the given serial
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// servedSerial performs a handshake against the reloader's config and returns the server certificate serial
+func servedSerial(t *testing.T, r *Reloader) int64 {
+	t.Helper()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", r.TLSConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn.(*tls.Conn).Handshake()
+		conn.Close()
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestReloadPicksUpReplacedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, 1)
+
+	r, err := New(certFile, keyFile, "", ClientAuthNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := servedSerial(t, r); got != 1 {
+		t.Fatalf("served serial %d, want 1", got)
+	}
+	if r.Changed() {
+		t.Error("expected no change right after loading")
+	}
+
+	writeCertificate(t, certFile, keyFile, 2)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+
+	if !r.Changed() {
+		t.Fatal("expected the replaced certificate to be detected")
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := servedSerial(t, r); got != 2 {
+		t.Errorf("served serial %d after reload, want 2", got)
+	}
+}
+
+func TestReloadKeepsCertificateOnError(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, 1)
+
+	r, err := New(certFile, keyFile, "", ClientAuthNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A half-written key must not take the server down
+	os.WriteFile(keyFile, []byte("not a key"), 0o600)
+	if err := r.Reload(); err == nil {
+		t.Fatal("expected reload of an invalid key to fail")
+	}
+	if got := servedSerial(t, r); got != 1 {
+		t.Errorf("served serial %d, want the previous certificate", got)
+	}
+}
+
+func TestClientAuthModes(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, 1)
+
+	if _, err := New(certFile, keyFile, "", ClientAuthRequire); err == nil {
+		t.Error("expected require without a client CA file to fail")
+	}
+	if _, err := New(certFile, keyFile, certFile, "sometimes"); err != ErrUnknownClientAuth {
+		t.Errorf("expected ErrUnknownClientAuth, got %v", err)
+	}
+
+	r, err := New(certFile, keyFile, certFile, ClientAuthOptional)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := r.getConfigForClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ClientAuth != tls.VerifyClientCertIfGiven || config.ClientCAs == nil {
+		t.Errorf("unexpected client auth config: %v", config.ClientAuth)
+	}
+}
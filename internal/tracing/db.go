@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// tracedDB wraps a database connection or transaction with a span around every query
+type tracedDB struct {
+	tracer *Tracer
+	db     database.DBTX
+}
+
+// WrapDB returns a database.DBTX that times every query as a child of the span in the query's context. Queries
+// made outside any span, such as background cleanups, are not traced. A nil tracer returns db unchanged.
+func WrapDB(tracer *Tracer, db database.DBTX) database.DBTX {
+	if tracer == nil {
+		return db
+	}
+	return &tracedDB{tracer: tracer, db: db}
+}
+
+// start begins a client span named after the sqlc query, if the context is traced
+func (t *tracedDB) start(ctx context.Context, query string) (context.Context, *Span) {
+	if SpanFromContext(ctx) == nil {
+		return ctx, nil
+	}
+
+	name := queryName(query)
+	return t.tracer.Start(ctx, "query "+name, SpanKindClient,
+		String("db.system", "postgresql"),
+		String("db.operation.name", name),
+		String("db.query.text", query),
+	)
+}
+
+func (t *tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	result, err := t.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		span.SetError(err.Error())
+	}
+	return result, err
+}
+
+func (t *tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	stmt, err := t.db.PrepareContext(ctx, query)
+	if err != nil {
+		span.SetError(err.Error())
+	}
+	return stmt, err
+}
+
+// QueryContext times the query until its first rows are ready; reading the rows is not included
+func (t *tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		span.SetError(err.Error())
+	}
+	return rows, err
+}
+
+// QueryRowContext times the query; errors only surface when the row is scanned, so they are not recorded
+func (t *tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	return t.db.QueryRowContext(ctx, query, args...)
+}
+
+// queryName returns the name sqlc puts in the first line of every query, such as GetUserByID
+func queryName(query string) string {
+	if rest, ok := strings.CutPrefix(query, "-- name: "); ok {
+		if name, _, ok := strings.Cut(rest, " "); ok {
+			return name
+		}
+	}
+	return "query"
+}
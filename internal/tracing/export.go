@@ -0,0 +1,235 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Exporter sends finished spans somewhere they can be inspected
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// WriterExporter writes every span as a JSON line, to stdout or a file
+type WriterExporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewWriterExporter creates an exporter writing to w, such as os.Stdout
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// NewFileExporter creates an exporter appending to the given file, creating it if needed
+func NewFileExporter(path string) (*WriterExporter, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &WriterExporter{w: f, closer: f}, nil
+}
+
+// jsonSpan is the JSON line written for a span
+type jsonSpan struct {
+	Name          string         `json:"name"`
+	Kind          string         `json:"kind"`
+	TraceID       string         `json:"trace_id"`
+	SpanID        string         `json:"span_id"`
+	ParentSpanID  string         `json:"parent_span_id,omitempty"`
+	Start         time.Time      `json:"start"`
+	End           time.Time      `json:"end"`
+	DurationMS    float64        `json:"duration_ms"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+	Error         bool           `json:"error,omitempty"`
+	StatusMessage string         `json:"status_message,omitempty"`
+}
+
+// Export writes the spans as JSON lines
+func (e *WriterExporter) Export(ctx context.Context, spans []SpanData) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, span := range spans {
+		line := jsonSpan{
+			Name:          span.Name,
+			Kind:          span.Kind.String(),
+			TraceID:       span.TraceID.String(),
+			SpanID:        span.SpanID.String(),
+			Start:         span.Start,
+			End:           span.End,
+			DurationMS:    float64(span.End.Sub(span.Start).Microseconds()) / 1000,
+			Error:         span.Error,
+			StatusMessage: span.StatusMessage,
+		}
+		if span.ParentSpanID != (SpanID{}) {
+			line.ParentSpanID = span.ParentSpanID.String()
+		}
+		if len(span.Attributes) > 0 {
+			line.Attributes = make(map[string]any, len(span.Attributes))
+			for _, attr := range span.Attributes {
+				line.Attributes[attr.Key] = attr.Value
+			}
+		}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+// Shutdown closes the file, if the exporter opened one
+func (e *WriterExporter) Shutdown(ctx context.Context) error {
+	if e.closer == nil {
+		return nil
+	}
+	return e.closer.Close()
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector with OTLP over HTTP, using the JSON encoding
+type OTLPExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+}
+
+// NewOTLPExporter creates an exporter posting to a collector's traces endpoint, such as
+// http://localhost:4318/v1/traces, reporting spans under the given service name
+func NewOTLPExporter(endpoint, serviceName string) *OTLPExporter {
+	return &OTLPExporter{endpoint: endpoint, serviceName: serviceName, client: &http.Client{Timeout: exportTimeout}}
+}
+
+// OTLP/JSON request body; IDs are hex and 64-bit integers are strings, as the specification requires
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+// otlpStatusError is the OTLP status code of failed spans
+const otlpStatusError = 2
+
+// otlpScopeName identifies this package as the instrumentation that produced the spans
+const otlpScopeName = "github.com/omed0/go-hello-world/internal/tracing"
+
+// Export posts the spans to the collector
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	body := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpKeyValue{otlpAttribute(String("service.name", e.serviceName))}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: otlpScopeName}, Spans: make([]otlpSpan, 0, len(spans))}},
+	}}}
+
+	scope := &body.ResourceSpans[0].ScopeSpans[0]
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID:           span.TraceID.String(),
+			SpanID:            span.SpanID.String(),
+			Name:              span.Name,
+			Kind:              int(span.Kind),
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+		}
+		if span.ParentSpanID != (SpanID{}) {
+			s.ParentSpanID = span.ParentSpanID.String()
+		}
+		for _, attr := range span.Attributes {
+			s.Attributes = append(s.Attributes, otlpAttribute(attr))
+		}
+		if span.Error {
+			s.Status = otlpStatus{Code: otlpStatusError, Message: span.StatusMessage}
+		}
+		scope.Spans = append(scope.Spans, s)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// Shutdown does nothing; every export is a complete request
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// otlpAttribute converts an attribute to an OTLP key and typed value
+func otlpAttribute(attr Attribute) otlpKeyValue {
+	var value map[string]any
+	switch v := attr.Value.(type) {
+	case string:
+		value = map[string]any{"stringValue": v}
+	case int64:
+		value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		value = map[string]any{"doubleValue": v}
+	case bool:
+		value = map[string]any{"boolValue": v}
+	default:
+		value = map[string]any{"stringValue": fmt.Sprint(v)}
+	}
+	return otlpKeyValue{Key: attr.Key, Value: value}
+}
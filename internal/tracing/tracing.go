@@ -0,0 +1,356 @@
+// Package tracing records spans for requests and database queries, propagates W3C trace context and exports
+// spans as JSON lines or to an OpenTelemetry collector over OTLP/HTTP
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	mathrand "math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Batching of finished spans before they are exported
+const (
+	queueSize     = 4096
+	batchSize     = 512
+	flushInterval = 5 * time.Second
+	exportTimeout = 10 * time.Second
+)
+
+// TraceID identifies a trace, shared by all its spans
+type TraceID [16]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext is the part of a span that is propagated to other services
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether both IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent formats the span context as a W3C traceparent header
+func (sc SpanContext) Traceparent() string {
+	flags := 0
+	if sc.Sampled {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent parses a W3C traceparent header such as 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(header string) (SpanContext, bool) {
+	header = strings.TrimSpace(header)
+	parts := strings.Split(header, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+
+	// IDs are lowercase hex only
+	var sc SpanContext
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 || strings.ToLower(header) != header {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+
+	return sc, sc.IsValid()
+}
+
+// SpanKind tells whether a span serves a request, calls another service or is internal; values match OTLP
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+// Attribute is a key and a string, integer, float or boolean value describing a span
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String creates a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int creates an integer attribute
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// SpanData is a finished span as handed to exporters
+type SpanData struct {
+	Name          string
+	Kind          SpanKind
+	TraceID       TraceID
+	SpanID        SpanID
+	ParentSpanID  SpanID
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	Error         bool
+	StatusMessage string
+}
+
+// Span is an operation being timed. A nil span is valid and records nothing, so code can trace
+// unconditionally whether or not tracing is configured.
+type Span struct {
+	tracer  *Tracer
+	parent  *Span
+	context SpanContext
+
+	mu   sync.Mutex
+	data SpanData
+	done bool
+}
+
+// SpanContext returns the IDs of the span for propagation
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.context
+}
+
+// Parent returns the span this one was started in, or nil for a root or remote parent
+func (s *Span) Parent() *Span {
+	if s == nil {
+		return nil
+	}
+	return s.parent
+}
+
+// SetName renames the span, for names only known once the work is done such as a matched route
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Name = name
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// SetError marks the span as failed with the given message
+func (s *Span) SetError(message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Error = true
+	s.data.StatusMessage = message
+}
+
+// End finishes the span and queues it for export if it is sampled; only the first call has an effect
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return
+	}
+	s.done = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if s.context.Sampled {
+		s.tracer.record(data)
+	}
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// ContextWithSpan returns a context in which new spans are children of span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the current span, or nil if there is none
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext returns a context in which new root spans continue a trace from another service
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Tracer starts spans and exports the finished ones in batches from a background goroutine.
+// A nil tracer is valid and starts nil spans.
+type Tracer struct {
+	exporter    Exporter
+	sampleRatio float64
+
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan SpanData
+	stopped chan struct{}
+}
+
+// NewTracer creates a tracer exporting to exporter. New traces are sampled with the given probability;
+// traces continued from a traceparent header keep the caller's decision.
+func NewTracer(exporter Exporter, sampleRatio float64) *Tracer {
+	t := &Tracer{
+		exporter:    exporter,
+		sampleRatio: sampleRatio,
+		queue:       make(chan SpanData, queueSize),
+		stopped:     make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// Start begins a span as a child of the span in ctx, or of a remote parent, or as the root of a new trace
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{tracer: t, data: SpanData{Name: name, Kind: kind, Start: time.Now(), Attributes: attrs}}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.parent = parent
+		span.context = SpanContext{TraceID: parent.context.TraceID, Sampled: parent.context.Sampled}
+		span.data.ParentSpanID = parent.context.SpanID
+	} else if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok && remote.IsValid() {
+		span.context = SpanContext{TraceID: remote.TraceID, Sampled: remote.Sampled}
+		span.data.ParentSpanID = remote.SpanID
+	} else {
+		rand.Read(span.context.TraceID[:])
+		span.context.Sampled = mathrand.Float64() < t.sampleRatio
+	}
+	rand.Read(span.context.SpanID[:])
+
+	span.data.TraceID = span.context.TraceID
+	span.data.SpanID = span.context.SpanID
+	return ContextWithSpan(ctx, span), span
+}
+
+// record queues a finished span, dropping it when the queue is full rather than slowing the request down
+func (t *Tracer) record(data SpanData) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		return
+	}
+
+	select {
+	case t.queue <- data:
+	default:
+	}
+}
+
+// run exports queued spans in batches until the queue is closed
+func (t *Tracer) run() {
+	defer close(t.stopped)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, batchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if err := t.exporter.Export(ctx, batch); err != nil {
+			slog.Warn("Failed to export spans", "spans", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case data, ok := <-t.queue:
+			if !ok {
+				export()
+				return
+			}
+			batch = append(batch, data)
+			if len(batch) >= batchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		}
+	}
+}
+
+// Shutdown exports the spans still queued and closes the exporter; spans ended afterwards are dropped
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.queue)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-t.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.exporter.Shutdown(ctx)
+}
@@ -0,0 +1,174 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestParseTraceparent checks valid headers round-trip and malformed ones are rejected
+func TestParseTraceparent(t *testing.T) {
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := ParseTraceparent(header)
+	if !ok || !sc.Sampled || sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("unexpected span context %+v, %v", sc, ok)
+	}
+	if sc.Traceparent() != header {
+		t.Errorf("expected %s, got %s", header, sc.Traceparent())
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, ok := ParseTraceparent(invalid); ok {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+// recordingExporter keeps exported spans for tests
+type recordingExporter struct {
+	spans []SpanData
+}
+
+func (e *recordingExporter) Export(ctx context.Context, spans []SpanData) error {
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// TestTracerContinuesRemoteTrace checks parent links, remote propagation and that Shutdown flushes
+func TestTracerContinuesRemoteTrace(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(exporter, 0)
+
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithRemoteSpanContext(context.Background(), remote)
+
+	ctx, server := tracer.Start(ctx, "GET /v1/tasks", SpanKindServer)
+	_, query := tracer.Start(ctx, "query ListTasks", SpanKindClient)
+	query.SetError("timeout")
+	query.End()
+	server.End()
+	server.End()
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(exporter.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(exporter.spans))
+	}
+	child, parent := exporter.spans[0], exporter.spans[1]
+	if parent.TraceID != remote.TraceID || parent.ParentSpanID != remote.SpanID {
+		t.Errorf("expected the server span to continue the remote trace, got %+v", parent)
+	}
+	if child.TraceID != remote.TraceID || child.ParentSpanID != parent.SpanID || !child.Error {
+		t.Errorf("expected a failed child of the server span, got %+v", child)
+	}
+}
+
+// TestTracerSampling checks that unsampled traces are not exported and nil tracers are safe
+func TestTracerSampling(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(exporter, 0)
+
+	ctx, root := tracer.Start(context.Background(), "root", SpanKindServer)
+	_, child := tracer.Start(ctx, "child", SpanKindInternal)
+	child.End()
+	root.End()
+	tracer.Shutdown(context.Background())
+
+	if len(exporter.spans) != 0 || root.SpanContext().Sampled {
+		t.Errorf("expected nothing to be sampled, got %d spans", len(exporter.spans))
+	}
+
+	var none *Tracer
+	_, span := none.Start(context.Background(), "noop", SpanKindInternal)
+	span.SetAttributes(String("k", "v"))
+	span.End()
+	if none.Shutdown(context.Background()) != nil || span != nil {
+		t.Error("expected a nil tracer to do nothing")
+	}
+}
+
+// TestWriterExporter checks the JSON line written for a span
+func TestWriterExporter(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewTracer(NewWriterExporter(&buf), 1)
+
+	_, span := tracer.Start(context.Background(), "argon2 verify", SpanKindInternal, Int("memory_kb", 65536))
+	span.End()
+	tracer.Shutdown(context.Background())
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+	attrs, _ := line["attributes"].(map[string]any)
+	if line["name"] != "argon2 verify" || line["kind"] != "internal" || attrs["memory_kb"] != float64(65536) {
+		t.Errorf("unexpected span %s", buf.String())
+	}
+	if _, ok := line["parent_span_id"]; ok {
+		t.Error("expected no parent for a root span")
+	}
+}
+
+// TestOTLPExporter checks the OTLP/JSON request sent to a collector
+func TestOTLPExporter(t *testing.T) {
+	var body []byte
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer collector.Close()
+
+	tracer := NewTracer(NewOTLPExporter(collector.URL+"/v1/traces", "go-hello-world"), 1)
+	_, span := tracer.Start(context.Background(), "GET /v1/tasks", SpanKindServer, Int("http.response.status_code", 500))
+	span.SetError("boom")
+	span.End()
+	tracer.Shutdown(context.Background())
+
+	for _, expected := range []string{
+		`"service.name","value":{"stringValue":"go-hello-world"}`,
+		`"kind":2`,
+		`"intValue":"500"`,
+		`"status":{"code":2,"message":"boom"}`,
+		`"traceId":"` + span.SpanContext().TraceID.String() + `"`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("expected %s in %s", expected, body)
+		}
+	}
+
+	failing := NewOTLPExporter(collector.URL+"/missing", "go-hello-world")
+	collector.Config.Handler = http.NotFoundHandler()
+	if err := failing.Export(context.Background(), []SpanData{{Name: "x"}}); err == nil {
+		t.Error("expected a collector error to be reported")
+	}
+}
+
+// TestQueryName checks that span names come from the sqlc query name
+func TestQueryName(t *testing.T) {
+	if name := queryName("-- name: GetUserByID :one\nSELECT 1"); name != "GetUserByID" {
+		t.Errorf("unexpected name %q", name)
+	}
+	if name := queryName("SELECT 1"); name != "query" {
+		t.Errorf("unexpected name %q", name)
+	}
+}
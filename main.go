@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,49 +12,118 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/joho/godotenv"
 	"github.com/omed0/go-hello-world/handlers"
+	"github.com/omed0/go-hello-world/internal/auth"
+	"github.com/omed0/go-hello-world/internal/authz"
 	"github.com/omed0/go-hello-world/internal/config"
+	"github.com/omed0/go-hello-world/internal/logging"
 	"github.com/omed0/go-hello-world/internal/middleware"
+	"github.com/omed0/go-hello-world/internal/ratelimit"
+	"github.com/omed0/go-hello-world/internal/tlsreload"
 
 	_ "github.com/lib/pq"
 )
 
 func main() {
 	// Load environment variables
-	if err := godotenv.Load(".env"); err != nil {
-		log.Printf("Warning: failed to load .env file: %v", err)
-	}
+	envErr := godotenv.Load(".env")
 
 	// Load configuration
 	cfg := config.LoadConfig()
 
+	// Structured logging; the standard library logger writes through it too
+	logger, err := logging.New(os.Stderr, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		fatal("Invalid logging configuration", "error", err)
+	}
+	slog.SetDefault(logger)
+	if envErr != nil {
+		slog.Warn("Failed to load .env file", "error", envErr)
+	}
+
 	// Validate required configuration
 	if cfg.DatabaseURL == "" {
-		log.Fatal("DB_URL environment variable is required")
+		fatal("DB_URL environment variable is required")
+	}
+	if cfg.JWTSecret == "" {
+		fatal("JWT_SECRET environment variable is required")
 	}
 
 	// Initialize database connection
-	apiCfg, err := handlers.NewApiConfig()
+	apiCfg, err := handlers.NewApiConfig(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to the database: %v", err)
+		fatal("Failed to connect to the database", "error", err)
 	}
 	defer handlers.CloseDB()
 
+	// Middleware stages get spans of their own when tracing is on
+	stage := func(name string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+		return middleware.TraceStage(apiCfg.Tracer, name, mw)
+	}
+	authenticate := stage("authenticate", middleware.Authenticate(apiCfg.Authenticator))
+
+	// Rate limits; a limit that is off, or RATE_LIMIT_ENABLED=false, lets requests through uncounted
+	var limitStore ratelimit.Store
+	switch cfg.RateLimitBackend {
+	case "memory":
+		limitStore = ratelimit.NewMemoryStore()
+	case "postgres":
+		limitStore = ratelimit.NewPostgresStore(apiCfg.Queries)
+	default:
+		fatal("Invalid RATE_LIMIT_BACKEND, expected memory or postgres", "backend", cfg.RateLimitBackend)
+	}
+	limit := func(name, value string) ratelimit.Limit {
+		if !cfg.RateLimitEnabled {
+			return ratelimit.Limit{}
+		}
+		parsed, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			fatal("Invalid rate limit", "variable", name, "error", err)
+		}
+		return parsed
+	}
+	byIP := middleware.RateLimitByIP(apiCfg.ClientIP)
+	ipRateLimit := stage("ip rate limit", middleware.RateLimit(limitStore, middleware.RateLimitPolicy{
+		Name: "ip", Limit: limit("RATE_LIMIT_IP", cfg.RateLimitIP), Key: byIP,
+	}))
+	authRateLimit := stage("auth rate limit", middleware.RateLimit(limitStore, middleware.RateLimitPolicy{
+		Name: "auth", Limit: limit("RATE_LIMIT_AUTH", cfg.RateLimitAuth), Key: byIP,
+	}))
+	callerRateLimit := stage("caller rate limit", middleware.RateLimit(limitStore,
+		middleware.RateLimitPolicy{
+			Name: "read", Limit: limit("RATE_LIMIT_READ", cfg.RateLimitRead), Key: middleware.RateLimitByCaller,
+			Methods: []string{http.MethodGet, http.MethodHead},
+		},
+		middleware.RateLimitPolicy{
+			Name: "write", Limit: limit("RATE_LIMIT_WRITE", cfg.RateLimitWrite), Key: middleware.RateLimitByCaller,
+			Methods: []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		},
+		middleware.RateLimitPolicy{
+			Name: "org", Limit: limit("RATE_LIMIT_ORGANIZATION", cfg.RateLimitOrganization), Key: middleware.RateLimitByOrganization,
+		},
+	))
+
 	// Create router
 	router := chi.NewRouter()
 
 	// Global middleware
-	router.Use(middleware.Recovery)      // Panic recovery
-	router.Use(middleware.RequestLogger) // Request logging
+	router.Use(middleware.RequestID)            // Request IDs for responses and log lines
+	router.Use(middleware.Trace(apiCfg.Tracer)) // Request spans, continuing incoming traceparent headers
+	router.Use(middleware.Recovery)             // Panic recovery
+	router.Use(middleware.RequestLogger)        // Request logging
+	if cfg.MetricsEnabled {
+		router.Use(middleware.Metrics(apiCfg.Metrics)) // Request counts and latencies
+	}
 
 	// CORS configuration
-	router.Use(cors.Handler(cors.Options{
+	router.Use(stage("cors", cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
 		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "X-Request-ID", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"},
 		AllowCredentials: false,
 		MaxAge:           300,
-	}))
+	})))
+	router.Use(ipRateLimit) // Per-client-address limit on every request
 
 	// API v1 routes
 	v1Router := chi.NewRouter()
@@ -62,36 +131,202 @@ func main() {
 	// Public endpoints (no authentication required)
 	v1Router.Get("/healthz", handlers.HandlerReadiness)
 	v1Router.Get("/err", handlers.HandlerErr)
-	v1Router.Post("/user", apiCfg.HandlerCreateUser)
-	v1Router.Post("/login", apiCfg.HandlerLogin)
+	v1Router.Get("/device", apiCfg.HandlerDeviceVerificationPage)
 
-	// Protected endpoints (authentication required)
+	// Sign-up, login and token endpoints get a stricter per-address limit against credential guessing
 	v1Router.Group(func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware(apiCfg))
+		r.Use(authRateLimit)
+		r.Post("/user", apiCfg.HandlerCreateUser)
+		r.Post("/login", apiCfg.HandlerLogin)
+		r.Post("/login/2fa", apiCfg.HandlerLoginTwoFactor)
+		r.Post("/token/refresh", apiCfg.HandlerRefreshToken)
+		r.Post("/password/reset/request", apiCfg.HandlerRequestPasswordReset)
+		r.Post("/password/reset", apiCfg.HandlerResetPassword)
+		r.Get("/auth/oidc/login", apiCfg.HandlerOIDCLogin)
+		r.Get("/auth/oidc/callback", apiCfg.HandlerOIDCCallback)
+		r.Post("/oauth/device/code", apiCfg.HandlerDeviceAuthorization)
+		r.Post("/oauth/token", apiCfg.HandlerOAuthToken)
+	})
+
+	// Protected endpoints (authentication required). Requests act in the organization named by the path, the
+	// X-Organization-ID header or the caller's default organization, with the caller's role there.
+	// Routes acting on organizations or tasks declare the permission they need; routes acting on the
+	// caller's own account only need the matching scope.
+	permit := func(permission string) func(http.Handler) http.Handler {
+		return stage("authorize "+permission, middleware.RequirePermission(apiCfg.Permissions, permission))
+	}
+	v1Router.Group(func(r chi.Router) {
+		r.Use(authenticate, stage("active organization", middleware.ActiveOrganization(apiCfg.Permissions)), callerRateLimit)
+
+		// Session endpoints
+		r.Post("/logout", apiCfg.HandlerLogout)
+		r.Post("/logout/all", apiCfg.HandlerLogoutAll)
+
+		r.With(middleware.RequireScope(auth.ScopeUserRead)).Get("/user/sessions", apiCfg.HandlerGetSessions)
+		r.With(middleware.RequireScope(auth.ScopeUserWrite)).Delete("/user/sessions/{sessionId}", apiCfg.HandlerRevokeSession)
+		r.With(middleware.RequireScope(auth.ScopeOrgsAdmin), permit(authz.OrgSessionsManage)).Get("/users/{userId}/sessions", apiCfg.HandlerAdminGetUserSessions)
+		r.With(middleware.RequireScope(auth.ScopeOrgsAdmin), permit(authz.OrgSessionsManage)).Delete("/users/{userId}/sessions/{sessionId}", apiCfg.HandlerAdminRevokeUserSession)
+
+		// Approving a device login hands out a full session, so scoped API keys cannot do it
+		r.With(middleware.RequireScope(auth.ScopeAll), middleware.RequireHumanAccount).Post("/device/verify", apiCfg.HandlerVerifyDevice)
 
 		// User endpoints
-		r.Get("/user", apiCfg.HandlerGetUser)
-		r.Put("/user", apiCfg.HandlerUpdateUser)
+		r.With(middleware.RequireScope(auth.ScopeUserRead)).Get("/user", apiCfg.HandlerGetUser)
+		r.With(middleware.RequireScope(auth.ScopeUserWrite)).Put("/user", apiCfg.HandlerUpdateUser)
+		r.With(middleware.RequireScope(auth.ScopeUserRead)).Get("/user/password-policy", apiCfg.HandlerGetPasswordPolicy)
+		r.With(middleware.RequireScope(auth.ScopeUserRead)).Get("/user/organizations", apiCfg.HandlerGetUserOrganizations)
+		r.With(middleware.RequireScope(auth.ScopeUserWrite), middleware.RequireHumanAccount).Put("/user/password", apiCfg.HandlerChangePassword)
+		r.With(middleware.RequireScope(auth.ScopeUserRead)).Get("/user/export", apiCfg.HandlerExportAccount)
+
+		// Account deletion, scheduled after a grace period in which it can be cancelled
+		r.With(middleware.RequireScope(auth.ScopeAll), middleware.RequireHumanAccount).Delete("/user", apiCfg.HandlerDeleteAccount)
+		r.With(middleware.RequireScope(auth.ScopeUserRead)).Get("/user/deletion", apiCfg.HandlerGetAccountDeletion)
+		r.With(middleware.RequireScope(auth.ScopeUserWrite), middleware.RequireHumanAccount).Delete("/user/deletion", apiCfg.HandlerCancelAccountDeletion)
+		r.With(middleware.RequireScope(auth.ScopeOrgsAdmin), permit(authz.OrgMembersManage)).Post("/users/{userId}/password-reset", apiCfg.HandlerAdminResetPassword)
+		r.With(middleware.RequireScope(auth.ScopeOrgsAdmin), permit(authz.OrgMembersManage)).Post("/users/{userId}/unlock", apiCfg.HandlerAdminUnlockUser)
+
+		// API key endpoints; service account keys are managed by organization admins
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeUserWrite), middleware.RequireHumanAccount)
+			r.Post("/user/keys", apiCfg.HandlerCreateAPIKey)
+			r.Get("/user/keys", apiCfg.HandlerGetAPIKeys)
+			r.Post("/user/keys/{keyId}/rotate", apiCfg.HandlerRotateAPIKey)
+			r.Delete("/user/keys/{keyId}", apiCfg.HandlerRevokeAPIKey)
+		})
+
+		// Two-factor authentication endpoints
+		r.With(middleware.RequireScope(auth.ScopeUserRead)).Get("/user/2fa", apiCfg.HandlerGetTwoFactorStatus)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeUserWrite), middleware.RequireHumanAccount)
+			r.Post("/user/2fa/enroll", apiCfg.HandlerEnrollTwoFactor)
+			r.Post("/user/2fa/verify", apiCfg.HandlerVerifyTwoFactor)
+			r.Post("/user/2fa/recovery-codes", apiCfg.HandlerRegenerateRecoveryCodes)
+			r.Delete("/user/2fa", apiCfg.HandlerDisableTwoFactor)
+		})
 
 		// Organization endpoints
-		r.Post("/organizations", apiCfg.HandlerCreateOrganization)
-		r.Get("/organizations/{orgId}", apiCfg.HandlerGetOrganization)
-		r.With(middleware.RequireRole(apiCfg, "admin", "owner")).Put("/organizations/{orgId}", apiCfg.HandlerUpdateOrganization)
-		r.With(middleware.RequireRole(apiCfg, "admin", "owner")).Delete("/organizations/{orgId}", apiCfg.HandlerDeleteOrganization)
-		r.Get("/organizations/{orgId}/users", apiCfg.HandlerGetOrganizationUsers)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeOrgsRead))
+			r.With(permit(authz.OrgRead)).Get("/organizations/{orgId}", apiCfg.HandlerGetOrganization)
+			r.With(permit(authz.OrgMembersRead)).Get("/organizations/{orgId}/users", apiCfg.HandlerGetOrganizationUsers)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeOrgsAdmin))
+			r.With(permit(authz.OrgCreate)).Post("/organizations", apiCfg.HandlerCreateOrganization)
+			r.With(permit(authz.OrgUpdate)).Put("/organizations/{orgId}", apiCfg.HandlerUpdateOrganization)
+			r.With(permit(authz.OrgDelete)).Delete("/organizations/{orgId}", apiCfg.HandlerDeleteOrganization)
+			r.With(permit(authz.OrgMembersManage)).Put("/organizations/{orgId}/users/{userId}/role", apiCfg.HandlerAssignRole)
+			r.With(permit(authz.OrgMembersManage)).Put("/organizations/{orgId}/members/{userId}", apiCfg.HandlerAssignRole)
+			r.With(permit(authz.OrgMembersManage)).Delete("/organizations/{orgId}/members/{userId}", apiCfg.HandlerRemoveOrganizationMember)
+		})
+
+		// Ownership transfer endpoints; the new owner confirms a transfer before anything changes
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeOrgsAdmin))
+			r.With(permit(authz.OrgDelete)).Post("/organizations/{orgId}/ownership-transfer", apiCfg.HandlerCreateOwnershipTransfer)
+			r.With(permit(authz.OrgRead)).Get("/organizations/{orgId}/ownership-transfer", apiCfg.HandlerGetOwnershipTransfer)
+			r.With(permit(authz.OrgRead)).Delete("/organizations/{orgId}/ownership-transfer", apiCfg.HandlerCancelOwnershipTransfer)
+			r.With(permit(authz.OrgRead), middleware.RequireHumanAccount).Post("/organizations/{orgId}/ownership-transfer/accept", apiCfg.HandlerAcceptOwnershipTransfer)
+		})
+
+		// Audit log endpoints
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeOrgsAdmin), permit(authz.OrgAuditRead))
+			r.Get("/organizations/{orgId}/audit", apiCfg.HandlerGetAuditEvents)
+			r.Get("/organizations/{orgId}/audit/export", apiCfg.HandlerExportAuditEvents)
+		})
+
+		// Invitation endpoints
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeOrgsAdmin), permit(authz.OrgMembersManage))
+			r.Post("/organizations/{orgId}/invitations", apiCfg.HandlerCreateInvitation)
+			r.Get("/organizations/{orgId}/invitations", apiCfg.HandlerGetInvitations)
+			r.Delete("/organizations/{orgId}/invitations/{invitationId}", apiCfg.HandlerRevokeInvitation)
+		})
+
+		// Custom role endpoints
+		r.Route("/organizations/{orgId}/roles", func(r chi.Router) {
+			r.With(middleware.RequireScope(auth.ScopeOrgsRead), permit(authz.OrgMembersRead)).Get("/", apiCfg.HandlerGetRoles)
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireScope(auth.ScopeOrgsAdmin), permit(authz.OrgRolesManage))
+				r.Post("/", apiCfg.HandlerCreateRole)
+				r.Put("/{roleId}", apiCfg.HandlerUpdateRole)
+				r.Delete("/{roleId}", apiCfg.HandlerDeleteRole)
+			})
+		})
+
+		// Service account endpoints
+		r.Route("/organizations/{orgId}/service-accounts", func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeOrgsAdmin), permit(authz.OrgServiceAccountsManage))
+			r.Post("/", apiCfg.HandlerCreateServiceAccount)
+			r.Get("/", apiCfg.HandlerGetServiceAccounts)
+			r.Get("/{accountId}/keys", apiCfg.HandlerGetServiceAccountKeys)
+			r.Post("/{accountId}/keys", apiCfg.HandlerCreateServiceAccountKey)
+			r.Delete("/{accountId}/keys/{keyId}", apiCfg.HandlerRevokeServiceAccountKey)
+			r.Post("/{accountId}/disable", apiCfg.HandlerDisableServiceAccount)
+			r.Post("/{accountId}/enable", apiCfg.HandlerEnableServiceAccount)
+		})
+
+		// Client certificate mappings for mutual TLS
+		r.Route("/organizations/{orgId}/client-certificates", func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeOrgsAdmin), permit(authz.OrgCertificatesManage))
+			r.Post("/", apiCfg.HandlerCreateClientCertificateMapping)
+			r.Get("/", apiCfg.HandlerGetClientCertificateMappings)
+			r.Delete("/{mappingId}", apiCfg.HandlerDeleteClientCertificateMapping)
+		})
 
 		// Task endpoints
-		r.Post("/tasks", apiCfg.HandlerCreateTask)
-		r.Get("/tasks", apiCfg.HandlerGetTasks)
-		r.Get("/tasks/search", apiCfg.HandlerSearchTasks)
-		r.Get("/tasks/{taskId}", apiCfg.HandlerGetTask)
-		r.Put("/tasks/{taskId}", apiCfg.HandlerUpdateTask)
-		r.Delete("/tasks/{taskId}", apiCfg.HandlerDeleteTask)
-		r.Patch("/tasks/{taskId}/complete", apiCfg.HandlerToggleTaskCompletion)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeTasksRead))
+			r.With(permit(authz.TaskRead)).Get("/tasks", apiCfg.HandlerGetTasks)
+			r.With(permit(authz.TaskRead)).Get("/tasks/search", apiCfg.HandlerSearchTasks)
+			r.With(permit(authz.TaskRead)).Get("/tasks/{taskId}", apiCfg.HandlerGetTask)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeTasksWrite))
+			r.With(permit(authz.TaskCreate)).Post("/tasks", apiCfg.HandlerCreateTask)
+			r.With(permit(authz.TaskUpdate)).Put("/tasks/{taskId}", apiCfg.HandlerUpdateTask)
+			r.With(permit(authz.TaskDelete)).Delete("/tasks/{taskId}", apiCfg.HandlerDeleteTask)
+			r.With(permit(authz.TaskUpdate)).Patch("/tasks/{taskId}/complete", apiCfg.HandlerToggleTaskCompletion)
+		})
+	})
+
+	// Invitees are not members of the organization yet, so answering an invitation skips organization selection
+	v1Router.Group(func(r chi.Router) {
+		r.Use(authenticate, callerRateLimit, middleware.RequireScope(auth.ScopeUserWrite), middleware.RequireHumanAccount)
+		r.Post("/organizations/{orgId}/invitations/accept", apiCfg.HandlerAcceptInvitation)
+		r.Post("/organizations/{orgId}/invitations/decline", apiCfg.HandlerDeclineInvitation)
+	})
+
+	// System administration endpoints; system admins are marked on their account and are unrelated to organization roles
+	v1Router.Route("/admin", func(r chi.Router) {
+		r.Use(authenticate, callerRateLimit, stage("system admin", middleware.RequireSystemAdmin(apiCfg.Queries)))
+		r.Get("/users", apiCfg.HandlerAdminGetUsers)
+		r.Post("/users/{userId}/suspend", apiCfg.HandlerAdminSuspendUser)
+		r.Post("/users/{userId}/reactivate", apiCfg.HandlerAdminReactivateUser)
+		r.Post("/users/{userId}/rotate-keys", apiCfg.HandlerAdminRequireKeyRotation)
+		r.Get("/organizations", apiCfg.HandlerAdminGetOrganizations)
+		r.Delete("/organizations/{orgId}", apiCfg.HandlerAdminDeleteOrganization)
+		r.Get("/audit/export", apiCfg.HandlerAdminExportAuditEvents)
 	})
 
 	router.Mount("/v1", v1Router)
 
+	// Prometheus metrics, on a separate port when configured so they can stay off the public listener
+	var metricsSrv *http.Server
+	if cfg.MetricsEnabled && cfg.MetricsPort != "" {
+		metricsRouter := chi.NewRouter()
+		metricsRouter.Handle("/metrics", apiCfg.Metrics)
+		metricsSrv = &http.Server{
+			Handler:           metricsRouter,
+			Addr:              ":" + cfg.MetricsPort,
+			ReadHeaderTimeout: cfg.ReadTimeout,
+			ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		}
+	} else if cfg.MetricsEnabled {
+		router.Handle("/metrics", apiCfg.Metrics)
+	}
+
 	// Create server with configuration-based timeouts
 	srv := &http.Server{
 		Handler:      router,
@@ -99,29 +334,89 @@ func main() {
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
 		IdleTimeout:  cfg.IdleTimeout,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	// Serve TLS natively when a certificate is configured, reloading it when the files change
+	var certs *tlsreload.Reloader
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		certs, err = tlsreload.New(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSClientCAFile, cfg.TLSClientAuth)
+		if err != nil {
+			fatal("Failed to load TLS certificates", "error", err)
+		}
+		srv.TLSConfig = certs.TLSConfig()
+
+		watchCtx, stopWatching := context.WithCancel(context.Background())
+		defer stopWatching()
+		go certs.Watch(watchCtx, cfg.TLSReloadInterval)
+	}
+
+	// Delete accounts whose grace period has ended
+	deletionCtx, stopDeletions := context.WithCancel(context.Background())
+	defer stopDeletions()
+	go apiCfg.RunAccountDeletions(deletionCtx)
+
+	if metricsSrv != nil {
+		go func() {
+			slog.Info("Metrics server starting", "port", cfg.MetricsPort)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("Metrics server failed to start", "error", err)
+			}
+		}()
 	}
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Server starting on port %s", cfg.Port)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+		var err error
+		if certs != nil {
+			slog.Info("Server starting with TLS", "port", cfg.Port)
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			slog.Info("Server starting", "port", cfg.Port)
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("Server failed to start", "error", err)
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server
+	// Wait for interrupt signal to gracefully shutdown the server; SIGHUP reloads the TLS certificates
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit // Block until a signal is received
-	log.Println("Shutting down server...")
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range quit {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		if certs == nil {
+			continue
+		}
+		if err := certs.Reload(); err != nil {
+			slog.Error("Failed to reload TLS certificates", "error", err)
+			continue
+		}
+		slog.Info("Reloaded TLS certificates")
+	}
+	slog.Info("Shutting down server...")
 
 	// Graceful shutdown with configurable timeout
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ServerTimeout)
 	defer cancel()
 
+	if metricsSrv != nil {
+		metricsSrv.Shutdown(ctx)
+	}
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("Server forced to shutdown", "error", err)
 	}
+	if err := apiCfg.Tracer.Shutdown(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+
+	slog.Info("Server exited gracefully")
+}
 
-	log.Println("Server exited gracefully")
+// fatal logs an error that keeps the server from running and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// What happens to a deleted account's tasks
+const (
+	TaskActionDelete    = "delete"
+	TaskActionAnonymize = "anonymize"
+	TaskActionTransfer  = "transfer"
+)
+
+// DeleteAccountRequest represents the request body for deleting the current user's account.
+// TransferTo names the member of one of the user's organizations who receives the tasks when TaskAction is transfer.
+type DeleteAccountRequest struct {
+	Password   string     `json:"password" validate:"required"`
+	TaskAction string     `json:"task_action,omitempty" validate:"omitempty,oneof=delete anonymize transfer"`
+	TransferTo *uuid.UUID `json:"transfer_to,omitempty"`
+}
+
+// AccountDeletion is a scheduled deletion of the current user's account
+type AccountDeletion struct {
+	TaskAction   string     `json:"task_action"`
+	TransferTo   *uuid.UUID `json:"transfer_to,omitempty"`
+	ScheduledFor time.Time  `json:"scheduled_for"`
+	RequestedAt  time.Time  `json:"requested_at"`
+}
+
+// AccountExport is everything the API stores about a user, as returned by GET /user/export
+type AccountExport struct {
+	ExportedAt  time.Time        `json:"exported_at"`
+	Profile     User             `json:"profile"`
+	Tasks       []Task           `json:"tasks"`
+	Memberships []Membership     `json:"memberships"`
+	Deletion    *AccountDeletion `json:"scheduled_deletion,omitempty"`
+}
+
+// DatabaseAccountDeletionToAccountDeletion converts a database account deletion to an account deletion model
+func DatabaseAccountDeletionToAccountDeletion(dbDeletion database.AccountDeletion) AccountDeletion {
+	deletion := AccountDeletion{
+		TaskAction:   dbDeletion.TaskAction,
+		ScheduledFor: dbDeletion.ScheduledFor,
+		RequestedAt:  dbDeletion.RequestedAt,
+	}
+
+	// Handle nullable fields
+	if dbDeletion.TransferTo.Valid {
+		deletion.TransferTo = &dbDeletion.TransferTo.UUID
+	}
+
+	return deletion
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// Page is one page of a listing together with the total number of matching items
+type Page[T any] struct {
+	Items  []T   `json:"items"`
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+// AdminUser is an account as seen by system administrators
+type AdminUser struct {
+	ID               uuid.UUID  `json:"id"`
+	Username         string     `json:"username"`
+	AccountType      string     `json:"account_type"`
+	SystemAdmin      bool       `json:"system_admin"`
+	OrganizationID   *uuid.UUID `json:"organization_id,omitempty"`
+	OrganizationName *string    `json:"organization_name,omitempty"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// AdminOrganization is an organization as seen by system administrators, including deleted ones
+type AdminOrganization struct {
+	Organization
+	MemberCount int64      `json:"member_count"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// RequireKeyRotationRequest represents the request body for forcing a user to rotate their API keys.
+// GracePeriod is a duration such as "24h"; without it keys stop working immediately.
+type RequireKeyRotationRequest struct {
+	GracePeriod string `json:"grace_period,omitempty"`
+}
+
+// KeyRotationResponse reports which keys have to be rotated and by when
+type KeyRotationResponse struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Keys     int64     `json:"keys"`
+	RotateBy time.Time `json:"rotate_by"`
+}
+
+// OrganizationDeletionReport lists what deleting an organization removes or changes
+type OrganizationDeletionReport struct {
+	OrganizationID             uuid.UUID `json:"organization_id"`
+	Name                       string    `json:"name"`
+	DryRun                     bool      `json:"dry_run"`
+	Members                    int64     `json:"members"`
+	CustomRoles                int64     `json:"custom_roles"`
+	Invitations                int64     `json:"invitations"`
+	OwnershipTransfers         int64     `json:"ownership_transfers"`
+	ServiceAccounts            int64     `json:"service_accounts"`
+	ServiceAccountKeys         int64     `json:"service_account_keys"`
+	ServiceAccountCertificates int64     `json:"service_account_certificates"`
+	ServiceAccountTasks        int64     `json:"service_account_tasks"`
+	DefaultOrganizationUsers   int64     `json:"default_organization_users"`
+}
+
+// DatabaseAdminUserRowToAdminUser converts a database admin user row to an admin user model
+func DatabaseAdminUserRowToAdminUser(row database.AdminListUsersRow) AdminUser {
+	user := AdminUser{
+		ID:          row.ID,
+		Username:    row.Username,
+		AccountType: row.AccountType,
+		SystemAdmin: row.IsSystemAdmin,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+
+	// Handle nullable fields
+	if row.OrganizationID.Valid {
+		user.OrganizationID = &row.OrganizationID.UUID
+	}
+
+	if row.OrganizationName.Valid {
+		user.OrganizationName = &row.OrganizationName.String
+	}
+
+	if row.DisabledAt.Valid {
+		user.SuspendedAt = &row.DisabledAt.Time
+	}
+
+	return user
+}
+
+// DatabaseUserToAdminUser converts a database user to an admin user model
+func DatabaseUserToAdminUser(dbUser database.User) AdminUser {
+	return DatabaseAdminUserRowToAdminUser(database.AdminListUsersRow{
+		ID:             dbUser.ID,
+		Username:       dbUser.Username,
+		AccountType:    dbUser.AccountType,
+		IsSystemAdmin:  dbUser.IsSystemAdmin,
+		OrganizationID: dbUser.OrganizationID,
+		DisabledAt:     dbUser.DisabledAt,
+		CreatedAt:      dbUser.CreatedAt,
+		UpdatedAt:      dbUser.UpdatedAt,
+	})
+}
+
+// DatabaseAdminOrganizationRowToAdminOrganization converts a database admin organization row to an admin organization model
+func DatabaseAdminOrganizationRowToAdminOrganization(row database.AdminListOrganizationsRow) AdminOrganization {
+	org := AdminOrganization{
+		Organization: DatabaseOrganizationToOrganization(row.Organization),
+		MemberCount:  row.MemberCount,
+	}
+
+	if row.Organization.DeletedAt.Valid {
+		org.DeletedAt = &row.Organization.DeletedAt.Time
+	}
+
+	return org
+}
+
+// DatabaseOrganizationDependentsToReport converts the dependents of an organization to a deletion report
+func DatabaseOrganizationDependentsToReport(dbOrg database.Organization, counts database.CountOrganizationDependentsRow, dryRun bool) OrganizationDeletionReport {
+	return OrganizationDeletionReport{
+		OrganizationID:             dbOrg.ID,
+		Name:                       dbOrg.Name,
+		DryRun:                     dryRun,
+		Members:                    counts.Members,
+		CustomRoles:                counts.CustomRoles,
+		Invitations:                counts.Invitations,
+		OwnershipTransfers:         counts.OwnershipTransfers,
+		ServiceAccounts:            counts.ServiceAccounts,
+		ServiceAccountKeys:         counts.ServiceAccountKeys,
+		ServiceAccountCertificates: counts.ServiceAccountCertificates,
+		ServiceAccountTasks:        counts.ServiceAccountTasks,
+		DefaultOrganizationUsers:   counts.DefaultOrganizationUsers,
+	}
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// APIKey represents a named API key without its secret value
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RotateBy   *time.Time `json:"rotate_by,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// APIKeyWithSecret is returned when a key is created or rotated; the full key is never stored or shown again
+type APIKeyWithSecret struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// DatabaseAPIKeyToAPIKey converts a database API key to an API key model
+func DatabaseAPIKeyToAPIKey(dbKey database.ApiKey) APIKey {
+	key := APIKey{
+		ID:        dbKey.ID,
+		Name:      dbKey.Name,
+		Prefix:    dbKey.KeyPrefix,
+		Scopes:    dbKey.Scopes,
+		CreatedAt: dbKey.CreatedAt,
+		UpdatedAt: dbKey.UpdatedAt,
+	}
+
+	// Handle nullable timestamps
+	if dbKey.ExpiresAt.Valid {
+		key.ExpiresAt = &dbKey.ExpiresAt.Time
+	}
+
+	if dbKey.RotateBy.Valid {
+		key.RotateBy = &dbKey.RotateBy.Time
+	}
+
+	if dbKey.LastUsedAt.Valid {
+		key.LastUsedAt = &dbKey.LastUsedAt.Time
+	}
+
+	return key
+}
+
+// DatabaseAPIKeysToAPIKeys converts a slice of database API keys to API key models
+func DatabaseAPIKeysToAPIKeys(dbKeys []database.ApiKey) []APIKey {
+	keys := make([]APIKey, len(dbKeys))
+	for i, dbKey := range dbKeys {
+		keys[i] = DatabaseAPIKeyToAPIKey(dbKey)
+	}
+	return keys
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// AuditEvent records who changed what. Changes maps each changed field to its before and after values.
+type AuditEvent struct {
+	ID             uuid.UUID       `json:"id"`
+	OrganizationID *uuid.UUID      `json:"organization_id,omitempty"`
+	ActorID        *uuid.UUID      `json:"actor_id,omitempty"`
+	ActorUsername  *string         `json:"actor_username,omitempty"`
+	AuthMethod     *string         `json:"auth_method,omitempty"`
+	Action         string          `json:"action"`
+	TargetType     string          `json:"target_type"`
+	TargetID       *uuid.UUID      `json:"target_id,omitempty"`
+	Changes        json.RawMessage `json:"changes,omitempty"`
+	IPAddress      *string         `json:"ip_address,omitempty"`
+	RequestID      *string         `json:"request_id,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// DatabaseAuditEventToAuditEvent converts a database audit event to an audit event model
+func DatabaseAuditEventToAuditEvent(dbEvent database.AuditEvent) AuditEvent {
+	event := AuditEvent{
+		ID:         dbEvent.ID,
+		Action:     dbEvent.Action,
+		TargetType: dbEvent.TargetType,
+		CreatedAt:  dbEvent.CreatedAt,
+	}
+
+	// Handle nullable fields
+	if dbEvent.OrganizationID.Valid {
+		event.OrganizationID = &dbEvent.OrganizationID.UUID
+	}
+
+	if dbEvent.ActorID.Valid {
+		event.ActorID = &dbEvent.ActorID.UUID
+	}
+
+	if dbEvent.ActorUsername.Valid {
+		event.ActorUsername = &dbEvent.ActorUsername.String
+	}
+
+	if dbEvent.AuthMethod.Valid {
+		event.AuthMethod = &dbEvent.AuthMethod.String
+	}
+
+	if dbEvent.TargetID.Valid {
+		event.TargetID = &dbEvent.TargetID.UUID
+	}
+
+	if dbEvent.Changes.Valid {
+		event.Changes = dbEvent.Changes.RawMessage
+	}
+
+	if dbEvent.IpAddress.Valid {
+		event.IPAddress = &dbEvent.IpAddress.String
+	}
+
+	if dbEvent.RequestID.Valid {
+		event.RequestID = &dbEvent.RequestID.String
+	}
+
+	return event
+}
+
+// DatabaseAuditEventsToAuditEvents converts database audit events to audit event models
+func DatabaseAuditEventsToAuditEvents(dbEvents []database.AuditEvent) []AuditEvent {
+	events := make([]AuditEvent, len(dbEvents))
+	for i, dbEvent := range dbEvents {
+		events[i] = DatabaseAuditEventToAuditEvent(dbEvent)
+	}
+	return events
+}
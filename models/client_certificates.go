@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// ClientCertificateMapping links a name in TLS client certificates to the account it authenticates as
+type ClientCertificateMapping struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Username   string     `json:"username"`
+	MatchType  string     `json:"match_type"`
+	MatchValue string     `json:"match_value"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// CreateClientCertificateMappingRequest represents the request body for mapping a certificate name to an account
+type CreateClientCertificateMappingRequest struct {
+	UserID     uuid.UUID `json:"user_id" validate:"required"`
+	MatchType  string    `json:"match_type" validate:"required,oneof=uri dns email common_name"`
+	MatchValue string    `json:"match_value" validate:"required,max=255"`
+	Scopes     []string  `json:"scopes" validate:"required"`
+}
+
+// DatabaseClientCertificateMappingToClientCertificateMapping converts a database mapping to a mapping model
+func DatabaseClientCertificateMappingToClientCertificateMapping(dbMapping database.ClientCertificateMapping, username string) ClientCertificateMapping {
+	mapping := ClientCertificateMapping{
+		ID:         dbMapping.ID,
+		UserID:     dbMapping.UserID,
+		Username:   username,
+		MatchType:  dbMapping.MatchType,
+		MatchValue: dbMapping.MatchValue,
+		Scopes:     dbMapping.Scopes,
+		CreatedAt:  dbMapping.CreatedAt,
+	}
+
+	// Handle nullable fields
+	if dbMapping.CreatedBy.Valid {
+		mapping.CreatedBy = &dbMapping.CreatedBy.UUID
+	}
+
+	if dbMapping.LastUsedAt.Valid {
+		mapping.LastUsedAt = &dbMapping.LastUsedAt.Time
+	}
+
+	return mapping
+}
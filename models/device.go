@@ -0,0 +1,38 @@
+package models
+
+// DeviceAuthorizationResponse is returned by the device authorization endpoint (RFC 8628 section 3.2)
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// OAuthTokenResponse is a successful OAuth 2.0 token endpoint response
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// OAuthErrorResponse is an OAuth 2.0 token endpoint error
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// DeviceVerifyRequest approves or denies a pending device login
+type DeviceVerifyRequest struct {
+	UserCode string `json:"user_code" validate:"required"`
+	Approve  bool   `json:"approve"`
+}
+
+// DeviceVerifyResponse reports the outcome of a device verification
+type DeviceVerifyResponse struct {
+	UserCode string `json:"user_code"`
+	ClientID string `json:"client_id"`
+	Status   string `json:"status"`
+}
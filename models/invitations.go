@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// Invitation statuses
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// Invitation is an offer to join an organization with a given role
+type Invitation struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	Role           string     `json:"role"`
+	Username       *string    `json:"username,omitempty"`
+	Email          *string    `json:"email,omitempty"`
+	InvitedBy      *uuid.UUID `json:"invited_by,omitempty"`
+	Status         string     `json:"status"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	RespondedAt    *time.Time `json:"responded_at,omitempty"`
+	RespondedBy    *uuid.UUID `json:"responded_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// InvitationWithToken is returned once, when an invitation is created
+type InvitationWithToken struct {
+	Invitation
+	Token string `json:"token"`
+}
+
+// CreateInvitationRequest represents the request body for inviting someone to an organization.
+// Username or email bind the invitation to one account; without them anyone holding the token can accept.
+type CreateInvitationRequest struct {
+	Role      string     `json:"role,omitempty"`
+	Username  *string    `json:"username,omitempty" validate:"omitempty,min=3,max=25"`
+	Email     *string    `json:"email,omitempty" validate:"omitempty,email,max=255"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// InvitationTokenRequest represents the request body for accepting or declining an invitation
+type InvitationTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// DatabaseInvitationToInvitation converts a database invitation to an invitation model
+func DatabaseInvitationToInvitation(dbInvitation database.OrganizationInvitation) Invitation {
+	invitation := Invitation{
+		ID:             dbInvitation.ID,
+		OrganizationID: dbInvitation.OrganizationID,
+		Role:           dbInvitation.Role,
+		Status:         InvitationPending,
+		ExpiresAt:      dbInvitation.ExpiresAt,
+		CreatedAt:      dbInvitation.CreatedAt,
+	}
+
+	// Handle nullable fields
+	if dbInvitation.Username.Valid {
+		invitation.Username = &dbInvitation.Username.String
+	}
+
+	if dbInvitation.Email.Valid {
+		invitation.Email = &dbInvitation.Email.String
+	}
+
+	if dbInvitation.InvitedBy.Valid {
+		invitation.InvitedBy = &dbInvitation.InvitedBy.UUID
+	}
+
+	if dbInvitation.RespondedBy.Valid {
+		invitation.RespondedBy = &dbInvitation.RespondedBy.UUID
+	}
+
+	switch {
+	case dbInvitation.AcceptedAt.Valid:
+		invitation.Status = InvitationAccepted
+		invitation.RespondedAt = &dbInvitation.AcceptedAt.Time
+	case dbInvitation.DeclinedAt.Valid:
+		invitation.Status = InvitationDeclined
+		invitation.RespondedAt = &dbInvitation.DeclinedAt.Time
+	case dbInvitation.RevokedAt.Valid:
+		invitation.Status = InvitationRevoked
+	case !dbInvitation.ExpiresAt.After(time.Now().UTC()):
+		invitation.Status = InvitationExpired
+	}
+
+	return invitation
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// OrganizationMember is a user's membership as seen from the organization
+type OrganizationMember struct {
+	UserID      uuid.UUID  `json:"user_id"`
+	Username    string     `json:"username"`
+	AccountType string     `json:"account_type"`
+	Role        string     `json:"role"`
+	DisabledAt  *time.Time `json:"disabled_at,omitempty"`
+	JoinedAt    time.Time  `json:"joined_at"`
+}
+
+// Membership is an organization the current user belongs to
+type Membership struct {
+	OrganizationID   uuid.UUID `json:"organization_id"`
+	OrganizationName string    `json:"organization_name"`
+	Role             string    `json:"role"`
+	Default          bool      `json:"default"`
+	JoinedAt         time.Time `json:"joined_at"`
+}
+
+// DatabaseOrganizationMemberRowToOrganizationMember converts a database member row to a member model
+func DatabaseOrganizationMemberRowToOrganizationMember(row database.ListOrganizationMembersRow) OrganizationMember {
+	member := OrganizationMember{
+		UserID:      row.OrganizationMember.UserID,
+		Username:    row.Username,
+		AccountType: row.AccountType,
+		Role:        row.OrganizationMember.Role,
+		JoinedAt:    row.OrganizationMember.CreatedAt,
+	}
+
+	// Handle nullable fields
+	if row.DisabledAt.Valid {
+		member.DisabledAt = &row.DisabledAt.Time
+	}
+
+	return member
+}
+
+// DatabaseMembershipRowToMembership converts a database membership row to a membership model
+func DatabaseMembershipRowToMembership(row database.ListUserMembershipsRow, defaultOrganizationID uuid.NullUUID) Membership {
+	return Membership{
+		OrganizationID:   row.OrganizationMember.OrganizationID,
+		OrganizationName: row.OrganizationName,
+		Role:             row.OrganizationMember.Role,
+		Default:          defaultOrganizationID.Valid && defaultOrganizationID.UUID == row.OrganizationMember.OrganizationID,
+		JoinedAt:         row.OrganizationMember.CreatedAt,
+	}
+}
@@ -1,12 +1,18 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/omed0/go-hello-world/internal/database"
+	"github.com/omed0/go-hello-world/internal/oidc"
 )
 
+// RedactedSecret replaces secrets in organization settings returned by the API.
+// Sending it back on update keeps the stored secret.
+const RedactedSecret = "********"
+
 // Organization represents an organization in the system
 type Organization struct {
 	ID          uuid.UUID              `json:"id"`
@@ -17,6 +23,12 @@ type Organization struct {
 	UpdatedAt   time.Time              `json:"updated_at"`
 }
 
+// OrganizationSettings holds the settings keys the server interprets itself
+type OrganizationSettings struct {
+	PasswordPolicy string               `json:"password_policy,omitempty"`
+	OIDC           *oidc.ProviderConfig `json:"oidc,omitempty"`
+}
+
 // CreateOrganizationRequest represents the request body for creating an organization
 type CreateOrganizationRequest struct {
 	Name        string                 `json:"name" validate:"required,min=2,max=100"`
@@ -47,14 +59,27 @@ func DatabaseOrganizationToOrganization(dbOrg database.Organization) Organizatio
 
 	// Handle JSONB settings
 	if dbOrg.Settings.Valid {
-		// Note: In a real implementation, you'd unmarshal the JSON
-		// For now, we'll leave it empty
 		org.Settings = make(map[string]interface{})
+		if err := json.Unmarshal(dbOrg.Settings.RawMessage, &org.Settings); err != nil {
+			org.Settings = nil
+		}
+		redactOIDCSecret(org.Settings)
 	}
 
 	return org
 }
 
+// redactOIDCSecret hides the OIDC client secret in organization settings
+func redactOIDCSecret(settings map[string]interface{}) {
+	provider, ok := settings["oidc"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if secret, _ := provider["client_secret"].(string); secret != "" {
+		provider["client_secret"] = RedactedSecret
+	}
+}
+
 // DatabaseOrganizationsToOrganizations converts a slice of database organizations to organization models
 func DatabaseOrganizationsToOrganizations(dbOrgs []database.Organization) []Organization {
 	orgs := make([]Organization, len(dbOrgs))
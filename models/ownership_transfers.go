@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// OwnershipTransfer is an owner's offer to hand an organization over to another member
+type OwnershipTransfer struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	FromUserID     uuid.UUID  `json:"from_user_id"`
+	ToUserID       uuid.UUID  `json:"to_user_id"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CreateOwnershipTransferRequest represents the request body for starting an ownership transfer
+type CreateOwnershipTransferRequest struct {
+	UserID uuid.UUID `json:"user_id" validate:"required"`
+}
+
+// DatabaseOwnershipTransferToOwnershipTransfer converts a database ownership transfer to an ownership transfer model
+func DatabaseOwnershipTransferToOwnershipTransfer(dbTransfer database.OrganizationOwnershipTransfer) OwnershipTransfer {
+	transfer := OwnershipTransfer{
+		ID:             dbTransfer.ID,
+		OrganizationID: dbTransfer.OrganizationID,
+		FromUserID:     dbTransfer.FromUserID,
+		ToUserID:       dbTransfer.ToUserID,
+		ExpiresAt:      dbTransfer.ExpiresAt,
+		CreatedAt:      dbTransfer.CreatedAt,
+	}
+
+	// Handle nullable fields
+	if dbTransfer.AcceptedAt.Valid {
+		transfer.AcceptedAt = &dbTransfer.AcceptedAt.Time
+	}
+
+	return transfer
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/authz"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// Role is a named set of permissions; built-in roles have no ID
+type Role struct {
+	ID          *uuid.UUID `json:"id,omitempty"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description,omitempty"`
+	Permissions []string   `json:"permissions"`
+	BuiltIn     bool       `json:"built_in"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// CreateRoleRequest represents the request body for defining a custom organization role
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,max=50"`
+	Description *string  `json:"description,omitempty" validate:"omitempty,max=500"`
+	Permissions []string `json:"permissions" validate:"required"`
+}
+
+// UpdateRoleRequest represents the request body for changing a custom role; the name cannot change
+type UpdateRoleRequest struct {
+	Description *string  `json:"description,omitempty" validate:"omitempty,max=500"`
+	Permissions []string `json:"permissions" validate:"required"`
+}
+
+// AssignRoleRequest represents the request body for changing a member's role
+type AssignRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+// BuiltinRoleToRole converts a built-in role to a role model
+func BuiltinRoleToRole(role authz.Role) Role {
+	return Role{
+		Name:        role.Name,
+		Permissions: role.Permissions,
+		BuiltIn:     true,
+	}
+}
+
+// DatabaseOrganizationRoleToRole converts a database custom role to a role model
+func DatabaseOrganizationRoleToRole(dbRole database.OrganizationRole) Role {
+	role := Role{
+		ID:          &dbRole.ID,
+		Name:        dbRole.Name,
+		Permissions: dbRole.Permissions,
+		CreatedAt:   &dbRole.CreatedAt,
+		UpdatedAt:   &dbRole.UpdatedAt,
+	}
+
+	// Handle nullable description
+	if dbRole.Description.Valid {
+		role.Description = &dbRole.Description.String
+	}
+
+	return role
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// ServiceAccount represents a non-human account owned by an organization that authenticates only with API keys
+type ServiceAccount struct {
+	ID             uuid.UUID  `json:"id"`
+	Name           string     `json:"name"`
+	AccountType    string     `json:"account_type"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty"`
+	Disabled       bool       `json:"disabled"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ServiceAccountWithKey is returned when a service account is created, together with its first API key
+type ServiceAccountWithKey struct {
+	ServiceAccount
+	APIKey APIKeyWithSecret `json:"api_key"`
+}
+
+// CreateServiceAccountRequest represents the request body for creating a service account and its first API key
+type CreateServiceAccountRequest struct {
+	Name      string     `json:"name" validate:"required,min=3,max=25"`
+	KeyName   string     `json:"key_name,omitempty" validate:"omitempty,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// DatabaseUserToServiceAccount converts a database user of the service account type to a service account model
+func DatabaseUserToServiceAccount(dbUser database.User) ServiceAccount {
+	account := ServiceAccount{
+		ID:             dbUser.ID,
+		Name:           dbUser.Username,
+		AccountType:    dbUser.AccountType,
+		OrganizationID: dbUser.OrganizationID.UUID,
+		Disabled:       dbUser.DisabledAt.Valid,
+		CreatedAt:      dbUser.CreatedAt,
+		UpdatedAt:      dbUser.UpdatedAt,
+	}
+
+	// Handle nullable fields
+	if dbUser.CreatedBy.Valid {
+		account.CreatedBy = &dbUser.CreatedBy.UUID
+	}
+
+	if dbUser.DisabledAt.Valid {
+		account.DisabledAt = &dbUser.DisabledAt.Time
+	}
+
+	return account
+}
+
+// DatabaseUsersToServiceAccounts converts a slice of database users to service account models
+func DatabaseUsersToServiceAccounts(dbUsers []database.User) []ServiceAccount {
+	accounts := make([]ServiceAccount, len(dbUsers))
+	for i, dbUser := range dbUsers {
+		accounts[i] = DatabaseUserToServiceAccount(dbUser)
+	}
+	return accounts
+}
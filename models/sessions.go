@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omed0/go-hello-world/internal/database"
+)
+
+// Session represents an active login without its refresh token
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	Client     *string   `json:"client,omitempty"`
+	IPAddress  *string   `json:"ip_address,omitempty"`
+	UserAgent  *string   `json:"user_agent,omitempty"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// DatabaseSessionToSession converts a database session to a session model
+func DatabaseSessionToSession(dbSession database.Session) Session {
+	session := Session{
+		ID:         dbSession.ID,
+		CreatedAt:  dbSession.CreatedAt,
+		LastSeenAt: dbSession.LastSeenAt,
+		ExpiresAt:  dbSession.ExpiresAt,
+	}
+
+	// Handle nullable request metadata
+	if dbSession.Client.Valid {
+		session.Client = &dbSession.Client.String
+	}
+	if dbSession.IpAddress.Valid {
+		session.IPAddress = &dbSession.IpAddress.String
+	}
+	if dbSession.UserAgent.Valid {
+		session.UserAgent = &dbSession.UserAgent.String
+	}
+
+	return session
+}
+
+// DatabaseSessionsToSessions converts a slice of database sessions to session models
+func DatabaseSessionsToSessions(dbSessions []database.Session) []Session {
+	sessions := make([]Session, len(dbSessions))
+	for i, dbSession := range dbSessions {
+		sessions[i] = DatabaseSessionToSession(dbSession)
+	}
+	return sessions
+}
@@ -16,7 +16,7 @@ type Task struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
-	UserID      uuid.UUID  `json:"user_id"`
+	UserID      *uuid.UUID `json:"user_id,omitempty"` // empty once the owner deleted their account and the task was anonymized
 }
 
 // DatabaseTaskToTask converts a database task to a task model
@@ -28,7 +28,10 @@ func DatabaseTaskToTask(dbTask database.Task) Task {
 		IsCompleted: dbTask.IsCompleted,
 		CreatedAt:   dbTask.CreatedAt,
 		UpdatedAt:   dbTask.UpdatedAt,
-		UserID:      dbTask.UserID,
+	}
+
+	if dbTask.UserID.Valid {
+		task.UserID = &dbTask.UserID.UUID
 	}
 
 	// Handle nullable deleted_at field
@@ -0,0 +1,43 @@
+package models
+
+// TwoFactorStatus represents whether two-factor authentication is enabled for the current user
+type TwoFactorStatus struct {
+	Enabled                bool  `json:"enabled"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorEnrollment holds the secret for a pending TOTP enrollment
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorCodeRequest represents a request body carrying a TOTP code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,len=6"`
+}
+
+// DisableTwoFactorRequest represents the request body for turning off two-factor authentication
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,len=6"`
+}
+
+// RecoveryCodesResponse returns freshly generated one-time recovery codes; they are shown only once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallengeResponse is returned by login when the account requires a second factor
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// LoginTwoFactorRequest completes a two-factor login with a TOTP code or a recovery code
+type LoginTwoFactorRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
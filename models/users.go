@@ -14,21 +14,21 @@ type User struct {
 	Username         string     `json:"username"`
 	Age              *int       `json:"age,omitempty"`
 	Gender           *string    `json:"gender,omitempty"`
-	Role             string     `json:"role"`
+	Role             string     `json:"role,omitempty"` // role in the default organization
+	AccountType      string     `json:"account_type"`
+	DisabledAt       *time.Time `json:"disabled_at,omitempty"`
 	OrganizationID   *uuid.UUID `json:"organization_id,omitempty"`
 	OrganizationName *string    `json:"organization_name,omitempty"`
-	APIKey           string     `json:"api_key"`
 	CreatedAt        time.Time  `json:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at"`
 }
 
 // CreateUserRequest represents the request body for creating a user
 type CreateUserRequest struct {
-	Username       string     `json:"username" validate:"required,min=3,max=25"`
-	Password       string     `json:"password" validate:"required,min=8"`
-	Age            *int       `json:"age,omitempty" validate:"omitempty,min=13,max=120"`
-	Gender         *string    `json:"gender,omitempty" validate:"omitempty,oneof=male female other prefer_not_to_say"`
-	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	Username string  `json:"username" validate:"required,min=3,max=25"`
+	Password string  `json:"password" validate:"required,min=8"`
+	Age      *int    `json:"age,omitempty" validate:"omitempty,min=13,max=120"`
+	Gender   *string `json:"gender,omitempty" validate:"omitempty,oneof=male female other prefer_not_to_say"`
 }
 
 // LoginRequest represents the request body for user login
@@ -39,8 +39,39 @@ type LoginRequest struct {
 
 // LoginResponse represents the response for successful login
 type LoginResponse struct {
-	User  User   `json:"user"`
-	Token string `json:"token,omitempty"` // For future JWT implementation
+	User User `json:"user"`
+	TokenResponse
+}
+
+// TokenResponse represents an issued access token and its refresh token
+type TokenResponse struct {
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int       `json:"expires_in"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// RefreshTokenRequest represents the request body for refreshing an access token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// ChangePasswordRequest represents the request body for changing the current user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
+// PasswordResetRequest represents the request body for requesting a password reset token
+type PasswordResetRequest struct {
+	Username string `json:"username" validate:"required"`
+}
+
+// PasswordResetConfirmRequest represents the request body for resetting a password with a token
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
 }
 
 // UpdateUserRequest represents the request body for updating a user
@@ -53,15 +84,18 @@ type UpdateUserRequest struct {
 // DatabaseUserToUser converts a database user to a user model
 func DatabaseUserToUser(dbUser database.User) User {
 	user := User{
-		ID:        dbUser.ID,
-		Username:  dbUser.Username,
-		Role:      dbUser.Role,
-		APIKey:    dbUser.ApiKey,
-		CreatedAt: dbUser.CreatedAt,
-		UpdatedAt: dbUser.UpdatedAt,
+		ID:          dbUser.ID,
+		Username:    dbUser.Username,
+		AccountType: dbUser.AccountType,
+		CreatedAt:   dbUser.CreatedAt,
+		UpdatedAt:   dbUser.UpdatedAt,
 	}
 
 	// Handle nullable fields
+	if dbUser.DisabledAt.Valid {
+		user.DisabledAt = &dbUser.DisabledAt.Time
+	}
+
 	if dbUser.Age.Valid {
 		age := int(dbUser.Age.Int32)
 		user.Age = &age
@@ -82,13 +116,11 @@ func DatabaseUserToUser(dbUser database.User) User {
 func DatabaseUserRowToUser(dbUser interface{}) User {
 	switch v := dbUser.(type) {
 	case database.GetUserByIDRow:
-		return rowToUser(v.ID, v.Username, v.Role, v.ApiKey, v.CreatedAt, v.UpdatedAt, v.Age, v.Gender, v.OrganizationID, v.OrganizationName)
+		return rowToUser(v.ID, v.Username, v.Role, v.AccountType, v.DisabledAt, v.CreatedAt, v.UpdatedAt, v.Age, v.Gender, v.OrganizationID, v.OrganizationName)
 	case database.GetUserByUsernameRow:
-		return rowToUser(v.ID, v.Username, v.Role, v.ApiKey, v.CreatedAt, v.UpdatedAt, v.Age, v.Gender, v.OrganizationID, v.OrganizationName)
-	case database.GetUserByAPIKeyRow:
-		return rowToUser(v.ID, v.Username, v.Role, v.ApiKey, v.CreatedAt, v.UpdatedAt, v.Age, v.Gender, v.OrganizationID, v.OrganizationName)
+		return rowToUser(v.ID, v.Username, v.Role, v.AccountType, v.DisabledAt, v.CreatedAt, v.UpdatedAt, v.Age, v.Gender, v.OrganizationID, v.OrganizationName)
 	case database.GetUserByUsernameAndPasswordRow:
-		return rowToUser(v.ID, v.Username, v.Role, v.ApiKey, v.CreatedAt, v.UpdatedAt, v.Age, v.Gender, v.OrganizationID, v.OrganizationName)
+		return rowToUser(v.ID, v.Username, v.Role, v.AccountType, v.DisabledAt, v.CreatedAt, v.UpdatedAt, v.Age, v.Gender, v.OrganizationID, v.OrganizationName)
 	default:
 		// Fallback to empty user if unknown type
 		return User{}
@@ -96,17 +128,21 @@ func DatabaseUserRowToUser(dbUser interface{}) User {
 }
 
 // Helper function to convert row data to User
-func rowToUser(id uuid.UUID, username string, role string, apiKey string, createdAt time.Time, updatedAt time.Time, age sql.NullInt32, gender sql.NullString, organizationID uuid.NullUUID, organizationName sql.NullString) User {
+func rowToUser(id uuid.UUID, username string, role sql.NullString, accountType string, disabledAt sql.NullTime, createdAt time.Time, updatedAt time.Time, age sql.NullInt32, gender sql.NullString, organizationID uuid.NullUUID, organizationName sql.NullString) User {
 	user := User{
-		ID:        id,
-		Username:  username,
-		Role:      role,
-		APIKey:    apiKey,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
+		ID:          id,
+		Username:    username,
+		Role:        role.String,
+		AccountType: accountType,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
 	}
 
 	// Handle nullable fields
+	if disabledAt.Valid {
+		user.DisabledAt = &disabledAt.Time
+	}
+
 	if age.Valid {
 		ageInt := int(age.Int32)
 		user.Age = &ageInt
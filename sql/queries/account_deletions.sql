@@ -0,0 +1,50 @@
+-- name: ScheduleAccountDeletion :one
+INSERT INTO account_deletions (user_id, task_action, transfer_to, scheduled_for)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET task_action = EXCLUDED.task_action,
+    transfer_to = EXCLUDED.transfer_to,
+    scheduled_for = EXCLUDED.scheduled_for,
+    requested_at = NOW()
+RETURNING *;
+
+-- name: GetAccountDeletion :one
+SELECT * FROM account_deletions
+WHERE user_id = $1;
+
+-- name: CancelAccountDeletion :execrows
+DELETE FROM account_deletions
+WHERE user_id = $1;
+
+-- name: ListDueAccountDeletions :many
+-- Sole owners are skipped so they cannot hold up the accounts scheduled after them
+SELECT * FROM account_deletions d
+WHERE d.scheduled_for <= NOW()
+  AND NOT EXISTS (
+      SELECT 1 FROM organization_members m
+      WHERE m.user_id = d.user_id AND m.role = 'owner'
+        AND NOT EXISTS (
+            SELECT 1 FROM organization_members other
+            WHERE other.organization_id = m.organization_id AND other.role = 'owner' AND other.user_id <> m.user_id
+        )
+  )
+ORDER BY d.scheduled_for
+LIMIT $1;
+
+-- name: ListSoleOwnedOrganizations :many
+SELECT organizations.id, organizations.name
+FROM organization_members m
+JOIN organizations ON organizations.id = m.organization_id
+WHERE m.user_id = $1 AND m.role = 'owner'
+  AND NOT EXISTS (
+      SELECT 1 FROM organization_members other
+      WHERE other.organization_id = m.organization_id AND other.role = 'owner' AND other.user_id <> m.user_id
+  )
+ORDER BY organizations.name;
+
+-- name: SharesOrganization :one
+SELECT EXISTS (
+    SELECT 1 FROM organization_members a
+    JOIN organization_members b ON b.organization_id = a.organization_id
+    WHERE a.user_id = @user_id AND b.user_id = @other_user_id
+);
@@ -0,0 +1,127 @@
+-- Queries for system administrators; they see every account and organization, including deleted ones
+
+-- name: IsSystemAdmin :one
+SELECT is_system_admin FROM users
+WHERE id = $1 AND disabled_at IS NULL;
+
+-- name: AdminListUsers :many
+SELECT u.*, o.name AS organization_name
+FROM users u
+LEFT JOIN organizations o ON o.id = u.organization_id
+WHERE (sqlc.narg(search)::text IS NULL OR u.username ILIKE '%' || sqlc.narg(search)::text || '%')
+  AND (sqlc.narg(account_type)::text IS NULL OR u.account_type = sqlc.narg(account_type)::text)
+  AND (sqlc.narg(suspended)::boolean IS NULL OR (u.disabled_at IS NOT NULL) = sqlc.narg(suspended)::boolean)
+  AND (sqlc.narg(organization_id)::uuid IS NULL OR EXISTS (
+      SELECT 1 FROM organization_members m
+      WHERE m.user_id = u.id AND m.organization_id = sqlc.narg(organization_id)::uuid
+  ))
+ORDER BY u.created_at DESC, u.id
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: AdminCountUsers :one
+SELECT COUNT(*) FROM users u
+WHERE (sqlc.narg(search)::text IS NULL OR u.username ILIKE '%' || sqlc.narg(search)::text || '%')
+  AND (sqlc.narg(account_type)::text IS NULL OR u.account_type = sqlc.narg(account_type)::text)
+  AND (sqlc.narg(suspended)::boolean IS NULL OR (u.disabled_at IS NOT NULL) = sqlc.narg(suspended)::boolean)
+  AND (sqlc.narg(organization_id)::uuid IS NULL OR EXISTS (
+      SELECT 1 FROM organization_members m
+      WHERE m.user_id = u.id AND m.organization_id = sqlc.narg(organization_id)::uuid
+  ));
+
+-- name: AdminGetUser :one
+SELECT * FROM users WHERE id = $1;
+
+-- name: AdminListOrganizations :many
+SELECT sqlc.embed(o),
+       (SELECT COUNT(*) FROM organization_members m WHERE m.organization_id = o.id) AS member_count
+FROM organizations o
+WHERE (sqlc.narg(search)::text IS NULL OR o.name ILIKE '%' || sqlc.narg(search)::text || '%')
+  AND (@include_deleted::boolean OR o.deleted_at IS NULL)
+ORDER BY o.created_at DESC, o.id
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: AdminCountOrganizations :one
+SELECT COUNT(*) FROM organizations o
+WHERE (sqlc.narg(search)::text IS NULL OR o.name ILIKE '%' || sqlc.narg(search)::text || '%')
+  AND (@include_deleted::boolean OR o.deleted_at IS NULL);
+
+-- name: AdminGetOrganization :one
+SELECT * FROM organizations WHERE id = $1;
+
+-- name: CountOrganizationDependents :one
+-- Service accounts belong to the organization when it is their only membership, whatever users.organization_id says
+WITH owned_service_accounts AS (
+    SELECT u.id FROM users u
+    JOIN organization_members m ON m.user_id = u.id
+    WHERE m.organization_id = @id AND u.account_type = 'service'
+      AND NOT EXISTS (
+          SELECT 1 FROM organization_members other
+          WHERE other.user_id = u.id AND other.organization_id <> @id
+      )
+)
+SELECT
+    (SELECT COUNT(*) FROM organization_members m WHERE m.organization_id = @id) AS members,
+    (SELECT COUNT(*) FROM organization_roles r WHERE r.organization_id = @id) AS custom_roles,
+    (SELECT COUNT(*) FROM organization_invitations i WHERE i.organization_id = @id) AS invitations,
+    (SELECT COUNT(*) FROM organization_ownership_transfers t WHERE t.organization_id = @id) AS ownership_transfers,
+    (SELECT COUNT(*) FROM users u WHERE u.organization_id = @id AND u.account_type = 'human') AS default_organization_users,
+    (SELECT COUNT(*) FROM owned_service_accounts) AS service_accounts,
+    (SELECT COUNT(*) FROM api_keys k
+     WHERE k.user_id IN (SELECT id FROM owned_service_accounts) AND k.revoked_at IS NULL) AS service_account_keys,
+    (SELECT COUNT(*) FROM client_certificate_mappings c
+     WHERE c.user_id IN (SELECT id FROM owned_service_accounts)) AS service_account_certificates,
+    (SELECT COUNT(*) FROM tasks t
+     WHERE t.user_id IN (SELECT id FROM owned_service_accounts)) AS service_account_tasks;
+
+-- name: RevokeOrganizationServiceAccountKeys :many
+UPDATE api_keys
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE revoked_at IS NULL
+  AND user_id IN (
+      SELECT u.id FROM users u
+      JOIN organization_members m ON m.user_id = u.id
+      WHERE m.organization_id = @id AND u.account_type = 'service'
+        AND NOT EXISTS (
+            SELECT 1 FROM organization_members other
+            WHERE other.user_id = u.id AND other.organization_id <> @id
+        )
+  )
+RETURNING *;
+
+-- name: DeleteOrganizationServiceAccountCertificates :many
+DELETE FROM client_certificate_mappings
+WHERE user_id IN (
+    SELECT u.id FROM users u
+    JOIN organization_members m ON m.user_id = u.id
+    WHERE m.organization_id = @id AND u.account_type = 'service'
+      AND NOT EXISTS (
+          SELECT 1 FROM organization_members other
+          WHERE other.user_id = u.id AND other.organization_id <> @id
+      )
+)
+RETURNING *;
+
+-- name: DeleteOrganizationServiceAccountTasks :execrows
+DELETE FROM tasks
+WHERE user_id IN (
+    SELECT u.id FROM users u
+    JOIN organization_members m ON m.user_id = u.id
+    WHERE m.organization_id = @id AND u.account_type = 'service'
+      AND NOT EXISTS (
+          SELECT 1 FROM organization_members other
+          WHERE other.user_id = u.id AND other.organization_id <> @id
+      )
+);
+
+-- name: DeleteOrganizationServiceAccounts :many
+DELETE FROM users
+WHERE account_type = 'service'
+  AND id IN (
+      SELECT m.user_id FROM organization_members m
+      WHERE m.organization_id = @id
+        AND NOT EXISTS (
+            SELECT 1 FROM organization_members other
+            WHERE other.user_id = m.user_id AND other.organization_id <> @id
+        )
+  )
+RETURNING *;
@@ -0,0 +1,51 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, user_id, name, key_prefix, key_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetAPIKeysByUser :many
+SELECT * FROM api_keys
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: GetAPIKeyByID :one
+SELECT * FROM api_keys
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: GetActiveAPIKey :one
+SELECT sqlc.embed(api_keys), users.username, users.organization_id, users.account_type
+FROM api_keys
+JOIN users ON users.id = api_keys.user_id
+WHERE api_keys.key_hash = $1
+  AND api_keys.revoked_at IS NULL
+  AND (api_keys.expires_at IS NULL OR api_keys.expires_at > NOW())
+  AND (api_keys.rotate_by IS NULL OR api_keys.rotate_by > NOW())
+  AND users.disabled_at IS NULL;
+
+-- name: RotateAPIKey :one
+UPDATE api_keys
+SET key_prefix = $3, key_hash = $4, rotate_by = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: TouchAPIKeyLastUsed :exec
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1;
+
+-- name: RevokeOtherUserAPIKeys :exec
+UPDATE api_keys
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL;
+
+-- name: RequireUserAPIKeyRotation :execrows
+UPDATE api_keys
+SET rotate_by = LEAST(COALESCE(rotate_by, @rotate_by), @rotate_by), updated_at = NOW()
+WHERE user_id = @user_id AND revoked_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW());
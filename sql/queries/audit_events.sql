@@ -0,0 +1,43 @@
+-- Audit events are only ever inserted; filters left NULL match every event
+
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (
+    id, organization_id, actor_id, actor_username, auth_method, action, target_type, target_id, changes, ip_address, request_id
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg(organization_id)::uuid IS NULL OR organization_id = sqlc.narg(organization_id)::uuid)
+  AND (sqlc.narg(actor_id)::uuid IS NULL OR actor_id = sqlc.narg(actor_id)::uuid)
+  AND (sqlc.narg(action)::text IS NULL OR action = sqlc.narg(action)::text OR action LIKE sqlc.narg(action)::text || '.%')
+  AND (sqlc.narg(target_type)::text IS NULL OR target_type = sqlc.narg(target_type)::text)
+  AND (sqlc.narg(target_id)::uuid IS NULL OR target_id = sqlc.narg(target_id)::uuid)
+  AND (sqlc.narg(since)::timestamp IS NULL OR created_at >= sqlc.narg(since)::timestamp)
+  AND (sqlc.narg(until)::timestamp IS NULL OR created_at < sqlc.narg(until)::timestamp)
+ORDER BY created_at DESC, id DESC
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: CountAuditEvents :one
+SELECT COUNT(*) FROM audit_events
+WHERE (sqlc.narg(organization_id)::uuid IS NULL OR organization_id = sqlc.narg(organization_id)::uuid)
+  AND (sqlc.narg(actor_id)::uuid IS NULL OR actor_id = sqlc.narg(actor_id)::uuid)
+  AND (sqlc.narg(action)::text IS NULL OR action = sqlc.narg(action)::text OR action LIKE sqlc.narg(action)::text || '.%')
+  AND (sqlc.narg(target_type)::text IS NULL OR target_type = sqlc.narg(target_type)::text)
+  AND (sqlc.narg(target_id)::uuid IS NULL OR target_id = sqlc.narg(target_id)::uuid)
+  AND (sqlc.narg(since)::timestamp IS NULL OR created_at >= sqlc.narg(since)::timestamp)
+  AND (sqlc.narg(until)::timestamp IS NULL OR created_at < sqlc.narg(until)::timestamp);
+
+-- name: ExportAuditEvents :many
+-- Oldest first, continuing after the last event of the previous batch
+SELECT * FROM audit_events
+WHERE (created_at, id) > (@after_created_at::timestamp, @after_id::uuid)
+  AND (sqlc.narg(organization_id)::uuid IS NULL OR organization_id = sqlc.narg(organization_id)::uuid)
+  AND (sqlc.narg(actor_id)::uuid IS NULL OR actor_id = sqlc.narg(actor_id)::uuid)
+  AND (sqlc.narg(action)::text IS NULL OR action = sqlc.narg(action)::text OR action LIKE sqlc.narg(action)::text || '.%')
+  AND (sqlc.narg(target_type)::text IS NULL OR target_type = sqlc.narg(target_type)::text)
+  AND (sqlc.narg(target_id)::uuid IS NULL OR target_id = sqlc.narg(target_id)::uuid)
+  AND (sqlc.narg(since)::timestamp IS NULL OR created_at >= sqlc.narg(since)::timestamp)
+  AND (sqlc.narg(until)::timestamp IS NULL OR created_at < sqlc.narg(until)::timestamp)
+ORDER BY created_at, id
+LIMIT @page_limit;
@@ -0,0 +1,33 @@
+-- name: CreateClientCertificateMapping :one
+INSERT INTO client_certificate_mappings (id, user_id, organization_id, match_type, match_value, scopes, created_by)
+VALUES (@id, @user_id, @organization_id, @match_type, @match_value, @scopes, @created_by)
+RETURNING *;
+
+-- name: GetActiveClientCertificateMapping :one
+SELECT sqlc.embed(client_certificate_mappings), users.username, users.organization_id, users.account_type
+FROM client_certificate_mappings
+JOIN users ON users.id = client_certificate_mappings.user_id
+WHERE client_certificate_mappings.match_type = $1
+  AND client_certificate_mappings.match_value = $2
+  AND users.disabled_at IS NULL
+  AND EXISTS (
+      SELECT 1 FROM organization_members
+      WHERE organization_members.user_id = client_certificate_mappings.user_id
+        AND organization_members.organization_id = client_certificate_mappings.organization_id
+  );
+
+-- name: ListOrganizationClientCertificateMappings :many
+SELECT sqlc.embed(client_certificate_mappings), users.username
+FROM client_certificate_mappings
+JOIN users ON users.id = client_certificate_mappings.user_id
+WHERE client_certificate_mappings.organization_id = $1
+ORDER BY users.username, client_certificate_mappings.created_at;
+
+-- name: DeleteClientCertificateMapping :execrows
+DELETE FROM client_certificate_mappings
+WHERE id = $1 AND organization_id = $2;
+
+-- name: TouchClientCertificateMapping :exec
+UPDATE client_certificate_mappings
+SET last_used_at = NOW()
+WHERE id = $1;
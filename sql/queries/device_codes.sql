@@ -0,0 +1,41 @@
+-- name: CreateDeviceCode :one
+INSERT INTO device_codes (id, device_code_hash, user_code, client_id, poll_interval, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetDeviceCodeByHash :one
+SELECT * FROM device_codes WHERE device_code_hash = $1;
+
+-- name: GetPendingDeviceCodeByUserCode :one
+SELECT * FROM device_codes
+WHERE user_code = $1 AND status = 'pending' AND expires_at > NOW();
+
+-- name: GetPendingDeviceCodeByID :one
+SELECT * FROM device_codes
+WHERE id = $1 AND status = 'pending' AND expires_at > NOW();
+
+-- name: RecordDeviceCodePoll :exec
+UPDATE device_codes
+SET last_polled_at = $2, poll_interval = $3
+WHERE id = $1;
+
+-- name: ApproveDeviceCode :one
+UPDATE device_codes
+SET status = 'approved', user_id = $2
+WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+RETURNING *;
+
+-- name: DenyDeviceCode :one
+UPDATE device_codes
+SET status = 'denied'
+WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+RETURNING *;
+
+-- name: ConsumeDeviceCode :one
+UPDATE device_codes
+SET status = 'consumed'
+WHERE id = $1 AND status = 'approved'
+RETURNING *;
+
+-- name: DeleteExpiredDeviceCodes :exec
+DELETE FROM device_codes WHERE expires_at <= NOW();
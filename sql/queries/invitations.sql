@@ -0,0 +1,43 @@
+-- name: CreateOrganizationInvitation :one
+INSERT INTO organization_invitations (id, organization_id, token_hash, role, username, email, invited_by, expires_at)
+VALUES (@id, @organization_id, @token_hash, @role, @username, @email, @invited_by, @expires_at)
+RETURNING *;
+
+-- name: ListOrganizationInvitations :many
+SELECT * FROM organization_invitations
+WHERE organization_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetPendingInvitationByToken :one
+SELECT * FROM organization_invitations
+WHERE token_hash = $1 AND organization_id = $2
+  AND accepted_at IS NULL AND declined_at IS NULL AND revoked_at IS NULL
+  AND expires_at > NOW();
+
+-- name: AcceptInvitation :one
+UPDATE organization_invitations
+SET accepted_at = NOW(), responded_by = $2
+WHERE id = $1
+  AND accepted_at IS NULL AND declined_at IS NULL AND revoked_at IS NULL
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeclineInvitation :one
+UPDATE organization_invitations
+SET declined_at = NOW(), responded_by = $2
+WHERE id = $1
+  AND accepted_at IS NULL AND declined_at IS NULL AND revoked_at IS NULL
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: RevokeInvitation :execrows
+UPDATE organization_invitations
+SET revoked_at = NOW()
+WHERE id = $1 AND organization_id = $2
+  AND accepted_at IS NULL AND declined_at IS NULL AND revoked_at IS NULL;
+
+-- name: UserHasIdentityEmail :one
+SELECT EXISTS (
+    SELECT 1 FROM user_identities
+    WHERE user_id = $1 AND LOWER(email) = LOWER(@email::text)
+);
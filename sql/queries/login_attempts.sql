@@ -0,0 +1,23 @@
+-- name: GetLoginAttempt :one
+SELECT * FROM login_attempts WHERE scope = $1 AND key = $2;
+
+-- name: RecordFailedLogin :one
+INSERT INTO login_attempts (scope, key, failed_count, last_failed_at)
+VALUES (@scope, @key, 1, @failed_at)
+ON CONFLICT (scope, key) DO UPDATE
+SET failed_count = CASE
+        WHEN login_attempts.last_failed_at < @window_start THEN 1
+        ELSE login_attempts.failed_count + 1
+    END,
+    last_failed_at = EXCLUDED.last_failed_at
+RETURNING *;
+
+-- name: LockLogin :exec
+UPDATE login_attempts SET locked_until = $3 WHERE scope = $1 AND key = $2;
+
+-- name: ClearLoginAttempts :exec
+DELETE FROM login_attempts WHERE scope = $1 AND key = $2;
+
+-- name: CreateAuthEvent :exec
+INSERT INTO auth_events (id, user_id, username, event_type, ip_address, detail)
+VALUES ($1, $2, $3, $4, $5, $6);
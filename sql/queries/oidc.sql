@@ -0,0 +1,32 @@
+-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (state_hash, organization_id, nonce, code_verifier, expires_at, device_code_id)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = $1 AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states WHERE expires_at <= NOW();
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities WHERE issuer = $1 AND subject = $2;
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (id, user_id, issuer, subject, email)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: TouchUserIdentity :exec
+UPDATE user_identities
+SET email = $2, last_login_at = NOW()
+WHERE id = $1;
+
+-- name: CreateSSOUser :one
+INSERT INTO users (id, username, password_hash, organization_id)
+VALUES (@id, @username, @password_hash, @organization_id)
+RETURNING *;
+
+-- name: UsernameExists :one
+SELECT EXISTS (SELECT 1 FROM users WHERE username = $1);
@@ -0,0 +1,46 @@
+-- name: AddOrganizationMember :one
+INSERT INTO organization_members (organization_id, user_id, role)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetOrganizationMembership :one
+SELECT * FROM organization_members
+WHERE organization_id = $1 AND user_id = $2;
+
+-- name: ListOrganizationMembers :many
+SELECT sqlc.embed(organization_members), users.username, users.account_type, users.disabled_at
+FROM organization_members
+JOIN users ON users.id = organization_members.user_id
+WHERE organization_members.organization_id = $1
+ORDER BY users.username;
+
+-- name: ListUserMemberships :many
+SELECT sqlc.embed(organization_members), organizations.name AS organization_name
+FROM organization_members
+JOIN organizations ON organizations.id = organization_members.organization_id
+WHERE organization_members.user_id = $1 AND organizations.deleted_at IS NULL
+ORDER BY organizations.name;
+
+-- name: UpdateOrganizationMemberRole :one
+UPDATE organization_members
+SET role = $3, updated_at = NOW()
+WHERE organization_id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: CountOrganizationRoleMembers :one
+SELECT COUNT(*) FROM organization_members
+WHERE organization_id = $1 AND role = $2;
+
+-- name: LockOrganizationOwners :many
+SELECT user_id FROM organization_members
+WHERE organization_id = $1 AND role = 'owner'
+FOR UPDATE;
+
+-- name: RemoveOrganizationMember :execrows
+DELETE FROM organization_members
+WHERE organization_id = $1 AND user_id = $2;
+
+-- name: ClearUserDefaultOrganization :exec
+UPDATE users
+SET organization_id = NULL, updated_at = NOW()
+WHERE id = $1 AND organization_id = $2;
@@ -1,11 +1,8 @@
 -- name: CreateOrganization :one
 INSERT INTO organizations (id, name, description, settings) 
-VALUES ($1, $2, $3, COALESCE($4, '{}'))
+VALUES ($1, $2, $3, COALESCE(sqlc.narg(settings)::jsonb, '{}'))
 RETURNING *;
 
--- name: GetAllOrganizations :many
-SELECT * FROM organizations WHERE deleted_at IS NULL;
-
 -- name: GetOrganizationByID :one
 SELECT * FROM organizations WHERE id = $1 AND deleted_at IS NULL;
 
@@ -0,0 +1,26 @@
+-- name: CreateOwnershipTransfer :one
+INSERT INTO organization_ownership_transfers (id, organization_id, from_user_id, to_user_id, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetPendingOwnershipTransfer :one
+SELECT * FROM organization_ownership_transfers
+WHERE organization_id = $1
+  AND accepted_at IS NULL AND cancelled_at IS NULL
+  AND expires_at > NOW()
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: AcceptOwnershipTransfer :one
+UPDATE organization_ownership_transfers
+SET accepted_at = NOW()
+WHERE id = $1
+  AND accepted_at IS NULL AND cancelled_at IS NULL
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: CancelOwnershipTransfers :execrows
+UPDATE organization_ownership_transfers
+SET cancelled_at = NOW()
+WHERE organization_id = $1
+  AND accepted_at IS NULL AND cancelled_at IS NULL;
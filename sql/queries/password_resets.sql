@@ -0,0 +1,19 @@
+-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (id, user_id, token_hash, requested_by, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetValidPasswordResetToken :one
+SELECT * FROM password_reset_tokens
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW();
+
+-- name: UsePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING *;
+
+-- name: InvalidateUserPasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
@@ -0,0 +1,20 @@
+-- name: TakeRateLimitToken :one
+-- Buckets refill at @rate tokens per second up to @capacity, measured with the database clock so every
+-- instance agrees. Returns no row when the bucket holds less than one token, leaving it untouched.
+INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at, idle_until)
+VALUES (@key, CAST(@capacity AS DOUBLE PRECISION) - 1, NOW(), NOW() + make_interval(secs => @period_seconds::float8))
+ON CONFLICT (key) DO UPDATE
+SET tokens = LEAST(@capacity::float8, b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at))::float8 * @rate::float8) - 1,
+    updated_at = NOW(),
+    idle_until = NOW() + make_interval(secs => @period_seconds::float8)
+WHERE LEAST(@capacity::float8, b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at))::float8 * @rate::float8) >= 1
+RETURNING tokens;
+
+-- name: GetRateLimitTokens :one
+SELECT LEAST(@capacity::float8, tokens + EXTRACT(EPOCH FROM (NOW() - updated_at))::float8 * @rate::float8)::float8 AS tokens
+FROM rate_limit_buckets
+WHERE key = @key;
+
+-- name: DeleteIdleRateLimitBuckets :exec
+-- A bucket left alone for a whole period is full again and behaves like a missing one
+DELETE FROM rate_limit_buckets WHERE idle_until < NOW();
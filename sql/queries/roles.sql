@@ -0,0 +1,29 @@
+-- name: CreateOrganizationRole :one
+INSERT INTO organization_roles (id, organization_id, name, description, permissions)
+VALUES (@id, @organization_id, @name, @description, @permissions)
+RETURNING *;
+
+-- name: ListOrganizationRoles :many
+SELECT * FROM organization_roles
+WHERE organization_id = $1
+ORDER BY name;
+
+-- name: GetOrganizationRole :one
+SELECT * FROM organization_roles
+WHERE id = $1 AND organization_id = $2;
+
+-- name: GetOrganizationRoleByName :one
+SELECT * FROM organization_roles
+WHERE organization_id = $1 AND name = $2;
+
+-- name: UpdateOrganizationRole :one
+UPDATE organization_roles
+SET description = @description,
+    permissions = @permissions,
+    updated_at = NOW()
+WHERE id = @id AND organization_id = @organization_id
+RETURNING *;
+
+-- name: DeleteOrganizationRole :execrows
+DELETE FROM organization_roles
+WHERE id = $1 AND organization_id = $2;
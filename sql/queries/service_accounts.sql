@@ -0,0 +1,19 @@
+-- name: CreateServiceAccount :one
+INSERT INTO users (id, username, password_hash, organization_id, account_type, created_by)
+VALUES (@id, @username, '', @organization_id, 'service', @created_by)
+RETURNING *;
+
+-- name: GetServiceAccount :one
+SELECT * FROM users
+WHERE id = $1 AND organization_id = $2 AND account_type = 'service';
+
+-- name: ListServiceAccounts :many
+SELECT * FROM users
+WHERE organization_id = $1 AND account_type = 'service'
+ORDER BY username;
+
+-- name: SetUserDisabledAt :one
+UPDATE users
+SET disabled_at = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
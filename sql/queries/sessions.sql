@@ -0,0 +1,59 @@
+-- name: CreateSession :one
+INSERT INTO sessions (id, user_id, refresh_token_hash, expires_at, client, ip_address, user_agent)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetSessionByRefreshTokenHash :one
+SELECT * FROM sessions
+WHERE refresh_token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW();
+
+-- name: GetActiveSessionByID :one
+SELECT sqlc.embed(sessions), users.username, users.organization_id, users.account_type
+FROM sessions
+JOIN users ON users.id = sessions.user_id
+WHERE sessions.id = $1
+  AND sessions.revoked_at IS NULL
+  AND sessions.expires_at > NOW()
+  AND users.disabled_at IS NULL;
+
+-- name: RotateSessionRefreshToken :one
+UPDATE sessions
+SET refresh_token_hash = @new_hash, expires_at = @expires_at, last_seen_at = NOW(), updated_at = NOW()
+WHERE id = @id AND refresh_token_hash = @old_hash AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RetireRefreshToken :exec
+INSERT INTO retired_refresh_tokens (token_hash, session_id)
+VALUES ($1, $2)
+ON CONFLICT (token_hash) DO NOTHING;
+
+-- name: GetSessionByRetiredRefreshTokenHash :one
+SELECT sessions.* FROM sessions
+JOIN retired_refresh_tokens ON retired_refresh_tokens.session_id = sessions.id
+WHERE retired_refresh_tokens.token_hash = $1;
+
+-- name: RevokeSession :one
+UPDATE sessions
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeOtherUserSessions :exec
+UPDATE sessions
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL;
+
+-- name: ListActiveUserSessions :many
+SELECT * FROM sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY last_seen_at DESC;
+
+-- name: TouchSessionLastSeen :exec
+UPDATE sessions
+SET last_seen_at = NOW()
+WHERE id = $1;
@@ -62,3 +62,19 @@ ORDER BY created_at DESC
 LIMIT COALESCE(NULLIF($3, 0), 10) 
 OFFSET COALESCE(NULLIF(($4 - 1) * COALESCE(NULLIF($3, 0), 10), -10), 0); 
 
+
+-- name: GetAllTasksByUserId :many
+SELECT * FROM tasks WHERE user_id = $1 ORDER BY created_at;
+
+-- name: DeleteUserTasks :execrows
+DELETE FROM tasks WHERE user_id = $1;
+
+-- name: AnonymizeUserTasks :execrows
+UPDATE tasks
+SET user_id = NULL, updated_at = NOW()
+WHERE user_id = $1;
+
+-- name: TransferUserTasks :execrows
+UPDATE tasks
+SET user_id = @to_user_id, updated_at = NOW()
+WHERE user_id = @from_user_id;
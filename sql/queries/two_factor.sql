@@ -0,0 +1,50 @@
+-- name: UpsertPendingUserTOTP :one
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, last_used_step = 0, updated_at = NOW()
+WHERE user_totp.enabled_at IS NULL
+RETURNING *;
+
+-- name: GetUserTOTP :one
+SELECT * FROM user_totp WHERE user_id = $1;
+
+-- name: EnableUserTOTP :one
+UPDATE user_totp
+SET enabled_at = NOW(), last_used_step = $2, updated_at = NOW()
+WHERE user_id = $1 AND enabled_at IS NULL
+RETURNING *;
+
+-- name: UseTOTPStep :one
+UPDATE user_totp
+SET last_used_step = $2, updated_at = NOW()
+WHERE user_id = $1 AND last_used_step < $2
+RETURNING *;
+
+-- name: DeleteUserTOTP :exec
+DELETE FROM user_totp WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (id, user_id, code_hash)
+VALUES ($1, $2, $3);
+
+-- name: DeleteUserRecoveryCodes :exec
+DELETE FROM recovery_codes WHERE user_id = $1;
+
+-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+RETURNING *;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: UseMFAToken :one
+INSERT INTO used_mfa_tokens (jti, user_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (jti) DO NOTHING
+RETURNING *;
+
+-- name: DeleteExpiredMFATokens :exec
+DELETE FROM used_mfa_tokens WHERE expires_at < NOW();
@@ -1,52 +1,36 @@
 -- name: CreateUser :one
-INSERT INTO users (id, username, password_hash, age, gender, role, organization_id, api_key) 
-VALUES ($1, $2, $3, $4, $5, COALESCE($6, 'user'), COALESCE($7, '00000000-0000-0000-0000-000000000000'),
-    encode(sha256(random()::text::bytea), 'hex')
-)
+INSERT INTO users (id, username, password_hash, age, gender, organization_id) 
+VALUES ($1, $2, $3, $4, $5, sqlc.narg(organization_id))
 RETURNING *;
 
 -- name: CreateUserWithPassword :one
-INSERT INTO users (id, username, password_hash, age, gender, role, organization_id, api_key) 
-VALUES ($1, $2, $3, $4, $5, COALESCE($6, 'user'), COALESCE($7, '00000000-0000-0000-0000-000000000000'),
-    encode(sha256(random()::text::bytea), 'hex')
-)
+INSERT INTO users (id, username, password_hash, age, gender, organization_id) 
+VALUES ($1, $2, $3, $4, $5, sqlc.narg(organization_id))
 RETURNING *;
 
--- name: GetAllUsers :many
-SELECT u.*, o.name as organization_name 
-FROM users u 
-LEFT JOIN organizations o ON u.organization_id = o.id;
+-- The role returned with a user is their role in their default organization
 
 -- name: GetUserByUsername :one
-SELECT u.*, o.name as organization_name 
+SELECT u.*, o.name as organization_name, m.role 
 FROM users u 
 LEFT JOIN organizations o ON u.organization_id = o.id 
+LEFT JOIN organization_members m ON m.organization_id = u.organization_id AND m.user_id = u.id
 WHERE u.username = $1;
 
 -- name: GetUserByUsernameAndPassword :one
-SELECT u.*, o.name as organization_name 
+SELECT u.*, o.name as organization_name, m.role 
 FROM users u 
 LEFT JOIN organizations o ON u.organization_id = o.id 
+LEFT JOIN organization_members m ON m.organization_id = u.organization_id AND m.user_id = u.id
 WHERE u.username = $1 AND u.password_hash = $2;
 
 -- name: GetUserByID :one
-SELECT u.*, o.name as organization_name 
+SELECT u.*, o.name as organization_name, m.role 
 FROM users u 
 LEFT JOIN organizations o ON u.organization_id = o.id 
+LEFT JOIN organization_members m ON m.organization_id = u.organization_id AND m.user_id = u.id
 WHERE u.id = $1;
 
--- name: GetUserByAPIKey :one
-SELECT u.*, o.name as organization_name 
-FROM users u 
-LEFT JOIN organizations o ON u.organization_id = o.id 
-WHERE u.api_key = $1;
-
--- name: GetUsersByOrganization :many
-SELECT u.*, o.name as organization_name 
-FROM users u 
-LEFT JOIN organizations o ON u.organization_id = o.id 
-WHERE u.organization_id = $1;
-
 -- name: UpdateUser :one
 UPDATE users
 SET username = COALESCE($2, username), 
@@ -62,12 +46,6 @@ SET password_hash = $2, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
--- name: UpdateUserRole :one
-UPDATE users
-SET role = $2, updated_at = NOW()
-WHERE id = $1
-RETURNING *;
-
 -- name: UpdateUserOrganization :one
 UPDATE users
 SET organization_id = $2, updated_at = NOW()
@@ -78,3 +56,14 @@ RETURNING *;
 DELETE FROM users WHERE id = $1
 RETURNING *;    
 
+
+-- name: RehashUserPassword :exec
+UPDATE users
+SET password_hash = @new_hash
+WHERE id = @id AND password_hash = @old_hash;
+
+-- name: ListUserPasswordHashes :many
+-- Service accounts have no password, so only human accounts are listed
+SELECT id, username, password_hash FROM users
+WHERE account_type = 'human'
+ORDER BY username;
@@ -0,0 +1,37 @@
+-- +goose Up
+-- Store only a display prefix and a SHA-256 hash of every API key
+ALTER TABLE api_keys
+ADD COLUMN key_prefix VARCHAR(12) NOT NULL DEFAULT '',
+ADD COLUMN key_hash VARCHAR(64);
+
+UPDATE api_keys
+SET key_prefix = substr(api_key, 1, 8),
+    key_hash = encode(sha256(api_key::bytea), 'hex');
+
+-- Carry each user's legacy key over as an unrestricted named key
+INSERT INTO api_keys (id, user_id, name, api_key, key_prefix, key_hash, scopes)
+SELECT gen_random_uuid(), id, 'Default key', api_key, substr(api_key, 1, 8),
+       encode(sha256(api_key::bytea), 'hex'), ARRAY['*']
+FROM users;
+
+ALTER TABLE api_keys
+ALTER COLUMN key_hash SET NOT NULL,
+ADD CONSTRAINT api_keys_key_hash_key UNIQUE (key_hash),
+DROP COLUMN api_key;
+
+ALTER TABLE users
+DROP COLUMN api_key;
+
+-- +goose Down
+-- Plaintext keys cannot be recovered; users get a fresh legacy key
+ALTER TABLE users
+ADD COLUMN api_key VARCHAR(64) UNIQUE NOT NULL DEFAULT (
+    encode(sha256(random()::text::bytea), 'hex')
+);
+
+ALTER TABLE api_keys
+ADD COLUMN api_key VARCHAR(64) UNIQUE NOT NULL DEFAULT (
+    encode(sha256(random()::text::bytea), 'hex')
+),
+DROP COLUMN key_prefix,
+DROP COLUMN key_hash;
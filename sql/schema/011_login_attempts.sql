@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE login_attempts (
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('username', 'ip')),
+    key VARCHAR(255) NOT NULL,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE TABLE auth_events (
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    username VARCHAR(255),
+    event_type VARCHAR(50) NOT NULL,
+    ip_address VARCHAR(45),
+    detail TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_auth_events_user_id ON auth_events(user_id);
+CREATE INDEX idx_auth_events_created_at ON auth_events(created_at);
+
+-- +goose Down
+DROP TABLE auth_events;
+DROP TABLE login_attempts;
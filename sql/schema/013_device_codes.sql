@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE device_codes (
+    id UUID PRIMARY KEY,
+    device_code_hash VARCHAR(64) NOT NULL UNIQUE,
+    user_code VARCHAR(16) NOT NULL UNIQUE,
+    client_id VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'denied', 'consumed')),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    poll_interval INTEGER NOT NULL,
+    last_polled_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE oidc_login_states
+ADD COLUMN device_code_id UUID REFERENCES device_codes(id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE oidc_login_states
+DROP COLUMN device_code_id;
+
+DROP TABLE device_codes;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE sessions
+ADD COLUMN client VARCHAR(100),
+ADD COLUMN ip_address VARCHAR(45),
+ADD COLUMN user_agent TEXT,
+ADD COLUMN last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE sessions SET last_seen_at = updated_at;
+
+-- +goose Down
+ALTER TABLE sessions
+DROP COLUMN client,
+DROP COLUMN ip_address,
+DROP COLUMN user_agent,
+DROP COLUMN last_seen_at;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN account_type VARCHAR(20) NOT NULL DEFAULT 'human' CHECK (account_type IN ('human', 'service')),
+ADD COLUMN disabled_at TIMESTAMP,
+ADD COLUMN created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_users_organization_account_type ON users(organization_id, account_type);
+
+-- +goose Down
+DROP INDEX idx_users_organization_account_type;
+
+ALTER TABLE users
+DROP COLUMN account_type,
+DROP COLUMN disabled_at,
+DROP COLUMN created_by;
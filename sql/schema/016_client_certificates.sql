@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE client_certificate_mappings (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    match_type VARCHAR(20) NOT NULL CHECK (match_type IN ('uri', 'dns', 'email', 'common_name')),
+    match_value VARCHAR(255) NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP,
+    UNIQUE (match_type, match_value)
+);
+
+CREATE INDEX idx_client_certificate_mappings_user_id ON client_certificate_mappings(user_id);
+
+-- +goose Down
+DROP TABLE client_certificate_mappings;
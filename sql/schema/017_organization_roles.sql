@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE organization_roles (
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    description TEXT,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (organization_id, name)
+);
+
+-- Roles are no longer a fixed list: besides the built-in roles, users can hold their organization's custom roles
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+
+-- +goose Down
+UPDATE users SET role = 'user' WHERE role NOT IN ('user', 'admin', 'moderator', 'owner');
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'admin', 'moderator', 'owner'));
+
+DROP TABLE organization_roles;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(50) NOT NULL DEFAULT 'user',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX idx_organization_members_user_id ON organization_members(user_id);
+
+-- Each user's organization and role become their first membership
+INSERT INTO organization_members (organization_id, user_id, role, created_at)
+SELECT users.organization_id, users.id, users.role, users.created_at
+FROM users
+JOIN organizations ON organizations.id = users.organization_id;
+
+-- Roles belong to memberships now; users.organization_id remains the organization used when a request selects none
+ALTER TABLE users DROP COLUMN role;
+
+-- +goose Down
+ALTER TABLE users ADD COLUMN role VARCHAR(50) NOT NULL DEFAULT 'user';
+
+UPDATE users
+SET role = organization_members.role
+FROM organization_members
+WHERE organization_members.user_id = users.id
+  AND organization_members.organization_id = users.organization_id;
+
+DROP TABLE organization_members;
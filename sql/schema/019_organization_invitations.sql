@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE organization_invitations (
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(50) NOT NULL,
+    username VARCHAR(50),
+    email VARCHAR(255),
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    declined_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    responded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_organization_invitations_organization_id ON organization_invitations(organization_id);
+
+-- +goose Down
+DROP TABLE organization_invitations;
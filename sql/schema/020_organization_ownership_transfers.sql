@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE organization_ownership_transfers (
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    from_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    cancelled_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_organization_ownership_transfers_organization_id ON organization_ownership_transfers(organization_id);
+
+-- +goose Down
+DROP TABLE organization_ownership_transfers;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- System administrators operate the whole installation, independent of any organization role
+ALTER TABLE users
+ADD COLUMN is_system_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Keys past their rotation deadline stop authenticating until they are rotated
+ALTER TABLE api_keys
+ADD COLUMN rotate_by TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE api_keys
+DROP COLUMN rotate_by;
+
+ALTER TABLE users
+DROP COLUMN is_system_admin;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Tasks can outlive their owner once anonymized; ON DELETE SET NULL could never work with NOT NULL
+ALTER TABLE tasks
+ALTER COLUMN user_id DROP NOT NULL;
+
+CREATE TABLE account_deletions (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    task_action VARCHAR(20) NOT NULL CHECK (task_action IN ('delete', 'anonymize', 'transfer')),
+    transfer_to UUID REFERENCES users(id) ON DELETE SET NULL,
+    scheduled_for TIMESTAMP NOT NULL,
+    requested_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_account_deletions_scheduled_for ON account_deletions(scheduled_for);
+
+-- +goose Down
+DROP TABLE account_deletions;
+
+DELETE FROM tasks WHERE user_id IS NULL;
+
+ALTER TABLE tasks
+ALTER COLUMN user_id SET NOT NULL;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- Audit events outlive the users and organizations they mention, so they carry no foreign keys
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY,
+    organization_id UUID,
+    actor_id UUID,
+    actor_username TEXT,
+    auth_method TEXT,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id UUID,
+    changes JSONB,
+    ip_address TEXT,
+    request_id TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_events_organization_id ON audit_events(organization_id, created_at);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at, id);
+
+-- +goose StatementBegin
+CREATE FUNCTION reject_audit_event_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_event_change();
+
+-- +goose Down
+DROP TABLE audit_events;
+DROP FUNCTION reject_audit_event_change();
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Token buckets shared by every instance; losing them on a crash only resets the limits
+CREATE UNLOGGED TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    idle_until TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_idle_until ON rate_limit_buckets(idle_until);
+
+-- +goose Down
+DROP TABLE rate_limit_buckets;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE retired_refresh_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    retired_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_retired_refresh_tokens_session_id ON retired_refresh_tokens(session_id);
+
+-- +goose Down
+DROP TABLE retired_refresh_tokens;
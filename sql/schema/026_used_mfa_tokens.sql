@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE used_mfa_tokens (
+    jti UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_used_mfa_tokens_expires_at ON used_mfa_tokens(expires_at);
+
+-- +goose Down
+DROP TABLE used_mfa_tokens;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Mappings belong to the organization whose admin created them, not to every organization the account is in
+ALTER TABLE client_certificate_mappings
+ADD COLUMN organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+
+UPDATE client_certificate_mappings c
+SET organization_id = COALESCE(
+    (SELECT u.organization_id FROM users u
+     JOIN organization_members m ON m.user_id = u.id AND m.organization_id = u.organization_id
+     WHERE u.id = c.user_id),
+    (SELECT m.organization_id FROM organization_members m
+     WHERE m.user_id = c.user_id
+     ORDER BY m.created_at
+     LIMIT 1)
+);
+
+-- Mappings of accounts without any membership cannot be managed by anyone
+DELETE FROM client_certificate_mappings WHERE organization_id IS NULL;
+
+ALTER TABLE client_certificate_mappings
+ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX idx_client_certificate_mappings_organization_id ON client_certificate_mappings(organization_id);
+
+-- +goose Down
+DROP INDEX idx_client_certificate_mappings_organization_id;
+
+ALTER TABLE client_certificate_mappings
+DROP COLUMN organization_id;